@@ -17,6 +17,7 @@ use crate::util::{collect_case, iter_procedures, iter_reducers, iter_table_names
 use crate::{CodegenOptions, Lang, OutputFile};
 
 const INDENT: &str = "\t";
+const SDK_TYPES_IMPORT: &str = "github.com/clockworklabs/spacetimedb/sdks/go/types";
 
 #[derive(Clone, Copy, Debug, Default)]
 pub struct Go;
@@ -75,7 +76,7 @@ impl Lang for Go {
 
         match ty {
             AlgebraicTypeDef::Product(product) => write_product_type(module, &mut output, &ty_name, product).unwrap(),
-            AlgebraicTypeDef::Sum(sum) => write_sum_type(&mut output, &ty_name, sum).unwrap(),
+            AlgebraicTypeDef::Sum(sum) => write_sum_type(module, &mut output, &ty_name, sum).unwrap(),
             AlgebraicTypeDef::PlainEnum(plain_enum) => {
                 write_plain_enum_type(&mut output, &ty_name, plain_enum).unwrap()
             }
@@ -145,6 +146,26 @@ impl Lang for Go {
     }
 
     fn generate_global_files(&self, module: &ModuleDef, options: &CodegenOptions) -> Vec<OutputFile> {
+        // Builtin types are kept apart from the client wrapper so that packages
+        // which only need the types do not also depend on the connection package.
+        let mut builtins = String::new();
+        print_go_header(&mut builtins, &[SDK_TYPES_IMPORT, "time"]);
+        writeln!(builtins, "type Result[T any, E any] struct {{");
+        writeln!(builtins, "{INDENT}Ok  *T");
+        writeln!(builtins, "{INDENT}Err *E");
+        writeln!(builtins, "}}");
+        writeln!(builtins);
+        write_sum(
+            module,
+            &mut builtins,
+            "ScheduleAt",
+            &[
+                ("Interval", &AlgebraicTypeUse::TimeDuration),
+                ("Time", &AlgebraicTypeUse::Timestamp),
+            ],
+        )
+        .unwrap();
+
         let mut client = String::new();
         print_go_header(
             &mut client,
@@ -154,16 +175,6 @@ impl Lang for Go {
                 "github.com/clockworklabs/spacetimedb/sdks/go/connection",
             ],
         );
-        writeln!(client, "type Result[T any, E any] struct {{");
-        writeln!(client, "{INDENT}Ok  *T");
-        writeln!(client, "{INDENT}Err *E");
-        writeln!(client, "}}");
-        writeln!(client);
-        writeln!(client, "type ScheduleAt struct {{");
-        writeln!(client, "{INDENT}Tag   string `json:\"tag\"`");
-        writeln!(client, "{INDENT}Value any    `json:\"value,omitempty\"`");
-        writeln!(client, "}}");
-        writeln!(client);
         writeln!(client, "type Client struct {{");
         writeln!(client, "{INDENT}conn *connection.Connection");
         writeln!(client, "}}");
@@ -258,6 +269,10 @@ impl Lang for Go {
         writeln!(schema, "}}");
 
         vec![
+            OutputFile {
+                filename: "builtin_types.go".to_string(),
+                code: builtins,
+            },
             OutputFile {
                 filename: "client.go".to_string(),
                 code: client,
@@ -318,20 +333,120 @@ fn write_product_type(module: &ModuleDef, out: &mut String, type_name: &str, pro
     writeln!(out, "}}")
 }
 
-fn write_sum_type(out: &mut String, type_name: &str, sum: &SumTypeDef) -> fmt::Result {
-    writeln!(out, "type {type_name}Tag string")?;
+fn write_sum_type(module: &ModuleDef, out: &mut String, type_name: &str, sum: &SumTypeDef) -> fmt::Result {
+    let variants = sum
+        .variants
+        .iter()
+        .map(|(name, ty)| (name.deref(), ty))
+        .collect::<Vec<_>>();
+    write_sum(module, out, type_name, &variants)
+}
+
+/// Writes a sum type as a struct embedding `types.Sum`, a sealed variant
+/// interface with one struct per variant, and exhaustive match helpers.
+fn write_sum(module: &ModuleDef, out: &mut String, type_name: &str, variants: &[(&str, &AlgebraicTypeUse)]) -> fmt::Result {
+    let schema_name = format!("{}Schema", type_name.to_case(Case::Camel));
+    let variant_iface = format!("{type_name}Variant");
+    let variants = variants
+        .iter()
+        .map(|(name, ty)| {
+            let mut payload = String::new();
+            if !matches!(ty, AlgebraicTypeUse::Unit) {
+                write_type(module, &mut payload, ty)?;
+            }
+            Ok((
+                *name,
+                format!("{type_name}{}", name.to_case(Case::Pascal)),
+                name.to_case(Case::Pascal),
+                payload,
+            ))
+        })
+        .collect::<Result<Vec<_>, fmt::Error>>()?;
+
+    writeln!(out, "type {type_name} struct {{")?;
+    writeln!(out, "{INDENT}types.Sum[{schema_name}]")?;
+    writeln!(out, "}}")?;
     writeln!(out)?;
-    writeln!(out, "const (")?;
-    for (variant_name, _) in &sum.variants {
-        let tag_name = format!("{type_name}Tag{}", variant_name.deref().to_case(Case::Pascal));
-        writeln!(out, "{INDENT}{tag_name} {type_name}Tag = \"{}\"", variant_name.deref())?;
+    writeln!(out, "type {variant_iface} interface {{")?;
+    writeln!(out, "{INDENT}types.SumVariant")?;
+    writeln!(out, "{INDENT}is{variant_iface}()")?;
+    writeln!(out, "}}")?;
+    for (_, struct_name, _, payload) in &variants {
+        writeln!(out)?;
+        if payload.is_empty() {
+            writeln!(out, "type {struct_name} struct{{}}")?;
+        } else {
+            writeln!(out, "type {struct_name} struct {{")?;
+            writeln!(out, "{INDENT}Value {payload}")?;
+            writeln!(out, "}}")?;
+        }
+    }
+    writeln!(out)?;
+    writeln!(out, "func New{type_name}(v {variant_iface}) {type_name} {{")?;
+    writeln!(out, "{INDENT}return {type_name}{{types.NewSum[{schema_name}](v)}}")?;
+    writeln!(out, "}}")?;
+    writeln!(out)?;
+    writeln!(out, "func (s {type_name}) Variant() {variant_iface} {{")?;
+    writeln!(out, "{INDENT}v, _ := s.Sum.Variant().({variant_iface})")?;
+    writeln!(out, "{INDENT}return v")?;
+    writeln!(out, "}}")?;
+    writeln!(out)?;
+    writeln!(out, "type {type_name}Visitor[R any] interface {{")?;
+    for (_, _, pascal, payload) in &variants {
+        writeln!(out, "{INDENT}Visit{pascal}({payload}) R")?;
+    }
+    writeln!(out, "}}")?;
+    writeln!(out)?;
+    writeln!(
+        out,
+        "func Visit{type_name}[R any](s {type_name}, visitor {type_name}Visitor[R]) R {{"
+    )?;
+    write!(out, "{INDENT}return Match{type_name}(s")?;
+    for (_, _, pascal, _) in &variants {
+        write!(out, ", visitor.Visit{pascal}")?;
     }
     writeln!(out, ")")?;
+    writeln!(out, "}}")?;
     writeln!(out)?;
-    writeln!(out, "type {type_name} struct {{")?;
-    writeln!(out, "{INDENT}Tag   {type_name}Tag `json:\"tag\"`")?;
-    writeln!(out, "{INDENT}Value any         `json:\"value,omitempty\"`")?;
-    writeln!(out, "}}")
+    write!(out, "func Match{type_name}[R any](s {type_name}")?;
+    for (_, _, pascal, payload) in &variants {
+        write!(out, ", on{pascal} func({payload}) R")?;
+    }
+    writeln!(out, ") R {{")?;
+    writeln!(out, "{INDENT}switch v := s.Variant().(type) {{")?;
+    for (_, struct_name, pascal, payload) in &variants {
+        writeln!(out, "{INDENT}case {struct_name}:")?;
+        if payload.is_empty() {
+            writeln!(out, "{INDENT}{INDENT}return on{pascal}()")?;
+        } else {
+            writeln!(out, "{INDENT}{INDENT}return on{pascal}(v.Value)")?;
+        }
+    }
+    writeln!(out, "{INDENT}}}")?;
+    writeln!(out, "{INDENT}var zero R")?;
+    writeln!(out, "{INDENT}return zero")?;
+    writeln!(out, "}}")?;
+    writeln!(out)?;
+    writeln!(out, "type {schema_name} struct{{}}")?;
+    writeln!(out)?;
+    writeln!(out, "func ({schema_name}) SumName() string {{ return \"{type_name}\" }}")?;
+    writeln!(out)?;
+    writeln!(out, "func ({schema_name}) SumVariants() []types.SumVariant {{")?;
+    writeln!(out, "{INDENT}return []types.SumVariant{{")?;
+    for (_, struct_name, _, _) in &variants {
+        writeln!(out, "{INDENT}{INDENT}{struct_name}{{}},")?;
+    }
+    writeln!(out, "{INDENT}}}")?;
+    writeln!(out, "}}")?;
+    for (wire_name, struct_name, _, _) in &variants {
+        writeln!(out)?;
+        writeln!(
+            out,
+            "func ({struct_name}) SumVariantName() string {{ return \"{wire_name}\" }}"
+        )?;
+        writeln!(out, "func ({struct_name}) is{variant_iface}() {{}}")?;
+    }
+    Ok(())
 }
 
 fn write_plain_enum_type(out: &mut String, type_name: &str, plain_enum: &PlainEnumTypeDef) -> fmt::Result {
@@ -353,7 +468,9 @@ fn write_type<W: Write>(module: &ModuleDef, out: &mut W, ty: &AlgebraicTypeUse)
     match ty {
         AlgebraicTypeUse::Unit => write!(out, "struct{{}}"),
         AlgebraicTypeUse::Never => write!(out, "any"),
-        AlgebraicTypeUse::Identity | AlgebraicTypeUse::ConnectionId | AlgebraicTypeUse::Uuid => write!(out, "string"),
+        AlgebraicTypeUse::Identity => write!(out, "types.Identity"),
+        AlgebraicTypeUse::ConnectionId => write!(out, "types.ConnectionId"),
+        AlgebraicTypeUse::Uuid => write!(out, "types.Uuid"),
         AlgebraicTypeUse::Timestamp => write!(out, "time.Time"),
         AlgebraicTypeUse::TimeDuration => write!(out, "time.Duration"),
         AlgebraicTypeUse::ScheduleAt => write!(out, "ScheduleAt"),
@@ -381,7 +498,10 @@ fn write_type<W: Write>(module: &ModuleDef, out: &mut W, ty: &AlgebraicTypeUse)
                 PrimitiveType::U32 => "uint32",
                 PrimitiveType::I64 => "int64",
                 PrimitiveType::U64 => "uint64",
-                PrimitiveType::I128 | PrimitiveType::U128 | PrimitiveType::I256 | PrimitiveType::U256 => "*big.Int",
+                PrimitiveType::I128 => "types.I128",
+                PrimitiveType::U128 => "types.U128",
+                PrimitiveType::I256 => "types.I256",
+                PrimitiveType::U256 => "types.U256",
                 PrimitiveType::F32 => "float32",
                 PrimitiveType::F64 => "float64",
             }
@@ -402,37 +522,35 @@ fn gather_imports_product(module: &ModuleDef, product: &ProductTypeDef, imports:
 }
 
 fn gather_imports_sum(module: &ModuleDef, sum: &SumTypeDef, imports: &mut Vec<String>) {
-    // Sum values are currently emitted as `any`, so variant payload types do not
-    // appear in generated code and should not contribute imports.
-    let _ = (module, sum, imports);
+    // Every sum embeds types.Sum, and each variant payload is a field of its
+    // variant struct.
+    imports.push(SDK_TYPES_IMPORT.to_string());
+    for (_, ty) in sum.variants.iter() {
+        gather_imports_type(module, ty, imports);
+    }
 }
 
 fn gather_imports_type(module: &ModuleDef, ty: &AlgebraicTypeUse, imports: &mut Vec<String>) {
     match ty {
         AlgebraicTypeUse::Timestamp | AlgebraicTypeUse::TimeDuration => imports.push("time".to_string()),
-        AlgebraicTypeUse::Primitive(PrimitiveType::I128)
+        AlgebraicTypeUse::Identity
+        | AlgebraicTypeUse::ConnectionId
+        | AlgebraicTypeUse::Uuid
+        | AlgebraicTypeUse::Primitive(PrimitiveType::I128)
         | AlgebraicTypeUse::Primitive(PrimitiveType::U128)
         | AlgebraicTypeUse::Primitive(PrimitiveType::I256)
-        | AlgebraicTypeUse::Primitive(PrimitiveType::U256) => imports.push("math/big".to_string()),
+        | AlgebraicTypeUse::Primitive(PrimitiveType::U256) => imports.push(SDK_TYPES_IMPORT.to_string()),
         AlgebraicTypeUse::Option(inner) | AlgebraicTypeUse::Array(inner) => gather_imports_type(module, inner, imports),
         AlgebraicTypeUse::Result { ok_ty, err_ty } => {
             gather_imports_type(module, ok_ty, imports);
             gather_imports_type(module, err_ty, imports);
         }
-        AlgebraicTypeUse::Ref(r) => {
-            // Nested references can contain types that require imports.
-            match &module.typespace_for_generate()[*r] {
-                AlgebraicTypeDef::Product(product) => gather_imports_product(module, product, imports),
-                AlgebraicTypeDef::Sum(sum) => gather_imports_sum(module, sum, imports),
-                AlgebraicTypeDef::PlainEnum(_) => {}
-            }
-        }
-        AlgebraicTypeUse::Unit
+        // Referenced types are declared in the same package under their own
+        // name, so whatever they import is not needed here.
+        AlgebraicTypeUse::Ref(_)
+        | AlgebraicTypeUse::Unit
         | AlgebraicTypeUse::Never
-        | AlgebraicTypeUse::Identity
-        | AlgebraicTypeUse::ConnectionId
         | AlgebraicTypeUse::ScheduleAt
-        | AlgebraicTypeUse::Uuid
         | AlgebraicTypeUse::Primitive(_)
         | AlgebraicTypeUse::String => {}
     }
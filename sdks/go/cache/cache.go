@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"bytes"
+	"maps"
 	"sync"
 	"sync/atomic"
 
@@ -8,34 +10,106 @@ import (
 )
 
 // Store holds client-side table state and applies transactions atomically.
+//
+// Rows are reference counted per query set, so a row matched by several
+// overlapping subscriptions stays cached until the last of them drops it.
 type Store struct {
 	writeMu sync.Mutex
 	state   atomic.Pointer[snapshot]
+
+	callbacks callbackRegistry
+}
+
+// TableChange lists the rows of one table that entered or left the cache,
+// that is the union of all query sets, in a transaction. A key that left and
+// entered again with different data, or whose data an insert replaced, is
+// reported once as an update.
+type TableChange struct {
+	Table   string
+	Inserts []sdktypes.Row
+	Deletes []sdktypes.Row
+	Updates []RowUpdate
+}
+
+// RowUpdate is a row whose data changed under the same key.
+type RowUpdate struct {
+	Old sdktypes.Row
+	New sdktypes.Row
 }
 
 type snapshot struct {
-	tables map[string]map[string][]byte
+	tables map[string]map[string]*entry
+	// schemas holds the tables declared with DefineTable. Schemas are never
+	// modified once stored, so snapshots share them.
+	schemas map[string]*tableSchema
+	// indexes holds the secondary indexes of each declared table by name.
+	indexes map[string]map[string]index
+}
+
+// entry is one cached row and how many times each query set holds it.
+type entry struct {
+	data []byte
+	refs map[uint32]int
 }
 
 func newSnapshot() *snapshot {
-	return &snapshot{tables: map[string]map[string][]byte{}}
+	return &snapshot{
+		tables:  map[string]map[string]*entry{},
+		schemas: map[string]*tableSchema{},
+		indexes: map[string]map[string]index{},
+	}
 }
 
+// cloneSnapshot copies the table and index sets of src. The tables and
+// indexes themselves are shared until a transaction writes to them.
 func cloneSnapshot(src *snapshot) *snapshot {
 	if src == nil {
 		return newSnapshot()
 	}
+	return &snapshot{
+		tables:  maps.Clone(src.tables),
+		schemas: maps.Clone(src.schemas),
+		indexes: maps.Clone(src.indexes),
+	}
+}
 
-	next := &snapshot{tables: make(map[string]map[string][]byte, len(src.tables))}
-	for tableName, rows := range src.tables {
-		clonedRows := make(map[string][]byte, len(rows))
-		for key, value := range rows {
-			clonedRows[key] = cloneBytes(value)
-		}
-		next.tables[tableName] = clonedRows
+func (e *entry) clone() *entry {
+	return &entry{data: e.data, refs: maps.Clone(e.refs)}
+}
+
+// tableWriter changes one table of a snapshot under construction. The table
+// is copied on the first write, and each of its entries is copied the first
+// time it changes, so the previous snapshot is left as it was.
+type tableWriter struct {
+	rows  map[string]*entry
+	owned map[string]bool
+}
+
+func newTableWriter(next *snapshot, table string) *tableWriter {
+	rows := maps.Clone(next.tables[table])
+	if rows == nil {
+		rows = map[string]*entry{}
 	}
+	next.tables[table] = rows
+	return &tableWriter{rows: rows, owned: map[string]bool{}}
+}
 
-	return next
+// mutable returns the entry stored under key, copying it first if it is
+// still shared with the previous snapshot.
+func (w *tableWriter) mutable(key string) (*entry, bool) {
+	row, ok := w.rows[key]
+	if !ok || w.owned[key] {
+		return row, ok
+	}
+	row = row.clone()
+	w.rows[key] = row
+	w.owned[key] = true
+	return row, true
+}
+
+func (w *tableWriter) insert(key string, row *entry) {
+	w.rows[key] = row
+	w.owned[key] = true
 }
 
 func cloneBytes(value []byte) []byte {
@@ -53,29 +127,136 @@ func NewStore() *Store {
 	return store
 }
 
-// ApplyTransaction applies a transaction as a single atomic state update.
-func (s *Store) ApplyTransaction(tx sdktypes.Transaction) {
+// ApplyTransaction applies a transaction as a single atomic state update and
+// returns, per table in order of first appearance, the rows that entered or
+// left the cache. Within a mutation deletes apply before inserts. An insert
+// of a row that another query set already holds, or a delete that leaves the
+// row held by another query set, is counted but not reported. A delete of a
+// row the query set does not hold is ignored. Rows are keyed as described
+// by DefineTable, and the indexes it declares are updated from the net
+// change of each row.
+//
+// ApplyTransaction does not run row callbacks; pass the changes to Dispatch.
+func (s *Store) ApplyTransaction(tx sdktypes.Transaction) []TableChange {
 	s.writeMu.Lock()
 	defer s.writeMu.Unlock()
 
 	next := cloneSnapshot(s.state.Load())
+	touched := touchedRows{}
+
+	var changes []*tableChanges
+	changeIndex := map[string]*tableChanges{}
+	change := func(table string) *tableChanges {
+		c, ok := changeIndex[table]
+		if !ok {
+			c = &tableChanges{TableChange: TableChange{Table: table}, deleted: map[string]int{}}
+			changeIndex[table] = c
+			changes = append(changes, c)
+		}
+		return c
+	}
 
+	writers := map[string]*tableWriter{}
 	for _, tableMutation := range tx.Tables {
-		rows, ok := next.tables[tableMutation.Table]
+		w, ok := writers[tableMutation.Table]
 		if !ok {
-			rows = map[string][]byte{}
-			next.tables[tableMutation.Table] = rows
+			w = newTableWriter(next, tableMutation.Table)
+			writers[tableMutation.Table] = w
 		}
+		schema := next.schemas[tableMutation.Table]
 
-		for _, key := range tableMutation.Deletes {
-			delete(rows, key)
+		for _, deleted := range tableMutation.Deletes {
+			key := schema.rowKey(deleted)
+			if row, ok := w.rows[key]; !ok || row.refs[tableMutation.QuerySet] == 0 {
+				continue
+			}
+			touched.touch(schema, tableMutation.Table, key, w.rows)
+			row, _ := w.mutable(key)
+			if row.refs[tableMutation.QuerySet]--; row.refs[tableMutation.QuerySet] == 0 {
+				delete(row.refs, tableMutation.QuerySet)
+			}
+			if len(row.refs) == 0 {
+				delete(w.rows, key)
+				change(tableMutation.Table).delete(sdktypes.Row{Key: key, Data: row.data})
+			}
 		}
-		for _, row := range tableMutation.Inserts {
-			rows[row.Key] = cloneBytes(row.Data)
+		for _, insert := range tableMutation.Inserts {
+			key := schema.rowKey(insert)
+			touched.touch(schema, tableMutation.Table, key, w.rows)
+			row, ok := w.mutable(key)
+			switch {
+			case !ok:
+				row = &entry{data: cloneBytes(insert.Data), refs: map[uint32]int{}}
+				w.insert(key, row)
+				change(tableMutation.Table).insert(sdktypes.Row{Key: key, Data: row.data})
+			case !bytes.Equal(row.data, insert.Data):
+				old := row.data
+				row.data = cloneBytes(insert.Data)
+				change(tableMutation.Table).update(sdktypes.Row{Key: key, Data: old}, sdktypes.Row{Key: key, Data: row.data})
+			}
+			row.refs[tableMutation.QuerySet]++
 		}
 	}
 
+	next.reindex(touched)
 	s.state.Store(next)
+
+	out := make([]TableChange, 0, len(changes))
+	for _, c := range changes {
+		if c := c.result(); c.Inserts != nil || c.Deletes != nil || c.Updates != nil {
+			out = append(out, c)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// tableChanges accumulates the TableChange of one table, pairing a delete
+// with a later insert of the same key.
+type tableChanges struct {
+	TableChange
+	// deleted maps the key of each reported delete to its index in Deletes.
+	deleted map[string]int
+	dropped []bool
+}
+
+func (c *tableChanges) delete(row sdktypes.Row) {
+	c.deleted[row.Key] = len(c.Deletes)
+	c.Deletes = append(c.Deletes, cloneRow(row))
+	c.dropped = append(c.dropped, false)
+}
+
+func (c *tableChanges) insert(row sdktypes.Row) {
+	i, ok := c.deleted[row.Key]
+	if !ok {
+		c.Inserts = append(c.Inserts, cloneRow(row))
+		return
+	}
+	delete(c.deleted, row.Key)
+	c.dropped[i] = true
+	if !bytes.Equal(c.Deletes[i].Data, row.Data) {
+		c.Updates = append(c.Updates, RowUpdate{Old: c.Deletes[i], New: cloneRow(row)})
+	}
+}
+
+func (c *tableChanges) update(old, new sdktypes.Row) {
+	c.Updates = append(c.Updates, RowUpdate{Old: cloneRow(old), New: cloneRow(new)})
+}
+
+func (c *tableChanges) result() TableChange {
+	out := TableChange{Table: c.Table, Inserts: c.Inserts, Updates: c.Updates}
+	for i, row := range c.Deletes {
+		if !c.dropped[i] {
+			out.Deletes = append(out.Deletes, row)
+		}
+	}
+	return out
+}
+
+func cloneRow(row sdktypes.Row) sdktypes.Row {
+	return sdktypes.Row{Key: row.Key, Data: cloneBytes(row.Data)}
 }
 
 func (s *Store) Get(table, key string) ([]byte, bool) {
@@ -91,7 +272,24 @@ func (s *Store) Get(table, key string) ([]byte, bool) {
 	if !ok {
 		return nil, false
 	}
-	return cloneBytes(value), true
+	return cloneBytes(value.data), true
+}
+
+// RefCount returns how many times the query sets hold a row in total.
+func (s *Store) RefCount(table, key string) int {
+	current := s.state.Load()
+	if current == nil {
+		return 0
+	}
+	value, ok := current.tables[table][key]
+	if !ok {
+		return 0
+	}
+	total := 0
+	for _, n := range value.refs {
+		total += n
+	}
+	return total
 }
 
 // TableSnapshot returns a copy of all rows in one table keyed by row key.
@@ -108,7 +306,7 @@ func (s *Store) TableSnapshot(table string) map[string][]byte {
 
 	out := make(map[string][]byte, len(rows))
 	for key, value := range rows {
-		out[key] = cloneBytes(value)
+		out[key] = cloneBytes(value.data)
 	}
 	return out
 }
@@ -124,7 +322,7 @@ func (s *Store) Snapshot() map[string]map[string][]byte {
 	for tableName, rows := range current.tables {
 		rowsCopy := make(map[string][]byte, len(rows))
 		for key, value := range rows {
-			rowsCopy[key] = cloneBytes(value)
+			rowsCopy[key] = cloneBytes(value.data)
 		}
 		out[tableName] = rowsCopy
 	}
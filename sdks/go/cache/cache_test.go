@@ -2,6 +2,8 @@ package cache
 
 import (
 	"bytes"
+	"reflect"
+	"slices"
 	"testing"
 
 	sdktypes "github.com/clockworklabs/spacetimedb/sdks/go/types"
@@ -29,7 +31,7 @@ func TestApplyTransactionAtomicallyPublishesState(t *testing.T) {
 	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
 		{
 			Table:   "users",
-			Deletes: []string{"u1"},
+			Deletes: []sdktypes.Row{{Key: "u1"}},
 			Inserts: []sdktypes.Row{{
 				Key:  "u2",
 				Data: []byte("bob"),
@@ -98,3 +100,117 @@ func TestReturnedDataIsDefensivelyCopied(t *testing.T) {
 		t.Fatalf("table snapshot leaked mutable backing array: %q", string(value3))
 	}
 }
+
+func TestOverlappingQuerySetsShareRows(t *testing.T) {
+	store := NewStore()
+	insert := func(querySet uint32, keys ...string) []TableChange {
+		mutation := sdktypes.TableMutation{Table: "users", QuerySet: querySet}
+		for _, key := range keys {
+			mutation.Inserts = append(mutation.Inserts, sdktypes.Row{Key: key, Data: []byte(key)})
+		}
+		return store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{mutation}})
+	}
+	remove := func(querySet uint32, keys ...string) []TableChange {
+		mutation := sdktypes.TableMutation{Table: "users", QuerySet: querySet}
+		for _, key := range keys {
+			mutation.Deletes = append(mutation.Deletes, sdktypes.Row{Key: key, Data: []byte(key)})
+		}
+		return store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{mutation}})
+	}
+	keys := func(rows []sdktypes.Row) []string {
+		out := make([]string, len(rows))
+		for i, row := range rows {
+			out[i] = row.Key
+		}
+		return out
+	}
+
+	insert(1, "a", "b")
+	changes := insert(2, "b", "c")
+	if len(changes) != 1 || !slices.Equal(keys(changes[0].Inserts), []string{"c"}) {
+		t.Fatalf("expected only c to enter the cache, got %+v", changes)
+	}
+	if got := store.RefCount("users", "b"); got != 2 {
+		t.Fatalf("expected b to be held twice, got %d", got)
+	}
+
+	if changes := remove(3, "a"); len(changes) != 0 {
+		t.Fatalf("expected a delete from a query set without the row to be ignored, got %+v", changes)
+	}
+	changes = remove(1, "a", "b")
+	if len(changes) != 1 || !slices.Equal(keys(changes[0].Deletes), []string{"a"}) || len(changes[0].Inserts) != 0 {
+		t.Fatalf("expected only a to leave the cache, got %+v", changes)
+	}
+	if _, ok := store.Get("users", "b"); !ok {
+		t.Fatalf("expected b to stay cached while query set 2 holds it")
+	}
+
+	changes = remove(2, "b")
+	if len(changes) != 1 || !slices.Equal(keys(changes[0].Deletes), []string{"b"}) {
+		t.Fatalf("expected b to leave the cache with its last query set, got %+v", changes)
+	}
+	if !bytes.Equal(changes[0].Deletes[0].Data, []byte("b")) {
+		t.Fatalf("expected the deleted row data to be reported, got %q", changes[0].Deletes[0].Data)
+	}
+}
+
+func TestApplyTransactionReportsUpdatesByKey(t *testing.T) {
+	store := NewStore()
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table:   "users",
+		Inserts: []sdktypes.Row{{Key: "u1", Data: []byte("alice")}, {Key: "u2", Data: []byte("bob")}},
+	}}})
+
+	changes := store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table:   "users",
+		Deletes: []sdktypes.Row{{Key: "u1"}, {Key: "u2"}},
+		Inserts: []sdktypes.Row{{Key: "u1", Data: []byte("alicia")}, {Key: "u2", Data: []byte("bob")}},
+	}}})
+	want := []TableChange{{Table: "users", Updates: []RowUpdate{{
+		Old: sdktypes.Row{Key: "u1", Data: []byte("alice")},
+		New: sdktypes.Row{Key: "u1", Data: []byte("alicia")},
+	}}}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+
+	// An insert that replaces the data of a row another query set holds is an update too.
+	changes = store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table:    "users",
+		QuerySet: 1,
+		Inserts:  []sdktypes.Row{{Key: "u2", Data: []byte("robert")}},
+	}}})
+	if len(changes) != 1 || len(changes[0].Updates) != 1 || string(changes[0].Updates[0].New.Data) != "robert" {
+		t.Fatalf("expected an update of u2, got %+v", changes)
+	}
+	if value, _ := store.Get("users", "u2"); string(value) != "robert" {
+		t.Fatalf("expected the cache to hold the new data, got %q", value)
+	}
+}
+
+func TestApplyTransactionCopiesOnlyWhatItChanges(t *testing.T) {
+	store := NewStore()
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
+		{Table: "users", QuerySet: 1, Inserts: []sdktypes.Row{{Key: "u1", Data: []byte("alice")}, {Key: "u2", Data: []byte("bob")}}},
+		{Table: "items", QuerySet: 1, Inserts: []sdktypes.Row{{Key: "i1", Data: []byte("sword")}}},
+	}})
+	before := store.state.Load()
+
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
+		{Table: "users", QuerySet: 2, Inserts: []sdktypes.Row{{Key: "u1", Data: []byte("alice")}}},
+	}})
+	after := store.state.Load()
+
+	if reflect.ValueOf(before.tables["items"]).UnsafePointer() != reflect.ValueOf(after.tables["items"]).UnsafePointer() {
+		t.Fatalf("expected the untouched table to be shared between snapshots")
+	}
+	if before.tables["users"]["u2"] != after.tables["users"]["u2"] {
+		t.Fatalf("expected the unchanged row to be shared between snapshots")
+	}
+	if got := len(before.tables["users"]["u1"].refs); got != 1 {
+		t.Fatalf("expected the earlier snapshot to keep one query set on u1, got %d", got)
+	}
+	if got := len(after.tables["users"]["u1"].refs); got != 2 {
+		t.Fatalf("expected two query sets on u1, got %d", got)
+	}
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/events"
+	sdktypes "github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+// CallbackID identifies a registered row callback for RemoveCallback.
+type CallbackID uint64
+
+// InsertCallback receives a row that entered the cache.
+type InsertCallback func(event events.Event, row sdktypes.Row)
+
+// DeleteCallback receives a row that left the cache.
+type DeleteCallback func(event events.Event, row sdktypes.Row)
+
+// UpdateCallback receives a row whose data changed under the same key.
+type UpdateCallback func(event events.Event, old, new sdktypes.Row)
+
+type callbackRegistry struct {
+	mu     sync.Mutex
+	nextID CallbackID
+	tables map[string][]registeredCallback
+}
+
+type registeredCallback struct {
+	id       CallbackID
+	onInsert InsertCallback
+	onDelete DeleteCallback
+	onUpdate UpdateCallback
+}
+
+// OnInsert registers fn to run for every row that enters table.
+func (s *Store) OnInsert(table string, fn InsertCallback) CallbackID {
+	return s.callbacks.add(table, registeredCallback{onInsert: fn})
+}
+
+// OnDelete registers fn to run for every row that leaves table.
+func (s *Store) OnDelete(table string, fn DeleteCallback) CallbackID {
+	return s.callbacks.add(table, registeredCallback{onDelete: fn})
+}
+
+// OnUpdate registers fn to run for every row of table whose data changes.
+func (s *Store) OnUpdate(table string, fn UpdateCallback) CallbackID {
+	return s.callbacks.add(table, registeredCallback{onUpdate: fn})
+}
+
+// RemoveCallback unregisters a row callback and reports whether it was
+// registered. It is safe to call from a callback.
+func (s *Store) RemoveCallback(id CallbackID) bool {
+	s.callbacks.mu.Lock()
+	defer s.callbacks.mu.Unlock()
+	for table, registered := range s.callbacks.tables {
+		i := slices.IndexFunc(registered, func(cb registeredCallback) bool { return cb.id == id })
+		if i < 0 {
+			continue
+		}
+		// Dispatch iterates a copy, so the slice must not be edited in place.
+		s.callbacks.tables[table] = slices.Delete(slices.Clone(registered), i, i+1)
+		return true
+	}
+	return false
+}
+
+// Dispatch runs the row callbacks for changes returned by ApplyTransaction,
+// table by table: deletes first, then updates, then inserts, each in
+// registration order. Callbacks registered or removed while Dispatch runs
+// take effect from the next call.
+//
+// Dispatch runs callbacks on the calling goroutine. The cache already holds
+// the whole transaction when they run.
+func (s *Store) Dispatch(event events.Event, changes []TableChange) {
+	s.callbacks.mu.Lock()
+	byTable := make(map[string][]registeredCallback, len(changes))
+	for _, change := range changes {
+		byTable[change.Table] = s.callbacks.tables[change.Table]
+	}
+	s.callbacks.mu.Unlock()
+
+	for _, change := range changes {
+		registered := byTable[change.Table]
+		if len(registered) == 0 {
+			continue
+		}
+
+		for _, row := range change.Deletes {
+			for _, cb := range registered {
+				if cb.onDelete != nil {
+					cb.onDelete(event, cloneRow(row))
+				}
+			}
+		}
+		for _, update := range change.Updates {
+			for _, cb := range registered {
+				if cb.onUpdate != nil {
+					cb.onUpdate(event, cloneRow(update.Old), cloneRow(update.New))
+				}
+			}
+		}
+		for _, row := range change.Inserts {
+			for _, cb := range registered {
+				if cb.onInsert != nil {
+					cb.onInsert(event, cloneRow(row))
+				}
+			}
+		}
+	}
+}
+
+func (r *callbackRegistry) add(table string, cb registeredCallback) CallbackID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tables == nil {
+		r.tables = map[string][]registeredCallback{}
+	}
+	r.nextID++
+	cb.id = r.nextID
+	// Copy so that a concurrent Dispatch keeps iterating the old slice.
+	r.tables[table] = append(slices.Clone(r.tables[table]), cb)
+	return cb.id
+}
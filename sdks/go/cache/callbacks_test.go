@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/events"
+	sdktypes "github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+func TestDispatchRunsTableCallbacksInOrder(t *testing.T) {
+	store := NewStore()
+	var got []string
+	store.OnInsert("users", func(ev events.Event, row sdktypes.Row) {
+		got = append(got, ev.Kind.String()+" insert "+string(row.Data))
+	})
+	store.OnDelete("users", func(ev events.Event, row sdktypes.Row) {
+		got = append(got, ev.Kind.String()+" delete "+string(row.Data))
+	})
+	store.OnUpdate("users", func(ev events.Event, old, new sdktypes.Row) {
+		got = append(got, ev.Kind.String()+" update "+string(old.Data)+"->"+string(new.Data))
+	})
+	store.OnInsert("teams", func(events.Event, sdktypes.Row) {
+		t.Fatalf("unexpected callback for another table")
+	})
+
+	apply := func(ev events.Event, mutation sdktypes.TableMutation) {
+		store.Dispatch(ev, store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{mutation}}))
+	}
+	apply(events.Event{Kind: events.KindSubscribeApplied}, sdktypes.TableMutation{
+		Table:   "users",
+		Inserts: []sdktypes.Row{{Key: "1", Data: []byte("alice")}, {Key: "2", Data: []byte("bob")}},
+	})
+	apply(events.Event{Kind: events.KindReducer, Reducer: &events.ReducerEvent{Name: "rename"}}, sdktypes.TableMutation{
+		Table:   "users",
+		Deletes: []sdktypes.Row{{Key: "1"}, {Key: "2"}},
+		Inserts: []sdktypes.Row{{Key: "1", Data: []byte("alicia")}, {Key: "3", Data: []byte("carol")}},
+	})
+
+	want := []string{
+		"subscribe_applied insert alice",
+		"subscribe_applied insert bob",
+		"reducer delete bob",
+		"reducer update alice->alicia",
+		"reducer insert carol",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected callbacks:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestRemoveCallback(t *testing.T) {
+	store := NewStore()
+	calls := 0
+	var second CallbackID
+	first := store.OnInsert("users", func(events.Event, sdktypes.Row) {
+		calls++
+		// Removing a callback mid-dispatch takes effect from the next dispatch.
+		store.RemoveCallback(second)
+	})
+	second = store.OnInsert("users", func(events.Event, sdktypes.Row) { calls++ })
+
+	insert := func(key string) {
+		store.Dispatch(events.Event{}, store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+			Table: "users", Inserts: []sdktypes.Row{{Key: key, Data: []byte(key)}},
+		}}}))
+	}
+	insert("a")
+	if calls != 2 {
+		t.Fatalf("expected both callbacks for the first insert, got %d calls", calls)
+	}
+	insert("b")
+	if calls != 3 {
+		t.Fatalf("expected only the first callback after removal, got %d calls", calls)
+	}
+	if !store.RemoveCallback(first) || store.RemoveCallback(first) {
+		t.Fatalf("expected RemoveCallback to report whether the callback was registered")
+	}
+	insert("c")
+	if calls != 3 {
+		t.Fatalf("expected no callbacks after removing both, got %d calls", calls)
+	}
+}
@@ -0,0 +1,450 @@
+package cache
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"maps"
+	"slices"
+	"strings"
+
+	sdktypes "github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+// IndexSchema declares a secondary index over one or more columns of a
+// table. A unique index maps each combination of column values to at most
+// one row and serves FindUnique. Any other index is a B-tree index, which
+// keeps rows ordered by their column values and serves Filter.
+type IndexSchema struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// Range selects the rows of a B-tree index whose column values lie between
+// Lower and Upper. A nil bound leaves that end open.
+type Range struct {
+	Lower *Bound
+	Upper *Bound
+}
+
+// Bound is one end of a Range. Values is a prefix of the index columns, so a
+// bound on the first column of a multi-column index matches every row whose
+// first column equals it, whatever the other columns hold.
+type Bound struct {
+	Values    []sdktypes.AlgebraicValue
+	Inclusive bool
+}
+
+// Inclusive returns a bound that includes rows equal to values.
+func Inclusive(values ...sdktypes.AlgebraicValue) *Bound {
+	return &Bound{Values: values, Inclusive: true}
+}
+
+// Exclusive returns a bound that excludes rows equal to values.
+func Exclusive(values ...sdktypes.AlgebraicValue) *Bound {
+	return &Bound{Values: values}
+}
+
+// Equal returns the range of rows whose leading index columns equal values.
+func Equal(values ...sdktypes.AlgebraicValue) Range {
+	return Range{Lower: Inclusive(values...), Upper: Inclusive(values...)}
+}
+
+// indexSchema is a validated IndexSchema.
+type indexSchema struct {
+	columns []int
+	types   []sdktypes.AlgebraicType
+	unique  bool
+}
+
+// index holds the entries of one index in one snapshot. Only the tree
+// matching the kind of the index is used. Indexes are persistent, so
+// snapshots share them and a change returns a new index.
+type index struct {
+	// unique maps the encoded column values to the keys of the rows.
+	unique treap[uniqueEntry]
+	// ordered is sorted by column values, then by row key.
+	ordered treap[indexEntry]
+}
+
+// uniqueEntry is ordered by encoded value, then by row key.
+type uniqueEntry struct {
+	encoded string
+	key     string
+}
+
+type indexEntry struct {
+	values []sdktypes.AlgebraicValue
+	key    string
+}
+
+// prioritySeed seeds the treap priorities of index entries.
+var prioritySeed = maphash.MakeSeed()
+
+func newIndex(schema *indexSchema) index {
+	if schema.unique {
+		return index{unique: newTreap(
+			func(a, b uniqueEntry) int {
+				return cmp.Or(strings.Compare(a.encoded, b.encoded), strings.Compare(a.key, b.key))
+			},
+			func(e uniqueEntry) uint64 { return maphash.String(prioritySeed, e.key) },
+		)}
+	}
+	return index{ordered: newTreap(
+		compareEntries,
+		func(e indexEntry) uint64 { return maphash.String(prioritySeed, e.key) },
+	)}
+}
+
+// FindUnique returns the cached row of table whose columns in the unique
+// index equal values, given in column order. It reports false if no row
+// matches, and an error if table or index is not defined, index is not
+// unique, or values do not match the index columns.
+func (s *Store) FindUnique(table, index string, values ...sdktypes.AlgebraicValue) ([]byte, bool, error) {
+	current := s.state.Load()
+	schema, indexSchema, err := current.lookupIndex(table, index)
+	if err != nil {
+		return nil, false, fmt.Errorf("find unique %s.%s: %w", table, index, err)
+	}
+	if !indexSchema.unique {
+		return nil, false, fmt.Errorf("find unique %s.%s: index is not unique", table, index)
+	}
+	if len(values) != len(indexSchema.columns) {
+		return nil, false, fmt.Errorf("find unique %s.%s: %d values for %d columns", table, index, len(values), len(indexSchema.columns))
+	}
+	encoded, err := schema.encodeIndexKey(indexSchema, values)
+	if err != nil {
+		return nil, false, fmt.Errorf("find unique %s.%s: %w", table, index, err)
+	}
+	key, ok := current.indexes[table][index].findUnique(encoded)
+	if !ok {
+		return nil, false, nil
+	}
+	return cloneBytes(current.tables[table][key].data), true, nil
+}
+
+// Filter returns the cached rows of table whose columns in the B-tree index
+// lie within r, ordered by those columns and then by row key. It returns an
+// error if table or index is not defined, index is unique, or a bound does
+// not match the index columns.
+func (s *Store) Filter(table, index string, r Range) ([][]byte, error) {
+	current := s.state.Load()
+	schema, indexSchema, err := current.lookupIndex(table, index)
+	if err != nil {
+		return nil, fmt.Errorf("filter %s.%s: %w", table, index, err)
+	}
+	if indexSchema.unique {
+		return nil, fmt.Errorf("filter %s.%s: index is unique, use FindUnique", table, index)
+	}
+	lower, err := schema.normalizeBound(indexSchema, r.Lower)
+	if err != nil {
+		return nil, fmt.Errorf("filter %s.%s: lower bound: %w", table, index, err)
+	}
+	upper, err := schema.normalizeBound(indexSchema, r.Upper)
+	if err != nil {
+		return nil, fmt.Errorf("filter %s.%s: upper bound: %w", table, index, err)
+	}
+
+	atOrAfter := func(indexEntry) bool { return true }
+	if lower != nil {
+		atOrAfter = func(e indexEntry) bool {
+			c := comparePrefix(e.values, lower.Values)
+			return c > 0 || c == 0 && lower.Inclusive
+		}
+	}
+	rows := current.tables[table]
+	var out [][]byte
+	current.indexes[table][index].ordered.ascend(atOrAfter, func(e indexEntry) bool {
+		if upper != nil {
+			c := comparePrefix(e.values, upper.Values)
+			if c > 0 || c == 0 && !upper.Inclusive {
+				return false
+			}
+		}
+		out = append(out, cloneBytes(rows[e.key].data))
+		return true
+	})
+	return out, nil
+}
+
+// lookupIndex returns the schemas of a declared index.
+func (s *snapshot) lookupIndex(table, index string) (*tableSchema, *indexSchema, error) {
+	var schema *tableSchema
+	if s != nil {
+		schema = s.schemas[table]
+	}
+	if schema == nil {
+		return nil, nil, errors.New("table is not defined")
+	}
+	indexSchema := schema.indexes[index]
+	if indexSchema == nil {
+		return nil, nil, errors.New("no such index")
+	}
+	return schema, indexSchema, nil
+}
+
+// findUnique returns the key of the row with the encoded unique value. If
+// several rows hold it, the one with the lowest key is returned.
+func (x index) findUnique(encoded string) (string, bool) {
+	var (
+		key   string
+		found bool
+	)
+	x.unique.ascend(func(e uniqueEntry) bool { return e.encoded >= encoded }, func(e uniqueEntry) bool {
+		key, found = e.key, e.encoded == encoded
+		return false
+	})
+	return key, found
+}
+
+// add returns x with the row stored under key indexed by the decoded
+// values. Rows that share a unique value, which the server does not allow,
+// are all kept so that each stays findable once the others leave.
+func (x index) add(schema *indexSchema, ts *sdktypes.Typespace, key string, row sdktypes.ProductValue) index {
+	values := indexValues(schema, row)
+	if schema.unique {
+		if encoded, err := encodeValues(ts, values); err == nil {
+			x.unique = x.unique.insert(uniqueEntry{encoded: encoded, key: key})
+		}
+		return x
+	}
+	x.ordered = x.ordered.insert(indexEntry{values: values, key: key})
+	return x
+}
+
+// remove returns x without the row stored under key with the decoded values.
+func (x index) remove(schema *indexSchema, ts *sdktypes.Typespace, key string, row sdktypes.ProductValue) index {
+	values := indexValues(schema, row)
+	if schema.unique {
+		if encoded, err := encodeValues(ts, values); err == nil {
+			x.unique = x.unique.delete(uniqueEntry{encoded: encoded, key: key})
+		}
+		return x
+	}
+	x.ordered = x.ordered.delete(indexEntry{values: values, key: key})
+	return x
+}
+
+func indexValues(schema *indexSchema, row sdktypes.ProductValue) []sdktypes.AlgebraicValue {
+	values := make([]sdktypes.AlgebraicValue, len(schema.columns))
+	for i, column := range schema.columns {
+		values[i] = row.Elements[column]
+	}
+	return values
+}
+
+func encodeValues(ts *sdktypes.Typespace, values []sdktypes.AlgebraicValue) (string, error) {
+	var b strings.Builder
+	for _, value := range values {
+		encoded, err := ts.EncodeValue(value)
+		if err != nil {
+			return "", err
+		}
+		b.Write(encoded)
+	}
+	return b.String(), nil
+}
+
+// encodeIndexKey encodes caller-supplied values as the columns of index.
+func (s *tableSchema) encodeIndexKey(index *indexSchema, values []sdktypes.AlgebraicValue) (string, error) {
+	typed := make([]sdktypes.AlgebraicValue, len(values))
+	for i, value := range values {
+		typed[i] = sdktypes.AlgebraicValue{Type: index.types[i], Value: value.Value}
+	}
+	return encodeValues(s.typespace, typed)
+}
+
+// normalizeBound converts the values of a caller-supplied bound to the form
+// decoded rows have, so that they compare with the index entries.
+func (s *tableSchema) normalizeBound(index *indexSchema, b *Bound) (*Bound, error) {
+	if b == nil {
+		return nil, nil
+	}
+	if len(b.Values) > len(index.columns) {
+		return nil, fmt.Errorf("bound has %d values for %d columns", len(b.Values), len(index.columns))
+	}
+	out := &Bound{Values: make([]sdktypes.AlgebraicValue, len(b.Values)), Inclusive: b.Inclusive}
+	for i, value := range b.Values {
+		encoded, err := s.typespace.EncodeValue(sdktypes.AlgebraicValue{Type: index.types[i], Value: value.Value})
+		if err != nil {
+			return nil, err
+		}
+		if out.Values[i], err = s.typespace.DecodeValue(index.types[i], encoded); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// rowBefore is the data a row had before a transaction first touched it.
+type rowBefore struct {
+	data    []byte
+	present bool
+}
+
+// touchedRows records, by table and row key, the rows of indexed tables a
+// transaction changed, so their indexes can be updated from the net change.
+type touchedRows map[string]map[string]rowBefore
+
+func (t touchedRows) touch(schema *tableSchema, table, key string, rows map[string]*entry) {
+	if schema == nil || len(schema.indexes) == 0 {
+		return
+	}
+	keys := t[table]
+	if keys == nil {
+		keys = map[string]rowBefore{}
+		t[table] = keys
+	}
+	if _, ok := keys[key]; ok {
+		return
+	}
+	row, ok := rows[key]
+	if !ok {
+		keys[key] = rowBefore{}
+		return
+	}
+	keys[key] = rowBefore{data: row.data, present: true}
+}
+
+// reindex updates the indexes of next for the touched rows. The indexes of
+// each touched table are replaced, leaving those of the previous snapshot
+// as they were. Every old entry is removed before any new one is added, so a
+// unique value may move from one row to another within a transaction.
+func (next *snapshot) reindex(touched touchedRows) {
+	for table, keys := range touched {
+		schema := next.schemas[table]
+		indexes := maps.Clone(next.indexes[table])
+		next.indexes[table] = indexes
+		rows := next.tables[table]
+		var added []string
+		for key, before := range keys {
+			after, present := rows[key]
+			if before.present && present && bytes.Equal(before.data, after.data) {
+				continue
+			}
+			if before.present {
+				if row, ok := schema.decodeRow(before.data); ok {
+					for name, x := range indexes {
+						indexes[name] = x.remove(schema.indexes[name], schema.typespace, key, row)
+					}
+				}
+			}
+			if present {
+				added = append(added, key)
+			}
+		}
+		for _, key := range added {
+			row, ok := schema.decodeRow(rows[key].data)
+			if !ok {
+				continue
+			}
+			for name, x := range indexes {
+				indexes[name] = x.add(schema.indexes[name], schema.typespace, key, row)
+			}
+		}
+	}
+}
+
+func compareEntries(a, b indexEntry) int {
+	if c := comparePrefix(a.values, b.values); c != 0 {
+		return c
+	}
+	return strings.Compare(a.key, b.key)
+}
+
+// comparePrefix compares values with the leading len(prefix) of them.
+func comparePrefix(values, prefix []sdktypes.AlgebraicValue) int {
+	for i := range prefix {
+		if c := compareValues(values[i], prefix[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues orders two decoded values of the same type. Products and
+// arrays compare element by element, and sums by tag and then payload.
+func compareValues(a, b sdktypes.AlgebraicValue) int {
+	switch x := a.Value.(type) {
+	case bool:
+		y, _ := b.Value.(bool)
+		switch {
+		case x == y:
+			return 0
+		case y:
+			return -1
+		default:
+			return 1
+		}
+	case int8:
+		return compareOrdered(x, b.Value)
+	case uint8:
+		return compareOrdered(x, b.Value)
+	case int16:
+		return compareOrdered(x, b.Value)
+	case uint16:
+		return compareOrdered(x, b.Value)
+	case int32:
+		return compareOrdered(x, b.Value)
+	case uint32:
+		return compareOrdered(x, b.Value)
+	case int64:
+		return compareOrdered(x, b.Value)
+	case uint64:
+		return compareOrdered(x, b.Value)
+	case float32:
+		return compareOrdered(x, b.Value)
+	case float64:
+		return compareOrdered(x, b.Value)
+	case string:
+		return compareOrdered(x, b.Value)
+	case []byte:
+		y, _ := b.Value.([]byte)
+		return bytes.Compare(x, y)
+	case sdktypes.I128:
+		return compareWith(x, b.Value)
+	case sdktypes.U128:
+		return compareWith(x, b.Value)
+	case sdktypes.I256:
+		return compareWith(x, b.Value)
+	case sdktypes.U256:
+		return compareWith(x, b.Value)
+	case sdktypes.Identity:
+		return compareWith(x, b.Value)
+	case sdktypes.ConnectionId:
+		return compareWith(x, b.Value)
+	case sdktypes.Timestamp:
+		return compareWith(x, b.Value)
+	case sdktypes.TimeDuration:
+		return compareWith(x, b.Value)
+	case sdktypes.Uuid:
+		return compareWith(x, b.Value)
+	case []sdktypes.AlgebraicValue:
+		y, _ := b.Value.([]sdktypes.AlgebraicValue)
+		return slices.CompareFunc(x, y, compareValues)
+	case sdktypes.ProductValue:
+		y, _ := b.Value.(sdktypes.ProductValue)
+		return slices.CompareFunc(x.Elements, y.Elements, compareValues)
+	case sdktypes.SumValue:
+		y, _ := b.Value.(sdktypes.SumValue)
+		if c := cmp.Compare(x.Tag, y.Tag); c != 0 {
+			return c
+		}
+		return compareValues(x.Value, y.Value)
+	default:
+		return 0
+	}
+}
+
+func compareOrdered[T cmp.Ordered](x T, other any) int {
+	y, _ := other.(T)
+	return cmp.Compare(x, y)
+}
+
+func compareWith[T interface{ Compare(T) int }](x T, other any) int {
+	y, _ := other.(T)
+	return x.Compare(y)
+}
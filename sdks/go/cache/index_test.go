@@ -0,0 +1,346 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	sdktypes "github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+type memberRow struct {
+	ID    uint32
+	Name  string
+	Guild uint32
+	Score int32
+}
+
+func memberSchema() TableSchema {
+	return TableSchema{
+		RowType: sdktypes.ProductOf(
+			sdktypes.ProductTypeElement{Name: "id", Type: sdktypes.PrimitiveType(sdktypes.KindU32)},
+			sdktypes.ProductTypeElement{Name: "name", Type: sdktypes.PrimitiveType(sdktypes.KindString)},
+			sdktypes.ProductTypeElement{Name: "guild", Type: sdktypes.PrimitiveType(sdktypes.KindU32)},
+			sdktypes.ProductTypeElement{Name: "score", Type: sdktypes.PrimitiveType(sdktypes.KindI32)},
+		),
+		PrimaryKey: "id",
+		Indexes: []IndexSchema{
+			{Name: "name", Columns: []string{"name"}, Unique: true},
+			{Name: "guild", Columns: []string{"guild"}},
+			{Name: "guild_score", Columns: []string{"guild", "score"}},
+		},
+	}
+}
+
+func member(t *testing.T, id uint32, name string, guild uint32, score int32) sdktypes.Row {
+	t.Helper()
+	data, err := bsatn.Marshal(memberRow{ID: id, Name: name, Guild: guild, Score: score})
+	if err != nil {
+		t.Fatalf("marshal member: %v", err)
+	}
+	return sdktypes.Row{Data: data}
+}
+
+func u32(v uint32) sdktypes.AlgebraicValue {
+	return sdktypes.AlgebraicValue{Type: sdktypes.PrimitiveType(sdktypes.KindU32), Value: v}
+}
+
+func i32(v int32) sdktypes.AlgebraicValue {
+	return sdktypes.AlgebraicValue{Type: sdktypes.PrimitiveType(sdktypes.KindI32), Value: v}
+}
+
+func str(v string) sdktypes.AlgebraicValue {
+	return sdktypes.AlgebraicValue{Type: sdktypes.PrimitiveType(sdktypes.KindString), Value: v}
+}
+
+// findUnique calls FindUnique and fails the test on an error.
+func findUnique(t *testing.T, store *Store, index string, values ...sdktypes.AlgebraicValue) ([]byte, bool) {
+	t.Helper()
+	data, ok, err := store.FindUnique("members", index, values...)
+	if err != nil {
+		t.Fatalf("find unique %s: %v", index, err)
+	}
+	return data, ok
+}
+
+// filter calls Filter and fails the test on an error.
+func filter(t *testing.T, store *Store, index string, r Range) [][]byte {
+	t.Helper()
+	rows, err := store.Filter("members", index, r)
+	if err != nil {
+		t.Fatalf("filter %s: %v", index, err)
+	}
+	return rows
+}
+
+// dataOf returns the data of rows, for comparing with Filter results.
+func dataOf(rows ...sdktypes.Row) [][]byte {
+	out := make([][]byte, len(rows))
+	for i, row := range rows {
+		out[i] = row.Data
+	}
+	return out
+}
+
+func TestIndexesFollowTransactions(t *testing.T) {
+	store := NewStore()
+	if err := store.DefineTable("members", memberSchema()); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	alice := member(t, 1, "alice", 7, 30)
+	bob := member(t, 2, "bob", 7, 10)
+	carol := member(t, 3, "carol", 8, 20)
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "members", Inserts: []sdktypes.Row{alice, bob, carol},
+	}}})
+
+	if data, ok := findUnique(t, store, "name", str("bob")); !ok || !reflect.DeepEqual(data, bob.Data) {
+		t.Fatalf("expected to find bob by name, got %q, %v", data, ok)
+	}
+	if got := filter(t, store, "guild", Equal(u32(7))); !reflect.DeepEqual(got, dataOf(alice, bob)) {
+		t.Fatalf("unexpected guild 7 members: %q", got)
+	}
+	if got := filter(t, store, "guild_score", Equal(u32(7))); !reflect.DeepEqual(got, dataOf(bob, alice)) {
+		t.Fatalf("expected guild 7 ordered by score, got %q", got)
+	}
+
+	// Rename alice and move her to guild 8.
+	alicia := member(t, 1, "alicia", 8, 30)
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "members", Deletes: []sdktypes.Row{alice}, Inserts: []sdktypes.Row{alicia},
+	}}})
+	if _, ok := findUnique(t, store, "name", str("alice")); ok {
+		t.Fatalf("expected the old name to leave the unique index")
+	}
+	if data, ok := findUnique(t, store, "name", str("alicia")); !ok || !reflect.DeepEqual(data, alicia.Data) {
+		t.Fatalf("expected to find alicia by name, got %q, %v", data, ok)
+	}
+	if got := filter(t, store, "guild", Equal(u32(7))); !reflect.DeepEqual(got, dataOf(bob)) {
+		t.Fatalf("unexpected guild 7 members after the move: %q", got)
+	}
+	if got := filter(t, store, "guild", Equal(u32(8))); !reflect.DeepEqual(got, dataOf(alicia, carol)) {
+		t.Fatalf("unexpected guild 8 members after the move: %q", got)
+	}
+
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "members", Deletes: []sdktypes.Row{bob},
+	}}})
+	if _, ok := findUnique(t, store, "name", str("bob")); ok {
+		t.Fatalf("expected a deleted row to leave the unique index")
+	}
+	if got := filter(t, store, "guild", Equal(u32(7))); got != nil {
+		t.Fatalf("expected no guild 7 members, got %q", got)
+	}
+}
+
+func TestFilterRanges(t *testing.T) {
+	store := NewStore()
+	if err := store.DefineTable("members", memberSchema()); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	rows := []sdktypes.Row{
+		member(t, 1, "a", 1, 5),
+		member(t, 2, "b", 2, 10),
+		member(t, 3, "c", 2, 20),
+		member(t, 4, "d", 2, 30),
+		member(t, 5, "e", 3, -5),
+	}
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{Table: "members", Inserts: rows}}})
+
+	tests := []struct {
+		name  string
+		index string
+		r     Range
+		want  [][]byte
+	}{
+		{"unbounded", "guild", Range{}, dataOf(rows...)},
+		{"from inclusive", "guild", Range{Lower: Inclusive(u32(2))}, dataOf(rows[1:]...)},
+		{"from exclusive", "guild", Range{Lower: Exclusive(u32(2))}, dataOf(rows[4])},
+		{"to exclusive", "guild", Range{Upper: Exclusive(u32(2))}, dataOf(rows[0])},
+		{"between", "guild", Range{Lower: Inclusive(u32(1)), Upper: Inclusive(u32(2))}, dataOf(rows[:4]...)},
+		{"prefix and range", "guild_score", Range{Lower: Inclusive(u32(2), i32(15)), Upper: Inclusive(u32(2), i32(30))}, dataOf(rows[2:4]...)},
+		{"prefix and exclusive", "guild_score", Range{Lower: Exclusive(u32(2), i32(10)), Upper: Exclusive(u32(2), i32(30))}, dataOf(rows[2])},
+		{"empty", "guild", Range{Lower: Inclusive(u32(3)), Upper: Exclusive(u32(3))}, nil},
+	}
+	for _, tt := range tests {
+		if got := filter(t, store, tt.index, tt.r); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: unexpected rows %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIndexMisuseIsAnError(t *testing.T) {
+	store := NewStore()
+	if err := store.DefineTable("members", memberSchema()); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+
+	filters := []struct {
+		name  string
+		table string
+		index string
+		r     Range
+	}{
+		{"wrong type", "members", "guild", Equal(i32(2))},
+		{"too many values", "members", "guild", Equal(u32(2), u32(2))},
+		{"unique index", "members", "name", Equal(str("a"))},
+		{"unknown index", "members", "missing", Range{}},
+		{"undefined table", "guests", "guild", Range{}},
+	}
+	for _, tt := range filters {
+		if _, err := store.Filter(tt.table, tt.index, tt.r); err == nil {
+			t.Fatalf("filter %s: expected an error", tt.name)
+		}
+	}
+
+	finds := []struct {
+		name   string
+		table  string
+		index  string
+		values []sdktypes.AlgebraicValue
+	}{
+		{"wrong type", "members", "name", []sdktypes.AlgebraicValue{u32(1)}},
+		{"too few values", "members", "name", nil},
+		{"b-tree index", "members", "guild", []sdktypes.AlgebraicValue{u32(1)}},
+		{"unknown index", "members", "missing", []sdktypes.AlgebraicValue{str("a")}},
+		{"undefined table", "guests", "name", []sdktypes.AlgebraicValue{str("a")}},
+	}
+	for _, tt := range finds {
+		if _, _, err := store.FindUnique(tt.table, tt.index, tt.values...); err == nil {
+			t.Fatalf("find unique %s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestUniqueConflictKeepsEveryRowIndexed(t *testing.T) {
+	store := NewStore()
+	schema := memberSchema()
+	schema.PrimaryKey = ""
+	if err := store.DefineTable("members", schema); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	first := member(t, 1, "alice", 1, 0)
+	first.Key = "a"
+	second := member(t, 2, "alice", 2, 0)
+	second.Key = "b"
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "members", Inserts: []sdktypes.Row{second, first},
+	}}})
+	if data, ok := findUnique(t, store, "name", str("alice")); !ok || !reflect.DeepEqual(data, first.Data) {
+		t.Fatalf("expected the row with the lowest key, got %q, %v", data, ok)
+	}
+
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "members", Deletes: []sdktypes.Row{first},
+	}}})
+	if data, ok := findUnique(t, store, "name", str("alice")); !ok || !reflect.DeepEqual(data, second.Data) {
+		t.Fatalf("expected the remaining row to stay indexed, got %q, %v", data, ok)
+	}
+}
+
+func TestUniqueValueMovesBetweenRowsInOneTransaction(t *testing.T) {
+	store := NewStore()
+	if err := store.DefineTable("members", memberSchema()); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	old := member(t, 1, "alice", 1, 0)
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "members", QuerySet: 1, Inserts: []sdktypes.Row{old},
+	}}})
+
+	// The insert of the new holder is applied before the delete of the old one.
+	replacement := member(t, 2, "alice", 1, 0)
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
+		{Table: "members", QuerySet: 2, Inserts: []sdktypes.Row{replacement}},
+		{Table: "members", QuerySet: 1, Deletes: []sdktypes.Row{old}},
+	}})
+	if data, ok := findUnique(t, store, "name", str("alice")); !ok || !reflect.DeepEqual(data, replacement.Data) {
+		t.Fatalf("expected the name to point at the new row, got %q, %v", data, ok)
+	}
+}
+
+func TestIndexesOnTablesWithoutPrimaryKey(t *testing.T) {
+	store := NewStore()
+	schema := memberSchema()
+	schema.PrimaryKey = ""
+	if err := store.DefineTable("members", schema); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	row := member(t, 1, "alice", 7, 0)
+	row.Key = string(row.Data)
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
+		{Table: "members", QuerySet: 1, Inserts: []sdktypes.Row{row}},
+		{Table: "members", QuerySet: 2, Inserts: []sdktypes.Row{row}},
+	}})
+	if got := filter(t, store, "guild", Equal(u32(7))); len(got) != 1 {
+		t.Fatalf("expected a row held twice to be indexed once, got %q", got)
+	}
+
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
+		{Table: "members", QuerySet: 1, Deletes: []sdktypes.Row{row}},
+	}})
+	if got := filter(t, store, "guild", Equal(u32(7))); len(got) != 1 {
+		t.Fatalf("expected the row to stay indexed while a query set holds it, got %q", got)
+	}
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
+		{Table: "members", QuerySet: 2, Deletes: []sdktypes.Row{row}},
+	}})
+	if got := filter(t, store, "guild", Equal(u32(7))); got != nil {
+		t.Fatalf("expected the row to leave the index with its last query set, got %q", got)
+	}
+}
+
+func TestDefineTableValidatesIndexes(t *testing.T) {
+	tests := []struct {
+		name    string
+		indexes []IndexSchema
+	}{
+		{"unnamed", []IndexSchema{{Columns: []string{"id"}}}},
+		{"no columns", []IndexSchema{{Name: "empty"}}},
+		{"unknown column", []IndexSchema{{Name: "missing", Columns: []string{"missing"}}}},
+		{"duplicate", []IndexSchema{{Name: "a", Columns: []string{"id"}}, {Name: "a", Columns: []string{"name"}}}},
+	}
+	for _, tt := range tests {
+		schema := memberSchema()
+		schema.Indexes = tt.indexes
+		if err := NewStore().DefineTable("members", schema); err == nil {
+			t.Fatalf("%s: expected the index to be rejected", tt.name)
+		}
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	option := sdktypes.OptionType(sdktypes.PrimitiveType(sdktypes.KindU8))
+	some := func(v uint8) sdktypes.AlgebraicValue {
+		return sdktypes.AlgebraicValue{Type: option, Value: sdktypes.SumValue{Tag: 0, Value: sdktypes.AlgebraicValue{Value: v}}}
+	}
+	none := sdktypes.AlgebraicValue{Type: option, Value: sdktypes.SumValue{Tag: 1, Value: sdktypes.AlgebraicValue{Value: sdktypes.ProductValue{}}}}
+	array := func(values ...uint32) sdktypes.AlgebraicValue {
+		out := make([]sdktypes.AlgebraicValue, len(values))
+		for i, v := range values {
+			out[i] = u32(v)
+		}
+		return sdktypes.AlgebraicValue{Value: out}
+	}
+
+	tests := []struct {
+		a, b sdktypes.AlgebraicValue
+		want int
+	}{
+		{sdktypes.AlgebraicValue{Value: false}, sdktypes.AlgebraicValue{Value: true}, -1},
+		{i32(-3), i32(2), -1},
+		{str("b"), str("a"), 1},
+		{sdktypes.AlgebraicValue{Value: []byte{1, 2}}, sdktypes.AlgebraicValue{Value: []byte{1, 2}}, 0},
+		{sdktypes.AlgebraicValue{Value: sdktypes.U128FromParts(1, 0)}, sdktypes.AlgebraicValue{Value: sdktypes.U128From64(5)}, 1},
+		{sdktypes.AlgebraicValue{Value: sdktypes.Identity{1}}, sdktypes.AlgebraicValue{Value: sdktypes.Identity{2}}, -1},
+		{some(9), none, -1},
+		{some(1), some(2), -1},
+		{array(1, 2), array(1, 2, 0), -1},
+		{array(2), array(1, 9), 1},
+	}
+	for i, tt := range tests {
+		if got := compareValues(tt.a, tt.b); got != tt.want {
+			t.Fatalf("case %d: compareValues = %d, want %d", i, got, tt.want)
+		}
+	}
+}
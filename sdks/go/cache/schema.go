@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"fmt"
+
+	sdktypes "github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+// TableSchema describes the rows of a table so the cache can read their
+// columns. RowType is the product type of a row and may be a ref into
+// Typespace. PrimaryKey names the primary key column and is empty for tables
+// without one. Indexes declares the secondary indexes of the table.
+type TableSchema struct {
+	Typespace  *sdktypes.Typespace
+	RowType    sdktypes.AlgebraicType
+	PrimaryKey string
+	Indexes    []IndexSchema
+}
+
+// tableSchema is a validated TableSchema with the row type resolved.
+type tableSchema struct {
+	typespace *sdktypes.Typespace
+	rowType   sdktypes.AlgebraicType
+	// primaryKey is the index of the primary key column, or -1.
+	primaryKey int
+	indexes    map[string]*indexSchema
+}
+
+// DefineTable declares the schema of table. Rows of a table with a primary
+// key are then keyed by the BSATN encoding of that column, decoded from the
+// row data, and Row.Key is ignored: a delete and an insert of the same
+// primary key in one transaction are reported as an update, and each primary
+// key is cached at most once. A row that does not decode against the schema
+// falls back to its Row.Key. Tables without a primary key, declared or not,
+// keep their rows as a multiset keyed by Row.Key.
+//
+// The declared indexes are kept up to date by ApplyTransaction and queried
+// with FindUnique and Filter. A row that does not decode is not indexed.
+//
+// DefineTable fails if the schema is invalid or the table already holds
+// rows, so it must be called before subscribing to the table.
+func (s *Store) DefineTable(table string, schema TableSchema) error {
+	rowType, err := schema.Typespace.Resolve(schema.RowType)
+	if err != nil {
+		return fmt.Errorf("define table %s: %w", table, err)
+	}
+	if rowType.Kind != sdktypes.KindProduct || rowType.Product == nil {
+		return fmt.Errorf("define table %s: row type %s is not a product", table, rowType)
+	}
+	defined := &tableSchema{typespace: schema.Typespace, rowType: rowType, primaryKey: -1}
+	if schema.PrimaryKey != "" {
+		defined.primaryKey = defined.column(schema.PrimaryKey)
+		if defined.primaryKey < 0 {
+			return fmt.Errorf("define table %s: no primary key column %q", table, schema.PrimaryKey)
+		}
+	}
+	defined.indexes = make(map[string]*indexSchema, len(schema.Indexes))
+	for _, index := range schema.Indexes {
+		if index.Name == "" || len(index.Columns) == 0 {
+			return fmt.Errorf("define table %s: index %q needs a name and at least one column", table, index.Name)
+		}
+		if _, ok := defined.indexes[index.Name]; ok {
+			return fmt.Errorf("define table %s: duplicate index %q", table, index.Name)
+		}
+		indexed := &indexSchema{unique: index.Unique}
+		for _, name := range index.Columns {
+			column := defined.column(name)
+			if column < 0 {
+				return fmt.Errorf("define table %s: index %q: no column %q", table, index.Name, name)
+			}
+			indexed.columns = append(indexed.columns, column)
+			indexed.types = append(indexed.types, rowType.Product.Elements[column].Type)
+		}
+		defined.indexes[index.Name] = indexed
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	current := s.state.Load()
+	if current != nil && len(current.tables[table]) > 0 {
+		return fmt.Errorf("define table %s: table already holds rows", table)
+	}
+	next := cloneSnapshot(current)
+	next.schemas[table] = defined
+	next.indexes[table] = make(map[string]index, len(defined.indexes))
+	for name, indexed := range defined.indexes {
+		next.indexes[table][name] = newIndex(indexed)
+	}
+	s.state.Store(next)
+	return nil
+}
+
+// FindByPrimaryKey returns the cached row of table whose primary key equals
+// value. It only finds rows of tables defined with a primary key.
+func (s *Store) FindByPrimaryKey(table string, value sdktypes.AlgebraicValue) ([]byte, bool) {
+	current := s.state.Load()
+	if current == nil {
+		return nil, false
+	}
+	schema := current.schemas[table]
+	if schema == nil || schema.primaryKey < 0 {
+		return nil, false
+	}
+	key, err := schema.typespace.EncodeValue(value)
+	if err != nil {
+		return nil, false
+	}
+	row, ok := current.tables[table][string(key)]
+	if !ok {
+		return nil, false
+	}
+	return cloneBytes(row.data), true
+}
+
+// column returns the index of the named column, or -1.
+func (s *tableSchema) column(name string) int {
+	for i, element := range s.rowType.Product.Elements {
+		if element.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// rowKey returns the key row is cached under in a table with schema s, which
+// may be nil.
+func (s *tableSchema) rowKey(row sdktypes.Row) string {
+	if s == nil || s.primaryKey < 0 {
+		return row.Key
+	}
+	decoded, ok := s.decodeRow(row.Data)
+	if !ok {
+		return row.Key
+	}
+	key, err := s.typespace.EncodeValue(decoded.Elements[s.primaryKey])
+	if err != nil {
+		return row.Key
+	}
+	return string(key)
+}
+
+// decodeRow decodes row data into its columns.
+func (s *tableSchema) decodeRow(data []byte) (sdktypes.ProductValue, bool) {
+	decoded, err := s.typespace.DecodeValue(s.rowType, data)
+	if err != nil {
+		return sdktypes.ProductValue{}, false
+	}
+	product, ok := decoded.Value.(sdktypes.ProductValue)
+	if !ok || len(product.Elements) != len(s.rowType.Product.Elements) {
+		return sdktypes.ProductValue{}, false
+	}
+	return product, true
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	sdktypes "github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+type playerRow struct {
+	ID   uint32
+	Name string
+}
+
+func playerSchema() TableSchema {
+	return TableSchema{
+		Typespace: &sdktypes.Typespace{Types: []sdktypes.AlgebraicType{sdktypes.ProductOf(
+			sdktypes.ProductTypeElement{Name: "id", Type: sdktypes.PrimitiveType(sdktypes.KindU32)},
+			sdktypes.ProductTypeElement{Name: "name", Type: sdktypes.PrimitiveType(sdktypes.KindString)},
+		)}},
+		RowType:    sdktypes.RefType(0),
+		PrimaryKey: "id",
+	}
+}
+
+// player encodes a player row. Rows are given a Key that differs from
+// their primary key to show that the store ignores it.
+func player(t *testing.T, id uint32, name string) sdktypes.Row {
+	t.Helper()
+	data, err := bsatn.Marshal(playerRow{ID: id, Name: name})
+	if err != nil {
+		t.Fatalf("marshal player: %v", err)
+	}
+	return sdktypes.Row{Key: name, Data: data}
+}
+
+func TestPrimaryKeyPairsDeleteAndInsertIntoUpdate(t *testing.T) {
+	store := NewStore()
+	if err := store.DefineTable("players", playerSchema()); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table:   "players",
+		Inserts: []sdktypes.Row{player(t, 1, "alice"), player(t, 2, "bob")},
+	}}})
+
+	changes := store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table:   "players",
+		Deletes: []sdktypes.Row{player(t, 1, "alice"), player(t, 2, "bob")},
+		Inserts: []sdktypes.Row{player(t, 1, "alicia"), player(t, 3, "carol")},
+	}}})
+	if len(changes) != 1 {
+		t.Fatalf("expected changes to one table, got %+v", changes)
+	}
+	change := changes[0]
+	if len(change.Updates) != 1 || !reflect.DeepEqual(change.Updates[0].Old.Data, player(t, 1, "alice").Data) ||
+		!reflect.DeepEqual(change.Updates[0].New.Data, player(t, 1, "alicia").Data) {
+		t.Fatalf("expected alice to be updated to alicia, got %+v", change.Updates)
+	}
+	if change.Updates[0].Old.Key != change.Updates[0].New.Key {
+		t.Fatalf("expected both sides of the update to share the primary key")
+	}
+	if len(change.Deletes) != 1 || !reflect.DeepEqual(change.Deletes[0].Data, player(t, 2, "bob").Data) {
+		t.Fatalf("expected bob to be deleted, got %+v", change.Deletes)
+	}
+	if len(change.Inserts) != 1 || !reflect.DeepEqual(change.Inserts[0].Data, player(t, 3, "carol").Data) {
+		t.Fatalf("expected carol to be inserted, got %+v", change.Inserts)
+	}
+
+	id := sdktypes.AlgebraicValue{Type: sdktypes.PrimitiveType(sdktypes.KindU32), Value: uint32(1)}
+	data, ok := store.FindByPrimaryKey("players", id)
+	if !ok || !reflect.DeepEqual(data, player(t, 1, "alicia").Data) {
+		t.Fatalf("expected to find alicia by primary key, got %q, %v", data, ok)
+	}
+	if got := len(store.TableSnapshot("players")); got != 2 {
+		t.Fatalf("expected two cached players, got %d", got)
+	}
+}
+
+func TestPrimaryKeyRowsSharedByQuerySets(t *testing.T) {
+	store := NewStore()
+	if err := store.DefineTable("players", playerSchema()); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
+		{Table: "players", QuerySet: 1, Inserts: []sdktypes.Row{player(t, 1, "alice")}},
+		{Table: "players", QuerySet: 2, Inserts: []sdktypes.Row{player(t, 1, "alice")}},
+	}})
+
+	// Each query set sees the rename; the row is reported updated once.
+	changes := store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
+		{Table: "players", QuerySet: 1, Deletes: []sdktypes.Row{player(t, 1, "alice")}, Inserts: []sdktypes.Row{player(t, 1, "alicia")}},
+		{Table: "players", QuerySet: 2, Deletes: []sdktypes.Row{player(t, 1, "alice")}, Inserts: []sdktypes.Row{player(t, 1, "alicia")}},
+	}})
+	if len(changes) != 1 || len(changes[0].Updates) != 1 || len(changes[0].Inserts) != 0 || len(changes[0].Deletes) != 0 {
+		t.Fatalf("expected a single update, got %+v", changes)
+	}
+	key := changes[0].Updates[0].New.Key
+	if got := store.RefCount("players", key); got != 2 {
+		t.Fatalf("expected the row to be held by both query sets, got %d", got)
+	}
+
+	// A delete carrying stale data still releases the row by primary key.
+	changes = store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{
+		{Table: "players", QuerySet: 1, Deletes: []sdktypes.Row{player(t, 1, "alice")}},
+		{Table: "players", QuerySet: 2, Deletes: []sdktypes.Row{player(t, 1, "alicia")}},
+	}})
+	if len(changes) != 1 || len(changes[0].Deletes) != 1 || !reflect.DeepEqual(changes[0].Deletes[0].Data, player(t, 1, "alicia").Data) {
+		t.Fatalf("expected the cached row to be deleted, got %+v", changes)
+	}
+}
+
+func TestTablesWithoutPrimaryKeyKeepMultisetSemantics(t *testing.T) {
+	store := NewStore()
+	schema := playerSchema()
+	schema.PrimaryKey = ""
+	if err := store.DefineTable("players", schema); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	keyed := func(row sdktypes.Row) sdktypes.Row {
+		row.Key = string(row.Data)
+		return row
+	}
+	alice, alicia := keyed(player(t, 1, "alice")), keyed(player(t, 1, "alicia"))
+
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "players", Inserts: []sdktypes.Row{alice, alice},
+	}}})
+	if got := store.RefCount("players", alice.Key); got != 2 {
+		t.Fatalf("expected a duplicate row to be counted twice, got %d", got)
+	}
+
+	changes := store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "players", Deletes: []sdktypes.Row{alice, alice}, Inserts: []sdktypes.Row{alicia},
+	}}})
+	if len(changes) != 1 || len(changes[0].Updates) != 0 || len(changes[0].Deletes) != 1 || len(changes[0].Inserts) != 1 {
+		t.Fatalf("expected a delete and an insert without a primary key, got %+v", changes)
+	}
+}
+
+func TestDefineTableValidatesSchema(t *testing.T) {
+	store := NewStore()
+
+	schema := playerSchema()
+	schema.PrimaryKey = "missing"
+	if err := store.DefineTable("players", schema); err == nil {
+		t.Fatalf("expected an unknown primary key column to be rejected")
+	}
+	schema = playerSchema()
+	schema.RowType = sdktypes.PrimitiveType(sdktypes.KindU32)
+	if err := store.DefineTable("players", schema); err == nil {
+		t.Fatalf("expected a non-product row type to be rejected")
+	}
+	schema = playerSchema()
+	schema.RowType = sdktypes.RefType(7)
+	if err := store.DefineTable("players", schema); err == nil {
+		t.Fatalf("expected an unknown type ref to be rejected")
+	}
+
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "players", Inserts: []sdktypes.Row{player(t, 1, "alice")},
+	}}})
+	if err := store.DefineTable("players", playerSchema()); err == nil {
+		t.Fatalf("expected a table that already holds rows to be rejected")
+	}
+}
+
+func TestUndecodableRowFallsBackToRowKey(t *testing.T) {
+	store := NewStore()
+	if err := store.DefineTable("players", playerSchema()); err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	store.ApplyTransaction(sdktypes.Transaction{Tables: []sdktypes.TableMutation{{
+		Table: "players", Inserts: []sdktypes.Row{{Key: "junk", Data: []byte{1}}},
+	}}})
+	if _, ok := store.Get("players", "junk"); !ok {
+		t.Fatalf("expected an undecodable row to be cached under its row key")
+	}
+}
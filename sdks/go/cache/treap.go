@@ -0,0 +1,160 @@
+package cache
+
+// treap is a persistent ordered set. Insert and delete copy only the nodes
+// on the path they change and return a new treap, so snapshots share the
+// rest of the tree and an older snapshot never sees a later change.
+//
+// Node priorities come from the items themselves, so the shape of the tree
+// does not depend on the order of the changes that built it.
+type treap[T any] struct {
+	root     *treapNode[T]
+	compare  func(a, b T) int
+	priority func(T) uint64
+}
+
+type treapNode[T any] struct {
+	item        T
+	priority    uint64
+	left, right *treapNode[T]
+}
+
+func newTreap[T any](compare func(a, b T) int, priority func(T) uint64) treap[T] {
+	return treap[T]{compare: compare, priority: priority}
+}
+
+// find returns the item equal to probe.
+func (t treap[T]) find(probe T) (T, bool) {
+	n := t.root
+	for n != nil {
+		switch c := t.compare(probe, n.item); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n.item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// insert returns t with item added, replacing an equal item.
+func (t treap[T]) insert(item T) treap[T] {
+	t.root = t.insertAt(t.root, &treapNode[T]{item: item, priority: t.priority(item)})
+	return t
+}
+
+func (t treap[T]) insertAt(n, fresh *treapNode[T]) *treapNode[T] {
+	if n == nil {
+		return fresh
+	}
+	c := t.compare(fresh.item, n.item)
+	if c == 0 {
+		copied := *n
+		copied.item = fresh.item
+		return &copied
+	}
+	copied := *n
+	if c < 0 {
+		copied.left = t.insertAt(n.left, fresh)
+		if copied.left.priority > copied.priority {
+			// The new left child is a fresh node, so it can be rotated in place.
+			l := copied.left
+			copied.left = l.right
+			l.right = &copied
+			return l
+		}
+		return &copied
+	}
+	copied.right = t.insertAt(n.right, fresh)
+	if copied.right.priority > copied.priority {
+		r := copied.right
+		copied.right = r.left
+		r.left = &copied
+		return r
+	}
+	return &copied
+}
+
+// delete returns t without the item equal to probe.
+func (t treap[T]) delete(probe T) treap[T] {
+	t.root = t.deleteAt(t.root, probe)
+	return t
+}
+
+func (t treap[T]) deleteAt(n *treapNode[T], probe T) *treapNode[T] {
+	if n == nil {
+		return nil
+	}
+	switch c := t.compare(probe, n.item); {
+	case c < 0:
+		left := t.deleteAt(n.left, probe)
+		if left == n.left {
+			return n
+		}
+		copied := *n
+		copied.left = left
+		return &copied
+	case c > 0:
+		right := t.deleteAt(n.right, probe)
+		if right == n.right {
+			return n
+		}
+		copied := *n
+		copied.right = right
+		return &copied
+	default:
+		return mergeTreap(n.left, n.right)
+	}
+}
+
+// mergeTreap joins two trees whose items all order a before b.
+func mergeTreap[T any](a, b *treapNode[T]) *treapNode[T] {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.priority > b.priority:
+		copied := *a
+		copied.right = mergeTreap(a.right, b)
+		return &copied
+	default:
+		copied := *b
+		copied.left = mergeTreap(a, b.left)
+		return &copied
+	}
+}
+
+// ascend calls visit on the items for which atOrAfter is true, in order,
+// until visit returns false. atOrAfter must be false for a prefix of the
+// items and true for the rest.
+func (t treap[T]) ascend(atOrAfter func(T) bool, visit func(T) bool) {
+	ascendFrom(t.root, atOrAfter, visit)
+}
+
+func ascendFrom[T any](n *treapNode[T], atOrAfter func(T) bool, visit func(T) bool) bool {
+	for n != nil {
+		if !atOrAfter(n.item) {
+			n = n.right
+			continue
+		}
+		if !ascendFrom(n.left, atOrAfter, visit) || !visit(n.item) {
+			return false
+		}
+		// Everything to the right is after n as well.
+		return ascendAll(n.right, visit)
+	}
+	return true
+}
+
+func ascendAll[T any](n *treapNode[T], visit func(T) bool) bool {
+	for n != nil {
+		if !ascendAll(n.left, visit) || !visit(n.item) {
+			return false
+		}
+		n = n.right
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"cmp"
+	"hash/maphash"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func intTreap() treap[int] {
+	return newTreap(cmp.Compare[int], func(n int) uint64 {
+		return maphash.String(prioritySeed, strconv.Itoa(n))
+	})
+}
+
+func treapItems(t treap[int], from int) []int {
+	var out []int
+	t.ascend(func(n int) bool { return n >= from }, func(n int) bool {
+		out = append(out, n)
+		return true
+	})
+	return out
+}
+
+func TestTreapMatchesSortedSet(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	tree := intTreap()
+	set := map[int]bool{}
+	for range 2000 {
+		n := rng.IntN(200)
+		if rng.IntN(3) == 0 {
+			tree = tree.delete(n)
+			delete(set, n)
+		} else {
+			tree = tree.insert(n)
+			set[n] = true
+		}
+	}
+
+	var want []int
+	for n := range set {
+		want = append(want, n)
+	}
+	slices.Sort(want)
+	if got := treapItems(tree, 0); !slices.Equal(got, want) {
+		t.Fatalf("unexpected items:\n got %v\nwant %v", got, want)
+	}
+	from := want[len(want)/2]
+	if got := treapItems(tree, from); !slices.Equal(got, want[len(want)/2:]) {
+		t.Fatalf("unexpected items from %d: %v", from, got)
+	}
+	for n := range 200 {
+		if _, ok := tree.find(n); ok != set[n] {
+			t.Fatalf("find(%d) = %v, want %v", n, ok, set[n])
+		}
+	}
+}
+
+func TestTreapChangesLeaveEarlierVersionsAlone(t *testing.T) {
+	before := intTreap()
+	for n := range 50 {
+		before = before.insert(n)
+	}
+	after := before.delete(10).insert(100)
+
+	if got := treapItems(before, 0); len(got) != 50 || got[10] != 10 {
+		t.Fatalf("expected the earlier version to keep its items, got %v", got)
+	}
+	if _, ok := after.find(10); ok {
+		t.Fatalf("expected 10 to be deleted from the later version")
+	}
+	if _, ok := after.find(100); !ok {
+		t.Fatalf("expected 100 in the later version")
+	}
+}
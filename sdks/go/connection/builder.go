@@ -3,10 +3,13 @@ package connection
 import (
 	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
 )
@@ -21,17 +24,30 @@ type Builder struct {
 	lightMode         bool
 	confirmedReads    *bool
 	useWebsocketToken bool
+	pingInterval      time.Duration
+	idleTimeout       time.Duration
+	callTimeout       time.Duration
+	sendQueueSize     int
+	overflowPolicy    OverflowPolicy
 	onConnect         func(*Connection)
 	onConnectError    func(error)
 	onDisconnect      func(error)
 	onMessage         func([]byte)
+	onStateChange     func(StateChange)
+	dialOptions       DialOptions
+	dialer            Dialer
+	transport         Transport
 }
 
 func NewBuilder() *Builder {
 	return &Builder{
 		compression:       protocol.CompressionGzip,
-		messageDecoder:    protocol.JSONMessageDecoder,
+		messageDecoder:    protocol.BSATNMessageDecoder,
 		useWebsocketToken: true,
+		pingInterval:      DefaultPingInterval,
+		idleTimeout:       DefaultIdleTimeout,
+		sendQueueSize:     DefaultSendQueueSize,
+		overflowPolicy:    OverflowBlock,
 	}
 }
 
@@ -90,6 +106,109 @@ func (b *Builder) WithUseWebSocketToken(enabled bool) *Builder {
 	return b.WithUseWebsocketToken(enabled)
 }
 
+// WithPingInterval sets how often the connection pings the server. Pongs
+// update Connection.RTT and keep the idle timeout from expiring. A value <= 0
+// disables pings.
+func (b *Builder) WithPingInterval(interval time.Duration) *Builder {
+	b.pingInterval = interval
+	return b
+}
+
+// WithIdleTimeout sets how long the connection may go without receiving any
+// frame, including pongs, before it is closed with an ErrorTimeout error.
+// A value <= 0 disables the timeout.
+func (b *Builder) WithIdleTimeout(timeout time.Duration) *Builder {
+	b.idleTimeout = timeout
+	return b
+}
+
+// WithCallTimeout sets how long reducer, procedure and one-off query calls
+// wait for their result before the callback fails with an ErrorTimeout error.
+// A context deadline passed to a call applies as well. A value <= 0, the
+// default, disables the timeout.
+func (b *Builder) WithCallTimeout(timeout time.Duration) *Builder {
+	b.callTimeout = timeout
+	return b
+}
+
+// WithSendQueue sets the capacity of the outbound message queue and what
+// happens when a message is sent while it is full. A size <= 0 uses
+// DefaultSendQueueSize.
+func (b *Builder) WithSendQueue(size int, policy OverflowPolicy) *Builder {
+	b.sendQueueSize = size
+	b.overflowPolicy = policy
+	return b
+}
+
+// WithHTTPClient sets the client used for the websocket token exchange. If
+// its Transport is an *http.Transport, the websocket handshake also uses its
+// TLS config, proxy and dial function unless WithTLSConfig or WithProxy
+// override them.
+func (b *Builder) WithHTTPClient(client *http.Client) *Builder {
+	b.dialOptions.HTTPClient = client
+	return b
+}
+
+// WithTLSConfig sets the TLS configuration for the token exchange and the
+// websocket handshake, for example to trust a private CA. With WithHTTPClient
+// it is applied to a copy of that client's transport, which must be nil or an
+// *http.Transport.
+func (b *Builder) WithTLSConfig(config *tls.Config) *Builder {
+	b.dialOptions.TLSConfig = config
+	return b
+}
+
+// WithProxy sets the proxy function for the token exchange and the websocket
+// handshake, such as http.ProxyFromEnvironment or http.ProxyURL. By default
+// both use the proxy of WithHTTPClient's transport, or none. It combines with
+// WithHTTPClient as WithTLSConfig does.
+func (b *Builder) WithProxy(proxy func(*http.Request) (*url.URL, error)) *Builder {
+	b.dialOptions.Proxy = proxy
+	return b
+}
+
+// WithHandshakeTimeout bounds the token exchange and the websocket handshake.
+// A value <= 0 uses websocket.DefaultDialer's timeout.
+func (b *Builder) WithHandshakeTimeout(timeout time.Duration) *Builder {
+	b.dialOptions.HandshakeTimeout = timeout
+	return b
+}
+
+// WithHeader adds a header sent with the token exchange and the websocket
+// handshake. The Authorization header is reserved for the token.
+func (b *Builder) WithHeader(key, value string) *Builder {
+	if b.dialOptions.Header == nil {
+		b.dialOptions.Header = http.Header{}
+	}
+	b.dialOptions.Header.Add(key, value)
+	return b
+}
+
+// WithDialer sets how Build opens the transport to the server. The default
+// dials a websocket with the options above. A custom dialer receives the
+// headers from WithHeader; the websocket token exchange still goes over HTTP
+// unless disabled with WithUseWebsocketToken(false).
+func (b *Builder) WithDialer(dialer Dialer) *Builder {
+	b.dialer = dialer
+	return b
+}
+
+// WithTransport makes Build use an already open transport, such as one end
+// of NewPipe, instead of dialing. The transport serves a single Build; later
+// builds use the dialer.
+func (b *Builder) WithTransport(transport Transport) *Builder {
+	b.transport = transport
+	return b
+}
+
+// Clone returns a copy of b that can be changed and built without
+// affecting b.
+func (b *Builder) Clone() *Builder {
+	c := *b
+	c.dialOptions.Header = b.dialOptions.Header.Clone()
+	return &c
+}
+
 func (b *Builder) OnConnect(cb func(*Connection)) *Builder {
 	b.onConnect = cb
 	return b
@@ -110,6 +229,13 @@ func (b *Builder) OnMessage(cb func([]byte)) *Builder {
 	return b
 }
 
+// OnStateChange registers a callback for every state transition of the
+// connection, starting with the move to StateConnected.
+func (b *Builder) OnStateChange(cb func(StateChange)) *Builder {
+	b.onStateChange = cb
+	return b
+}
+
 func (b *Builder) Build(ctx context.Context) (*Connection, error) {
 	if b.uri == "" {
 		return nil, errors.New("uri is required")
@@ -117,9 +243,14 @@ func (b *Builder) Build(ctx context.Context) (*Connection, error) {
 	if b.databaseName == "" {
 		return nil, errors.New("database name is required")
 	}
-	if b.compression != protocol.CompressionGzip && b.compression != protocol.CompressionNone {
+	switch b.compression {
+	case protocol.CompressionNone, protocol.CompressionBrotli, protocol.CompressionGzip:
+	default:
 		return nil, fmt.Errorf("invalid compression: %q", b.compression)
 	}
+	if err := b.dialOptions.validate(); err != nil {
+		return nil, err
+	}
 
 	hostURL, err := normalizeHostURL(b.uri)
 	if err != nil {
@@ -132,11 +263,14 @@ func (b *Builder) Build(ctx context.Context) (*Connection, error) {
 	}
 
 	wsURL := buildSubscribeURL(hostURL, b.databaseName, connectionID, b.compression, b.lightMode, b.confirmedReads)
-	headers := map[string]string{}
+	headers := b.dialOptions.Header.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
 
 	if b.token != "" {
 		if b.useWebsocketToken {
-			websocketToken, err := exchangeWebsocketToken(ctx, hostURL, b.token)
+			websocketToken, err := exchangeWebsocketToken(ctx, b.dialOptions, hostURL, b.token)
 			if err != nil {
 				if b.onConnectError != nil {
 					b.onConnectError(err)
@@ -147,11 +281,11 @@ func (b *Builder) Build(ctx context.Context) (*Connection, error) {
 			q.Set("token", websocketToken)
 			wsURL.RawQuery = q.Encode()
 		} else {
-			headers["Authorization"] = "Bearer " + b.token
+			headers.Set("Authorization", "Bearer "+b.token)
 		}
 	}
 
-	conn, err := dialWebsocket(ctx, wsURL, headers)
+	transport, err := b.openTransport(ctx, wsURL, headers)
 	if err != nil {
 		if b.onConnectError != nil {
 			b.onConnectError(err)
@@ -159,7 +293,13 @@ func (b *Builder) Build(ctx context.Context) (*Connection, error) {
 		return nil, err
 	}
 
-	c := newConnection(conn, connectionID, wsURL.String(), b.messageDecoder, b.messageEncoder, b.onMessage, b.onDisconnect)
+	c := newConnection(transport, connectionID, wsURL.String(), b.messageDecoder, b.messageEncoder, b.onMessage, b.onDisconnect)
+	c.pingInterval = b.pingInterval
+	c.idleTimeout = b.idleTimeout
+	c.callTimeout = b.callTimeout
+	c.sendQueue = newSendQueue(b.sendQueueSize, b.overflowPolicy)
+	c.state.onChange = b.onStateChange
+	c.state.Transition(StateConnected, nil)
 	if b.onConnect != nil {
 		b.onConnect(c)
 	}
@@ -168,6 +308,19 @@ func (b *Builder) Build(ctx context.Context) (*Connection, error) {
 	return c, nil
 }
 
+func (b *Builder) openTransport(ctx context.Context, endpoint *url.URL, headers http.Header) (Transport, error) {
+	if b.transport != nil {
+		transport := b.transport
+		b.transport = nil
+		return transport, nil
+	}
+	dialer := b.dialer
+	if dialer == nil {
+		dialer = NewWebsocketDialer(b.dialOptions)
+	}
+	return dialer(ctx, endpoint, headers)
+}
+
 func normalizeHostURL(raw string) (*url.URL, error) {
 	u, err := url.Parse(raw)
 	if err != nil {
@@ -89,6 +89,11 @@ func TestBuildSubscribeURL(t *testing.T) {
 	if got := q.Get("confirmed"); got != "true" {
 		t.Fatalf("unexpected confirmed: %q", got)
 	}
+
+	u = buildSubscribeURL(host, "mydb", "conn-1", protocol.CompressionBrotli, false, nil)
+	if got := u.Query().Get("compression"); got != "Brotli" {
+		t.Fatalf("unexpected compression: %q", got)
+	}
 }
 
 func TestWithMessageEncoderSetsEncoder(t *testing.T) {
@@ -0,0 +1,69 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/sdkerror"
+)
+
+// callExpiry holds the deadline watchers of one pending call so they can be
+// stopped as soon as the call completes.
+type callExpiry struct {
+	mu      sync.Mutex
+	stops   []func() bool
+	settled bool
+}
+
+// wrap returns callback with the watchers stopped before it runs.
+func (e *callExpiry) wrap(callback callResultCallback) callResultCallback {
+	return func(message protocol.RoutedMessage, err error) {
+		e.settle()
+		callback(message, err)
+	}
+}
+
+func (e *callExpiry) add(stop func() bool) {
+	e.mu.Lock()
+	if e.settled {
+		e.mu.Unlock()
+		stop()
+		return
+	}
+	e.stops = append(e.stops, stop)
+	e.mu.Unlock()
+}
+
+func (e *callExpiry) settle() {
+	e.mu.Lock()
+	e.settled = true
+	stops := e.stops
+	e.stops = nil
+	e.mu.Unlock()
+	for _, stop := range stops {
+		stop()
+	}
+}
+
+// armExpiry fails a pending call through expire when ctx is done or the
+// connection's call timeout elapses, whichever comes first.
+func (c *Connection) armExpiry(ctx context.Context, op string, e *callExpiry, expire func(error)) {
+	if ctx != nil && ctx.Done() != nil {
+		e.add(context.AfterFunc(ctx, func() { expire(expiredError(op, ctx.Err())) }))
+	}
+	if timeout := c.callTimeout; timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			expire(wrapError(ErrorTimeout, op, fmt.Errorf("no result after %s", timeout)))
+		})
+		e.add(timer.Stop)
+	}
+}
+
+// expiredError reports an expired deadline as ErrorTimeout and leaves
+// cancellation as the bare context error.
+func expiredError(op string, err error) error {
+	return sdkerror.Expired(op, err)
+}
@@ -0,0 +1,122 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+)
+
+func TestCanceledCallFailsCallbackAndClearsRoute(t *testing.T) {
+	c := newSinkConnection(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	callbacks := make(chan error, 2)
+	requestID, err := c.CallReducerContext(ctx, "slow", nil, func(_ protocol.RoutedMessage, err error) {
+		callbacks <- err
+	})
+	if err != nil {
+		t.Fatalf("call reducer: %v", err)
+	}
+	cancel()
+
+	if err := waitCallback(t, callbacks); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, ok := c.requestRoutes.Load(requestID); ok {
+		t.Fatalf("expected canceled call route to be cleared")
+	}
+
+	// A late result is ignored.
+	if err := c.RouteMessage(protocol.RoutedMessage{Kind: protocol.MessageKindReducerResult, RequestID: &requestID}); err != nil {
+		t.Fatalf("route late result: %v", err)
+	}
+	select {
+	case err := <-callbacks:
+		t.Fatalf("callback ran twice, second error: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestCallDeadlineReportsTimeout(t *testing.T) {
+	c := newSinkConnection(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	callbacks := make(chan error, 1)
+	if _, err := c.CallProcedureContext(ctx, "slow", nil, func(_ protocol.RoutedMessage, err error) {
+		callbacks <- err
+	}); err != nil {
+		t.Fatalf("call procedure: %v", err)
+	}
+
+	err := waitCallback(t, callbacks)
+	if !IsCode(err, ErrorTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ErrorTimeout wrapping the deadline, got %v", err)
+	}
+}
+
+func TestConnectionCallTimeout(t *testing.T) {
+	c := newSinkConnection(t)
+	c.callTimeout = 20 * time.Millisecond
+
+	callbacks := make(chan error, 1)
+	if _, err := c.OneOffQuery("select * from users", func(_ protocol.RoutedMessage, err error) {
+		callbacks <- err
+	}); err != nil {
+		t.Fatalf("one-off query: %v", err)
+	}
+	if err := waitCallback(t, callbacks); !IsCode(err, ErrorTimeout) {
+		t.Fatalf("expected ErrorTimeout, got %v", err)
+	}
+}
+
+func TestResultBeforeTimeoutRunsCallbackOnce(t *testing.T) {
+	c := newSinkConnection(t)
+	c.callTimeout = 30 * time.Millisecond
+
+	callbacks := make(chan error, 2)
+	requestID, err := c.CallReducer("fast", nil, func(_ protocol.RoutedMessage, err error) {
+		callbacks <- err
+	})
+	if err != nil {
+		t.Fatalf("call reducer: %v", err)
+	}
+	if err := c.RouteMessage(protocol.RoutedMessage{Kind: protocol.MessageKindReducerResult, RequestID: &requestID}); err != nil {
+		t.Fatalf("route result: %v", err)
+	}
+	if err := waitCallback(t, callbacks); err != nil {
+		t.Fatalf("expected successful result, got %v", err)
+	}
+	select {
+	case err := <-callbacks:
+		t.Fatalf("callback ran again after the result: %v", err)
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+// newSinkConnection connects to a server that reads and discards every message.
+func newSinkConnection(t *testing.T) *Connection {
+	t.Helper()
+	serverURL, cleanup := startWebsocketEchoSink(t, make(chan []byte, 16))
+	t.Cleanup(cleanup)
+	c, err := buildTestConnection(t, serverURL)
+	if err != nil {
+		t.Fatalf("build test connection: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c
+}
+
+func waitCallback(t *testing.T, callbacks <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-callbacks:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for callback")
+		return nil
+	}
+}
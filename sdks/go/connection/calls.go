@@ -1,6 +1,7 @@
 package connection
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/clockworklabs/spacetimedb/sdks/go/events"
@@ -13,11 +14,19 @@ type ProcedureResultCallback = events.ProcedureResultCallback
 type callResultCallback = events.ResultCallback
 
 func (c *Connection) CallReducer(reducer string, args []byte, callback ReducerResultCallback) (uint32, error) {
+	return c.CallReducerContext(context.Background(), reducer, args, callback)
+}
+
+// CallReducerContext is CallReducer with a context that bounds the whole call.
+// If ctx is done before the result arrives, the route is removed and callback
+// receives context.Canceled, or an ErrorTimeout error once the deadline passes.
+func (c *Connection) CallReducerContext(ctx context.Context, reducer string, args []byte, callback ReducerResultCallback) (uint32, error) {
 	if reducer == "" {
 		return 0, newInvalidArgument("call_reducer", "reducer name is required")
 	}
 
 	return c.callWithRequestRoute(
+		ctx,
 		protocol.ClientMessage{
 			Kind:      protocol.ClientMessageCallReducer,
 			RequestID: c.NextRequestID(),
@@ -30,11 +39,18 @@ func (c *Connection) CallReducer(reducer string, args []byte, callback ReducerRe
 }
 
 func (c *Connection) CallProcedure(procedure string, args []byte, callback ProcedureResultCallback) (uint32, error) {
+	return c.CallProcedureContext(context.Background(), procedure, args, callback)
+}
+
+// CallProcedureContext is CallProcedure with a context that bounds the whole
+// call, as in CallReducerContext.
+func (c *Connection) CallProcedureContext(ctx context.Context, procedure string, args []byte, callback ProcedureResultCallback) (uint32, error) {
 	if procedure == "" {
 		return 0, newInvalidArgument("call_procedure", "procedure name is required")
 	}
 
 	return c.callWithRequestRoute(
+		ctx,
 		protocol.ClientMessage{
 			Kind:      protocol.ClientMessageCallProcedure,
 			RequestID: c.NextRequestID(),
@@ -47,41 +63,74 @@ func (c *Connection) CallProcedure(procedure string, args []byte, callback Proce
 }
 
 func (c *Connection) callWithRequestRoute(
+	ctx context.Context,
 	message protocol.ClientMessage,
 	expectedKind protocol.MessageKind,
 	callback callResultCallback,
 ) (uint32, error) {
 	requestID := message.RequestID
+	var (
+		expiry *callExpiry
+		fail   func(error)
+	)
 	if callback != nil {
+		expiry = &callExpiry{}
+		callback = expiry.wrap(callback)
 		c.callCallbacks.Store(requestID, callback)
 		c.OnRequest(requestID, func(result protocol.RoutedMessage) {
-			c.callCallbacks.Delete(requestID)
-			c.ClearRequestRoute(requestID)
+			if !c.takeCall(requestID) {
+				// The call expired as its result arrived; route the result
+				// as one whose call is gone.
+				if handler, ok := c.kindRoutes.Load(result.Kind); ok {
+					handler.(protocol.RouteHandler)(result)
+				}
+				return
+			}
 			if result.Kind != expectedKind {
 				callback(result, newUnexpectedKind("call_result", string(result.Kind), string(expectedKind)))
 				return
 			}
 			callback(result, nil)
 		})
+		// A call that is dropped from a full queue or expires fails its
+		// callback, unless the result already arrived.
+		fail = func(err error) {
+			if c.takeCall(requestID) {
+				callback(protocol.RoutedMessage{}, err)
+			}
+		}
 	}
 
-	if err := c.sendClientMessage(message); err != nil {
+	if err := c.sendClientMessage(ctx, message, fail); err != nil {
 		if callback != nil {
-			c.callCallbacks.Delete(requestID)
-			c.ClearRequestRoute(requestID)
+			c.takeCall(requestID)
 		}
 		return requestID, err
 	}
+	if callback != nil {
+		c.armExpiry(ctx, string(message.Kind), expiry, fail)
+	}
 
 	return requestID, nil
 }
 
-func (c *Connection) sendClientMessage(message protocol.ClientMessage) error {
+// takeCall removes a pending call and reports whether it was still pending,
+// so that exactly one of the result, a failure or an expiry runs the callback.
+func (c *Connection) takeCall(requestID uint32) bool {
+	if _, ok := c.callCallbacks.LoadAndDelete(requestID); !ok {
+		return false
+	}
+	c.ClearRequestRoute(requestID)
+	c.signalSettled()
+	return true
+}
+
+func (c *Connection) sendClientMessage(ctx context.Context, message protocol.ClientMessage, onDrop func(error)) error {
 	encoded, err := c.messageEncoder(message)
 	if err != nil {
 		return wrapError(ErrorEncodeFailed, fmt.Sprintf("encode_%s", message.Kind), err)
 	}
-	if err := c.SendBinary(encoded); err != nil {
+	if err := c.enqueue(ctx, outboundMessage{payload: encoded, onDrop: onDrop}); err != nil {
 		return wrapError(ErrorSendFailed, fmt.Sprintf("send_%s", message.Kind), err)
 	}
 	return nil
@@ -3,6 +3,7 @@ package connection
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,12 +11,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/andybalholm/brotli"
 	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
-	"github.com/gorilla/websocket"
 )
 
 type Connection struct {
-	ws           *websocket.Conn
+	transport    Transport
 	connectionID string
 	endpoint     string
 
@@ -24,6 +25,17 @@ type Connection struct {
 	onMessage      func([]byte)
 	onDisconnect   func(error)
 
+	pingInterval time.Duration
+	idleTimeout  time.Duration
+	idleTimer    *time.Timer
+	lastRTT      atomic.Int64
+	callTimeout  time.Duration
+	// closeTimeout bounds the close handshake when the caller gives no
+	// deadline. Zero means defaultCloseTimeout.
+	closeTimeout time.Duration
+
+	sendQueue *sendQueue
+
 	requestIDCounter atomic.Uint32
 	queryIDCounter   atomic.Uint32
 
@@ -33,13 +45,18 @@ type Connection struct {
 	callCallbacks sync.Map // map[uint32]callResultCallback
 	subCallbacks  sync.Map // map[uint32]subscriptionCallback
 
+	pendingUnsubscribes sync.Map // map[uint32]struct{}, by query id
+	settled             chan struct{}
+	draining            atomic.Bool
+	readLoopDone        chan struct{}
+
+	state          StateMachine
 	closed         atomic.Bool
 	disconnectOnce sync.Once
-	mu             sync.Mutex
 }
 
 func newConnection(
-	ws *websocket.Conn,
+	transport Transport,
 	connectionID, endpoint string,
 	messageDecoder protocol.MessageDecoder,
 	messageEncoder protocol.MessageEncoder,
@@ -47,20 +64,22 @@ func newConnection(
 	onDisconnect func(error),
 ) *Connection {
 	if messageEncoder == nil {
-		messageEncoder = protocol.JSONMessageEncoder
+		messageEncoder = protocol.BSATNMessageEncoder
 	}
 	if messageDecoder == nil {
-		messageDecoder = protocol.JSONMessageDecoder
+		messageDecoder = protocol.BSATNMessageDecoder
 	}
 
 	return &Connection{
-		ws:             ws,
+		transport:      transport,
 		connectionID:   connectionID,
 		endpoint:       endpoint,
 		messageDecoder: messageDecoder,
 		messageEncoder: messageEncoder,
 		onMessage:      onMessage,
 		onDisconnect:   onDisconnect,
+		sendQueue:      newSendQueue(DefaultSendQueueSize, OverflowBlock),
+		settled:        make(chan struct{}, 1),
 	}
 }
 
@@ -72,8 +91,21 @@ func (c *Connection) Endpoint() string {
 	return c.endpoint
 }
 
+// IsActive reports whether the connection is open. It turns false as soon as
+// the connection is lost, before OnDisconnect runs.
 func (c *Connection) IsActive() bool {
-	return !c.closed.Load()
+	return !c.closed.Load() && c.state.State() != StateClosed
+}
+
+// State returns the lifecycle state of the connection.
+func (c *Connection) State() State {
+	return c.state.State()
+}
+
+// LastError returns the error that most recently changed the connection
+// state, such as the error that closed it.
+func (c *Connection) LastError() error {
+	return c.state.LastError()
 }
 
 func (c *Connection) NextRequestID() uint32 {
@@ -116,40 +148,74 @@ func (c *Connection) RouteMessage(message protocol.RoutedMessage) error {
 	return nil
 }
 
+// SendBinary queues payload for the writer goroutine. It returns once the
+// payload is queued, not once it is written to the socket.
 func (c *Connection) SendBinary(payload []byte) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.closed.Load() {
+	return c.SendBinaryContext(context.Background(), payload)
+}
+
+// SendBinaryContext is SendBinary with a context that bounds how long the
+// call may wait for space in the queue under OverflowBlock.
+func (c *Connection) SendBinaryContext(ctx context.Context, payload []byte) error {
+	return c.enqueue(ctx, outboundMessage{payload: payload})
+}
+
+func (c *Connection) enqueue(ctx context.Context, msg outboundMessage) error {
+	if c.closed.Load() || c.sendQueue == nil {
 		return wrapError(ErrorConnectionClosed, "send_binary", errors.New("connection is closed"))
 	}
-	return c.ws.WriteMessage(websocket.BinaryMessage, payload)
+	if c.draining.Load() {
+		return wrapError(ErrorConnectionClosed, "send_binary", errors.New("connection is shutting down"))
+	}
+	q := c.sendQueue
+	q.startOnce.Do(func() { go c.runWriter(q) })
+	return q.enqueue(ctx, msg)
+}
+
+const defaultCloseTimeout = 5 * time.Second
+
+// closeDeadline is the deadline for a close handshake without one of its own.
+func (c *Connection) closeDeadline() time.Time {
+	if c.closeTimeout > 0 {
+		return time.Now().Add(c.closeTimeout)
+	}
+	return time.Now().Add(defaultCloseTimeout)
 }
 
 func (c *Connection) Disconnect() error {
 	if c.closed.Swap(true) {
 		return nil
 	}
+	c.sendClose(c.closeDeadline())
+	return c.transport.Close()
+}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	deadline := time.Now().Add(5 * time.Second)
-	_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
-	return c.ws.Close()
+// sendClose flushes the send queue and sends a close frame, both bounded by deadline.
+func (c *Connection) sendClose(deadline time.Time) {
+	c.state.Transition(StateClosed, nil)
+	if c.sendQueue != nil {
+		c.sendQueue.stop()
+		c.sendQueue.wait(deadline)
+	}
+	_ = c.transport.SendClose(deadline)
 }
 
 func (c *Connection) startReadLoop() {
+	done := make(chan struct{})
+	c.readLoopDone = make(chan struct{})
+	c.startKeepalive(done)
 	go func() {
+		defer close(c.readLoopDone)
 		defer func() { _ = c.Disconnect() }()
+		defer close(done)
 
 		for {
-			msgType, payload, err := c.ws.ReadMessage()
+			payload, err := c.transport.ReadFrame()
 			if err != nil {
 				c.notifyDisconnect(err)
 				return
 			}
-			if msgType != websocket.BinaryMessage {
-				continue
-			}
+			c.resetIdleTimer()
 
 			decompressed, err := decompressServerMessage(payload)
 			if err != nil {
@@ -179,6 +245,7 @@ func (c *Connection) startReadLoop() {
 
 func (c *Connection) notifyDisconnect(err error) {
 	c.disconnectOnce.Do(func() {
+		c.state.Transition(StateClosed, err)
 		c.failPendingCalls(err)
 		if c.onDisconnect != nil {
 			c.onDisconnect(err)
@@ -187,6 +254,14 @@ func (c *Connection) notifyDisconnect(err error) {
 }
 
 func (c *Connection) route(message protocol.RoutedMessage) {
+	switch message.Kind {
+	case protocol.MessageKindInitialConnection:
+		c.state.Transition(StateIdentified, nil)
+	case protocol.MessageKindUnsubscribeApplied, protocol.MessageKindSubscriptionError:
+		if message.QueryID != nil {
+			c.settleUnsubscribe(*message.QueryID)
+		}
+	}
 	if message.RequestID != nil {
 		if handler, ok := c.requestRoutes.Load(*message.RequestID); ok {
 			handler.(protocol.RouteHandler)(message)
@@ -218,7 +293,11 @@ func decompressServerMessage(payload []byte) ([]byte, error) {
 		copy(out, body)
 		return out, nil
 	case 1:
-		return nil, errors.New("brotli compression is not yet supported")
+		data, err := io.ReadAll(brotli.NewReader(bytes.NewReader(body)))
+		if err != nil {
+			return nil, fmt.Errorf("brotli decompress: %w", err)
+		}
+		return data, nil
 	case 2:
 		zr, err := gzip.NewReader(bytes.NewReader(body))
 		if err != nil {
@@ -242,15 +321,18 @@ func (c *Connection) failPendingCalls(err error) {
 			return true
 		}
 		callback, ok := value.(callResultCallback)
-		if !ok {
+		if !ok || !c.takeCall(requestID) {
 			return true
 		}
-		c.callCallbacks.Delete(requestID)
-		c.ClearRequestRoute(requestID)
 		callback(protocol.RoutedMessage{}, err)
 		return true
 	})
 
+	c.pendingUnsubscribes.Range(func(key, _ any) bool {
+		c.settleUnsubscribe(key.(uint32))
+		return true
+	})
+
 	c.subCallbacks.Range(func(key, value any) bool {
 		queryID, ok := key.(uint32)
 		if !ok {
@@ -3,7 +3,6 @@ package connection
 import (
 	"bytes"
 	"compress/gzip"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"strings"
@@ -11,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/andybalholm/brotli"
 	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
 	"github.com/gorilla/websocket"
 )
@@ -132,13 +132,29 @@ func TestDecompressServerMessage(t *testing.T) {
 		}
 	})
 
+	t.Run("brotli", func(t *testing.T) {
+		var compressed bytes.Buffer
+		bw := brotli.NewWriter(&compressed)
+		_, _ = bw.Write([]byte("hello brotli"))
+		_ = bw.Close()
+
+		raw := append([]byte{1}, compressed.Bytes()...)
+		decompressed, err := decompressServerMessage(raw)
+		if err != nil {
+			t.Fatalf("decompress: %v", err)
+		}
+		if !bytes.Equal(decompressed, []byte("hello brotli")) {
+			t.Fatalf("unexpected brotli body: %q", string(decompressed))
+		}
+	})
+
 	t.Run("errors", func(t *testing.T) {
 		cases := []struct {
 			name string
 			raw  []byte
 		}{
 			{name: "empty", raw: []byte{}},
-			{name: "brotli unsupported", raw: []byte{1, 1, 2, 3}},
+			{name: "bad brotli", raw: []byte{1, 0xff, 0xff, 0xff}},
 			{name: "unknown scheme", raw: []byte{9, 1}},
 			{name: "bad gzip", raw: []byte{2, 1, 2, 3}},
 		}
@@ -206,8 +222,8 @@ func TestCallReducerSendsAndDispatchesCallback(t *testing.T) {
 
 	select {
 	case raw := <-incoming:
-		var sent protocol.ClientMessage
-		if err := json.Unmarshal(raw, &sent); err != nil {
+		sent, err := protocol.BSATNClientMessageDecoder(raw)
+		if err != nil {
 			t.Fatalf("unmarshal outgoing reducer call: %v", err)
 		}
 		if sent.Kind != protocol.ClientMessageCallReducer || sent.Reducer != "set_name" || sent.RequestID != requestID {
@@ -326,8 +342,8 @@ func TestOneOffQuerySendsAndDispatchesCallback(t *testing.T) {
 
 	select {
 	case raw := <-incoming:
-		var sent protocol.ClientMessage
-		if err := json.Unmarshal(raw, &sent); err != nil {
+		sent, err := protocol.BSATNClientMessageDecoder(raw)
+		if err != nil {
 			t.Fatalf("unmarshal outgoing one-off query: %v", err)
 		}
 		if sent.Kind != protocol.ClientMessageOneOffQuery || sent.RequestID != requestID || sent.Query != "select * from users" {
@@ -376,7 +392,8 @@ func TestSubscribeAndUnsubscribeMessageFlow(t *testing.T) {
 	var subscribeMsg protocol.ClientMessage
 	select {
 	case raw := <-incoming:
-		if err := json.Unmarshal(raw, &subscribeMsg); err != nil {
+		subscribeMsg, err = protocol.BSATNClientMessageDecoder(raw)
+		if err != nil {
 			t.Fatalf("unmarshal outgoing subscribe: %v", err)
 		}
 	case <-time.After(2 * time.Second):
@@ -406,8 +423,8 @@ func TestSubscribeAndUnsubscribeMessageFlow(t *testing.T) {
 
 	select {
 	case raw := <-incoming:
-		var unsubscribeMsg protocol.ClientMessage
-		if err := json.Unmarshal(raw, &unsubscribeMsg); err != nil {
+		unsubscribeMsg, err := protocol.BSATNClientMessageDecoder(raw)
+		if err != nil {
 			t.Fatalf("unmarshal outgoing unsubscribe: %v", err)
 		}
 		if unsubscribeMsg.Kind != protocol.ClientMessageUnsubscribe || unsubscribeMsg.QueryID == nil || *unsubscribeMsg.QueryID != queryID {
@@ -567,11 +584,11 @@ func TestFailedSendClearsPendingRequestCallbacks(t *testing.T) {
 func buildTestConnection(t *testing.T, serverURL string) (*Connection, error) {
 	t.Helper()
 	wsURL := toWebsocketURL(t, serverURL)
-	ws, err := dialWebsocket(t.Context(), wsURL, nil)
+	ws, err := dialWebsocket(t.Context(), DialOptions{}, wsURL, nil)
 	if err != nil {
 		return nil, err
 	}
-	return newConnection(ws, "cid", wsURL.String(), nil, nil, nil, nil), nil
+	return newConnection(NewWebsocketTransport(ws), "cid", wsURL.String(), nil, nil, nil, nil), nil
 }
 
 func startWebsocketEchoSink(t *testing.T, incoming chan<- []byte) (string, func()) {
@@ -14,3 +14,14 @@ func TestIsCodeMatchesWrappedError(t *testing.T) {
 		t.Fatalf("did not expect IsCode to match ErrorEncodeFailed")
 	}
 }
+
+func TestIsCodeMatchesNestedError(t *testing.T) {
+	inner := wrapError(ErrorQueueFull, "enqueue", errors.New("full"))
+	err := wrapError(ErrorSendFailed, "send_call_reducer", inner)
+	if !IsCode(err, ErrorQueueFull) {
+		t.Fatalf("expected IsCode to match the nested ErrorQueueFull")
+	}
+	if !IsCode(err, ErrorSendFailed) {
+		t.Fatalf("expected IsCode to match the outer ErrorSendFailed")
+	}
+}
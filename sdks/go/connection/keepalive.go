@@ -0,0 +1,80 @@
+package connection
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultPingInterval is how often a connection pings the server unless
+	// configured otherwise with Builder.WithPingInterval.
+	DefaultPingInterval = 30 * time.Second
+	// DefaultIdleTimeout is how long a connection waits for any frame from the
+	// server unless configured otherwise with Builder.WithIdleTimeout.
+	DefaultIdleTimeout = 90 * time.Second
+
+	controlWriteTimeout = 5 * time.Second
+)
+
+// RTT returns the round-trip time measured by the most recent keepalive
+// ping, or zero before the first pong arrives.
+func (c *Connection) RTT() time.Duration {
+	return time.Duration(c.lastRTT.Load())
+}
+
+// startKeepalive observes control frames, arms the idle timer and starts the
+// ping loop, all of which run until done is closed.
+func (c *Connection) startKeepalive(done <-chan struct{}) {
+	c.transport.OnControl(func(frame ControlFrame, payload []byte) {
+		if frame == ControlPong && len(payload) == 8 {
+			sent := time.Unix(0, int64(binary.BigEndian.Uint64(payload)))
+			c.lastRTT.Store(int64(time.Since(sent)))
+		}
+		c.resetIdleTimer()
+	})
+	if c.idleTimeout > 0 {
+		c.idleTimer = time.AfterFunc(c.idleTimeout, func() {
+			c.notifyDisconnect(wrapError(ErrorTimeout, "read", fmt.Errorf("no message from server for %s", c.idleTimeout)))
+			_ = c.transport.Close()
+		})
+		go func() {
+			<-done
+			c.idleTimer.Stop()
+		}()
+	}
+
+	if c.pingInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(c.pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if err := c.ping(now); err != nil {
+					c.notifyDisconnect(wrapError(ErrorSendFailed, "ping", err))
+					_ = c.transport.Close()
+					return
+				}
+			}
+		}
+	}()
+}
+
+// ping sends a ping whose payload is the send time, echoed back in the pong.
+func (c *Connection) ping(now time.Time) error {
+	var payload [8]byte
+	binary.BigEndian.PutUint64(payload[:], uint64(now.UnixNano()))
+	return c.transport.Ping(payload[:], now.Add(controlWriteTimeout))
+}
+
+// resetIdleTimer pushes the idle timeout forward after any frame from the server.
+func (c *Connection) resetIdleTimer() {
+	if c.idleTimer != nil {
+		c.idleTimer.Reset(c.idleTimeout)
+	}
+}
@@ -0,0 +1,107 @@
+package connection
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/gorilla/websocket"
+)
+
+func TestIdleTimeoutClosesSilentConnection(t *testing.T) {
+	serverURL, cleanup := startWebsocketServer(t, func(conn *websocket.Conn) {
+		// Never read, so pings go unanswered and nothing reaches the client.
+		time.Sleep(500 * time.Millisecond)
+	})
+	defer cleanup()
+
+	disconnected := make(chan error, 1)
+	c, err := NewBuilder().
+		WithURI(serverURL).
+		WithDatabaseName("db").
+		WithPingInterval(0).
+		WithIdleTimeout(50 * time.Millisecond).
+		OnDisconnect(func(err error) { disconnected <- err }).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer c.Disconnect()
+
+	select {
+	case err := <-disconnected:
+		if !IsCode(err, ErrorTimeout) {
+			t.Fatalf("expected ErrorTimeout, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for idle timeout")
+	}
+	if c.IsActive() {
+		t.Fatalf("expected connection to be closed after idle timeout")
+	}
+}
+
+func TestPingsMeasureRTTAndKeepConnectionAlive(t *testing.T) {
+	serverURL, cleanup := startWebsocketServer(t, func(conn *websocket.Conn) {
+		// Reading lets gorilla answer pings with pongs.
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer cleanup()
+
+	disconnected := make(chan error, 1)
+	c, err := NewBuilder().
+		WithURI(serverURL).
+		WithDatabaseName("db").
+		WithPingInterval(10 * time.Millisecond).
+		WithIdleTimeout(50 * time.Millisecond).
+		OnDisconnect(func(err error) { disconnected <- err }).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer c.Disconnect()
+
+	select {
+	case err := <-disconnected:
+		t.Fatalf("unexpected disconnect: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if c.RTT() <= 0 {
+		t.Fatalf("expected a measured round-trip time")
+	}
+	if !c.IsActive() {
+		t.Fatalf("expected pongs to keep the connection alive")
+	}
+}
+
+func TestNewBuilderKeepaliveDefaults(t *testing.T) {
+	b := NewBuilder()
+	if b.pingInterval != DefaultPingInterval || b.idleTimeout != DefaultIdleTimeout {
+		t.Fatalf("unexpected keepalive defaults: ping=%v idle=%v", b.pingInterval, b.idleTimeout)
+	}
+}
+
+// startWebsocketServer upgrades every request and hands the socket to serve.
+func startWebsocketServer(t *testing.T, serve func(*websocket.Conn)) (string, func()) {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{protocol.WSSubprotocolV2},
+		CheckOrigin:  func(r *http.Request) bool { return true },
+	}
+	server := newLocalHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(conn)
+	}))
+	return server.URL, server.Close
+}
@@ -0,0 +1,165 @@
+package connection
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPipeClosed is returned by a pipe transport once either end is closed or
+// the close handshake completed.
+var ErrPipeClosed = errors.New("pipe transport closed")
+
+// pipeBufferSize is how many frames each direction of a pipe buffers before
+// writes block.
+const pipeBufferSize = 64
+
+type pipeFrameKind int
+
+const (
+	pipeData pipeFrameKind = iota
+	pipePing
+	pipePong
+	pipeClose
+)
+
+type pipeFrame struct {
+	kind    pipeFrameKind
+	payload []byte
+}
+
+type pipeTransport struct {
+	in     chan pipeFrame
+	out    chan pipeFrame
+	closed chan struct{}
+	peer   *pipeTransport
+
+	closeOnce sync.Once
+	closeSent atomic.Bool
+
+	mu        sync.Mutex
+	onControl func(ControlFrame, []byte)
+}
+
+// NewPipe returns the two ends of an in-memory transport. Frames written to
+// one end are read from the other in order, pings are answered with pongs and
+// the close handshake behaves as it does over a websocket, so a fake server
+// can drive a Connection through the server end without opening sockets.
+// Frames written before an end is closed can still be read by its peer.
+func NewPipe() (client, server Transport) {
+	toServer := make(chan pipeFrame, pipeBufferSize)
+	toClient := make(chan pipeFrame, pipeBufferSize)
+	c := &pipeTransport{in: toClient, out: toServer, closed: make(chan struct{})}
+	s := &pipeTransport{in: toServer, out: toClient, closed: make(chan struct{})}
+	c.peer, s.peer = s, c
+	return c, s
+}
+
+func (t *pipeTransport) ReadFrame() ([]byte, error) {
+	for {
+		select {
+		case <-t.closed:
+			return nil, ErrPipeClosed
+		default:
+		}
+
+		var frame pipeFrame
+		select {
+		case frame = <-t.in:
+		default:
+			select {
+			case frame = <-t.in:
+			case <-t.closed:
+				return nil, ErrPipeClosed
+			case <-t.peer.closed:
+				return nil, ErrPipeClosed
+			}
+		}
+
+		switch frame.kind {
+		case pipeData:
+			return frame.payload, nil
+		case pipePing:
+			t.control(ControlPing, frame.payload)
+			_ = t.write(pipeFrame{kind: pipePong, payload: frame.payload}, time.Now().Add(controlWriteTimeout))
+		case pipePong:
+			t.control(ControlPong, frame.payload)
+		case pipeClose:
+			if !t.closeSent.Load() {
+				_ = t.SendClose(time.Now().Add(controlWriteTimeout))
+			}
+			return nil, ErrPipeClosed
+		}
+	}
+}
+
+func (t *pipeTransport) WriteFrame(payload []byte) error {
+	if t.closeSent.Load() {
+		return ErrPipeClosed
+	}
+	return t.write(pipeFrame{kind: pipeData, payload: append([]byte(nil), payload...)}, time.Time{})
+}
+
+func (t *pipeTransport) Ping(payload []byte, deadline time.Time) error {
+	if t.closeSent.Load() {
+		return ErrPipeClosed
+	}
+	return t.write(pipeFrame{kind: pipePing, payload: append([]byte(nil), payload...)}, deadline)
+}
+
+func (t *pipeTransport) SendClose(deadline time.Time) error {
+	if t.closeSent.Swap(true) {
+		return ErrPipeClosed
+	}
+	return t.write(pipeFrame{kind: pipeClose}, deadline)
+}
+
+func (t *pipeTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return nil
+}
+
+func (t *pipeTransport) OnControl(fn func(ControlFrame, []byte)) {
+	t.mu.Lock()
+	t.onControl = fn
+	t.mu.Unlock()
+}
+
+// write queues frame for the peer, failing once either end is closed or,
+// when deadline is non-zero, once it passes.
+func (t *pipeTransport) write(frame pipeFrame, deadline time.Time) error {
+	select {
+	case <-t.closed:
+		return ErrPipeClosed
+	case <-t.peer.closed:
+		return ErrPipeClosed
+	default:
+	}
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case t.out <- frame:
+		return nil
+	case <-t.closed:
+		return ErrPipeClosed
+	case <-t.peer.closed:
+		return ErrPipeClosed
+	case <-expired:
+		return wrapError(ErrorTimeout, "pipe_write", errors.New("write deadline exceeded"))
+	}
+}
+
+func (t *pipeTransport) control(frame ControlFrame, payload []byte) {
+	t.mu.Lock()
+	fn := t.onControl
+	t.mu.Unlock()
+	if fn != nil {
+		fn(frame, payload)
+	}
+}
@@ -0,0 +1,186 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+func TestPipeCarriesFramesAndAnswersPings(t *testing.T) {
+	client, server := NewPipe()
+	defer client.Close()
+
+	pongs := make(chan []byte, 1)
+	client.OnControl(func(frame ControlFrame, payload []byte) {
+		if frame == ControlPong {
+			pongs <- payload
+		}
+	})
+	pings := make(chan []byte, 1)
+	server.OnControl(func(frame ControlFrame, payload []byte) {
+		if frame == ControlPing {
+			pings <- payload
+		}
+	})
+
+	if err := client.Ping([]byte("p"), time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	if err := client.WriteFrame([]byte("hello")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// Reading the data frame makes the server answer the ping before it.
+	if frame, err := server.ReadFrame(); err != nil || string(frame) != "hello" {
+		t.Fatalf("unexpected server read %q: %v", frame, err)
+	}
+	if payload := <-pings; string(payload) != "p" {
+		t.Fatalf("unexpected ping payload %q", payload)
+	}
+
+	if err := server.WriteFrame([]byte("world")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if frame, err := client.ReadFrame(); err != nil || string(frame) != "world" {
+		t.Fatalf("unexpected client read %q: %v", frame, err)
+	}
+	if payload := <-pongs; string(payload) != "p" {
+		t.Fatalf("unexpected pong payload %q", payload)
+	}
+}
+
+func TestPipeCloseHandshakeAndClose(t *testing.T) {
+	client, server := NewPipe()
+
+	if err := client.WriteFrame([]byte("last")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := client.SendClose(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("send close: %v", err)
+	}
+	if err := client.WriteFrame([]byte("late")); !errors.Is(err, ErrPipeClosed) {
+		t.Fatalf("expected writes after the close frame to fail, got %v", err)
+	}
+
+	if frame, err := server.ReadFrame(); err != nil || string(frame) != "last" {
+		t.Fatalf("expected frames sent before close, got %q: %v", frame, err)
+	}
+	if _, err := server.ReadFrame(); !errors.Is(err, ErrPipeClosed) {
+		t.Fatalf("expected the close frame to end the server read, got %v", err)
+	}
+	if _, err := client.ReadFrame(); !errors.Is(err, ErrPipeClosed) {
+		t.Fatalf("expected the close reply to end the client read, got %v", err)
+	}
+
+	other, peer := NewPipe()
+	read := make(chan error, 1)
+	go func() {
+		_, err := other.ReadFrame()
+		read <- err
+	}()
+	_ = peer.Close()
+	select {
+	case err := <-read:
+		if !errors.Is(err, ErrPipeClosed) {
+			t.Fatalf("expected ErrPipeClosed, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for Close to unblock the peer")
+	}
+}
+
+func TestBuilderRunsOverPipeTransport(t *testing.T) {
+	client, server := NewPipe()
+	go func() {
+		defer server.Close()
+		writePipeMessage(t, server, clientapi.ServerMessageInitialConnection{
+			Value: clientapi.InitialConnection{Identity: types.Identity{1}, ConnectionId: types.ConnectionId{2}, Token: "token"},
+		})
+		for {
+			frame, err := server.ReadFrame()
+			if err != nil {
+				return
+			}
+			message, err := protocol.BSATNClientMessageDecoder(frame)
+			if err != nil {
+				t.Errorf("decode client message: %v", err)
+				return
+			}
+			writePipeMessage(t, server, reducerResult(message.RequestID, clientapi.ReducerOutcomeOkEmpty{}))
+		}
+	}()
+
+	var endpoint string
+	c, err := NewBuilder().
+		WithURI("http://in-memory").
+		WithDatabaseName("db").
+		WithDialer(func(ctx context.Context, u *url.URL, headers http.Header) (Transport, error) {
+			endpoint = u.String()
+			return client, nil
+		}).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if endpoint != c.Endpoint() {
+		t.Fatalf("expected the dialer to receive the subscribe url %q, got %q", c.Endpoint(), endpoint)
+	}
+
+	if _, err := c.CallReducerWait(context.Background(), "add", nil); err != nil {
+		t.Fatalf("call reducer wait: %v", err)
+	}
+	if c.State() != StateIdentified {
+		t.Fatalf("expected identified state, got %s", c.State())
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if c.State() != StateClosed || c.LastError() != nil {
+		t.Fatalf("expected a clean close, got %s / %v", c.State(), c.LastError())
+	}
+}
+
+func TestIdleTimeoutClosesSilentPipe(t *testing.T) {
+	client, server := NewPipe()
+	defer server.Close()
+
+	disconnected := make(chan error, 1)
+	c, err := NewBuilder().
+		WithURI("http://in-memory").
+		WithDatabaseName("db").
+		WithTransport(client).
+		WithPingInterval(0).
+		WithIdleTimeout(30 * time.Millisecond).
+		OnDisconnect(func(err error) { disconnected <- err }).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer c.Disconnect()
+
+	select {
+	case err := <-disconnected:
+		if !IsCode(err, ErrorTimeout) {
+			t.Fatalf("expected ErrorTimeout, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for idle timeout")
+	}
+}
+
+// writePipeMessage sends an uncompressed BSATN server message. It runs on
+// server goroutines, so failures are reported with t.Errorf.
+func writePipeMessage(t *testing.T, server Transport, variant clientapi.ServerMessageVariant) {
+	encoded, err := protocol.EncodeServerMessage(clientapi.NewServerMessage(variant))
+	if err != nil {
+		t.Errorf("encode server message: %v", err)
+		return
+	}
+	_ = server.WriteFrame(append([]byte{0}, encoded...))
+}
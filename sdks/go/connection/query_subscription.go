@@ -1,6 +1,8 @@
 package connection
 
 import (
+	"context"
+
 	"github.com/clockworklabs/spacetimedb/sdks/go/events"
 	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
 	sdksubscription "github.com/clockworklabs/spacetimedb/sdks/go/subscription"
@@ -12,11 +14,18 @@ type SubscriptionCallback = sdksubscription.Callback
 type subscriptionCallback = sdksubscription.Callback
 
 func (c *Connection) OneOffQuery(query string, callback OneOffQueryResultCallback) (uint32, error) {
+	return c.OneOffQueryContext(context.Background(), query, callback)
+}
+
+// OneOffQueryContext is OneOffQuery with a context that bounds the whole
+// query, as in CallReducerContext.
+func (c *Connection) OneOffQueryContext(ctx context.Context, query string, callback OneOffQueryResultCallback) (uint32, error) {
 	if query == "" {
 		return 0, newInvalidArgument("one_off_query", "query is required")
 	}
 
 	return c.callWithRequestRoute(
+		ctx,
 		protocol.ClientMessage{
 			Kind:      protocol.ClientMessageOneOffQuery,
 			RequestID: c.NextRequestID(),
@@ -28,16 +37,41 @@ func (c *Connection) OneOffQuery(query string, callback OneOffQueryResultCallbac
 }
 
 func (c *Connection) Subscribe(queryStrings []string, callback SubscriptionCallback) (uint32, error) {
+	return c.SubscribeContext(context.Background(), queryStrings, callback)
+}
+
+// SubscribeContext is Subscribe with a context that bounds how long the
+// subscription may wait for space in the send queue.
+func (c *Connection) SubscribeContext(ctx context.Context, queryStrings []string, callback SubscriptionCallback) (uint32, error) {
+	if err := validateQueryStrings(queryStrings); err != nil {
+		return 0, err
+	}
+	queryID := c.NextQueryID()
+	return queryID, c.subscribe(ctx, queryID, queryStrings, callback)
+}
+
+// SubscribeQueryID is SubscribeContext with a query id reserved beforehand
+// with NextQueryID, so the caller can record it before the server can answer.
+func (c *Connection) SubscribeQueryID(ctx context.Context, queryID uint32, queryStrings []string, callback SubscriptionCallback) error {
+	if err := validateQueryStrings(queryStrings); err != nil {
+		return err
+	}
+	return c.subscribe(ctx, queryID, queryStrings, callback)
+}
+
+func validateQueryStrings(queryStrings []string) error {
 	if len(queryStrings) == 0 {
-		return 0, newInvalidArgument("subscribe", "at least one query string is required")
+		return newInvalidArgument("subscribe", "at least one query string is required")
 	}
 	for _, query := range queryStrings {
 		if query == "" {
-			return 0, newInvalidArgument("subscribe", "query strings must be non-empty")
+			return newInvalidArgument("subscribe", "query strings must be non-empty")
 		}
 	}
+	return nil
+}
 
-	queryID := c.NextQueryID()
+func (c *Connection) subscribe(ctx context.Context, queryID uint32, queryStrings []string, callback SubscriptionCallback) error {
 	requestID := c.NextRequestID()
 	if callback != nil {
 		wrapped := subscriptionCallback(func(message protocol.RoutedMessage, err error) {
@@ -61,29 +95,62 @@ func (c *Connection) Subscribe(queryStrings []string, callback SubscriptionCallb
 		})
 	}
 
-	if err := c.sendClientMessage(protocol.ClientMessage{
+	var onDrop func(error)
+	if callback != nil {
+		onDrop = func(err error) {
+			if _, ok := c.subCallbacks.LoadAndDelete(queryID); ok {
+				c.ClearQueryRoute(queryID)
+				callback(protocol.RoutedMessage{}, err)
+			}
+		}
+	}
+
+	if err := c.sendClientMessage(ctx, protocol.ClientMessage{
 		Kind:         protocol.ClientMessageSubscribe,
 		RequestID:    requestID,
 		QueryID:      &queryID,
 		QueryStrings: queryStrings,
-	}); err != nil {
+	}, onDrop); err != nil {
 		if callback != nil {
 			c.subCallbacks.Delete(queryID)
 			c.ClearQueryRoute(queryID)
 		}
-		return queryID, err
+		return err
 	}
 
-	return queryID, nil
+	return nil
 }
 
 func (c *Connection) Unsubscribe(queryID uint32) (uint32, error) {
+	return c.UnsubscribeContext(context.Background(), queryID)
+}
+
+// UnsubscribeContext is Unsubscribe with a context that bounds how long the
+// request may wait for space in the send queue.
+//
+// If the request is later dropped from a full send queue, the subscription
+// stays active and its callback receives an unsubscribe_applied message
+// together with the ErrorQueueFull error.
+func (c *Connection) UnsubscribeContext(ctx context.Context, queryID uint32) (uint32, error) {
 	requestID := c.NextRequestID()
-	if err := c.sendClientMessage(protocol.ClientMessage{
+	// Shutdown waits for the server to confirm the unsubscribe.
+	c.pendingUnsubscribes.Store(queryID, struct{}{})
+	onDrop := func(err error) {
+		c.settleUnsubscribe(queryID)
+		if callback, ok := c.subCallbacks.Load(queryID); ok {
+			callback.(subscriptionCallback)(protocol.RoutedMessage{
+				Kind:      protocol.MessageKindUnsubscribeApplied,
+				RequestID: &requestID,
+				QueryID:   &queryID,
+			}, err)
+		}
+	}
+	if err := c.sendClientMessage(ctx, protocol.ClientMessage{
 		Kind:      protocol.ClientMessageUnsubscribe,
 		RequestID: requestID,
 		QueryID:   &queryID,
-	}); err != nil {
+	}, onDrop); err != nil {
+		c.settleUnsubscribe(queryID)
 		return requestID, err
 	}
 	return requestID, nil
@@ -0,0 +1,254 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what happens to a message sent while the outbound
+// queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for space in the queue, honoring the caller's context.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowFailFast rejects the message with an ErrorQueueFull error.
+	OverflowFailFast
+	// OverflowDropOldest discards the oldest queued message to make room. The
+	// callback of a dropped call receives an ErrorQueueFull error.
+	OverflowDropOldest
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowFailFast:
+		return "fail_fast"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// DefaultSendQueueSize is the outbound queue capacity unless configured
+// otherwise with Builder.WithSendQueue.
+const DefaultSendQueueSize = 256
+
+// SendQueueStats is a point-in-time view of a connection's outbound queue.
+type SendQueueStats struct {
+	// Depth is the number of messages waiting for the writer.
+	Depth int
+	// Capacity is the maximum number of queued messages.
+	Capacity int
+	// MaxDepth is the highest depth observed since the connection opened.
+	MaxDepth int
+	// Enqueued counts messages accepted into the queue.
+	Enqueued uint64
+	// Sent counts messages written to the socket.
+	Sent uint64
+	// Dropped counts messages discarded by OverflowDropOldest or left unsent
+	// after a write failed.
+	Dropped uint64
+	// Rejected counts messages refused by OverflowFailFast.
+	Rejected uint64
+}
+
+type outboundMessage struct {
+	payload []byte
+	// onDrop is called if the message is discarded by OverflowDropOldest or
+	// left unsent after a write failed.
+	onDrop func(error)
+}
+
+// sendQueue is the bounded queue between senders and a connection's writer goroutine.
+type sendQueue struct {
+	policy OverflowPolicy
+	items  chan outboundMessage
+	done   chan struct{}
+	// exited is closed when the writer goroutine returns.
+	exited chan struct{}
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	maxDepth atomic.Int64
+	enqueued atomic.Uint64
+	sent     atomic.Uint64
+	dropped  atomic.Uint64
+	rejected atomic.Uint64
+}
+
+func newSendQueue(size int, policy OverflowPolicy) *sendQueue {
+	if size <= 0 {
+		size = DefaultSendQueueSize
+	}
+	return &sendQueue{
+		policy: policy,
+		items:  make(chan outboundMessage, size),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+}
+
+func (q *sendQueue) enqueue(ctx context.Context, msg outboundMessage) error {
+	if q.stopped() {
+		return wrapError(ErrorConnectionClosed, "enqueue", errors.New("connection is closed"))
+	}
+
+	switch q.policy {
+	case OverflowFailFast:
+		if !q.tryPush(msg) {
+			q.rejected.Add(1)
+			return wrapError(ErrorQueueFull, "enqueue", fmt.Errorf("send queue is full (%d messages)", cap(q.items)))
+		}
+	case OverflowDropOldest:
+		for !q.tryPush(msg) {
+			select {
+			case old := <-q.items:
+				q.dropped.Add(1)
+				if old.onDrop != nil {
+					old.onDrop(wrapError(ErrorQueueFull, "drop_oldest", errors.New("message dropped from full send queue")))
+				}
+			default:
+			}
+		}
+	default:
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		select {
+		case q.items <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-q.done:
+			return wrapError(ErrorConnectionClosed, "enqueue", errors.New("connection is closed"))
+		}
+	}
+
+	q.enqueued.Add(1)
+	depth := int64(len(q.items))
+	for {
+		seen := q.maxDepth.Load()
+		if depth <= seen || q.maxDepth.CompareAndSwap(seen, depth) {
+			break
+		}
+	}
+	return nil
+}
+
+func (q *sendQueue) tryPush(msg outboundMessage) bool {
+	select {
+	case q.items <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *sendQueue) stop() {
+	q.stopOnce.Do(func() { close(q.done) })
+}
+
+// wait blocks until the writer has flushed and returned, or until deadline.
+func (q *sendQueue) wait(deadline time.Time) {
+	// A writer that never started has nothing to flush.
+	q.startOnce.Do(func() { close(q.exited) })
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-q.exited:
+	case <-timer.C:
+	}
+}
+
+func (q *sendQueue) stopped() bool {
+	select {
+	case <-q.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *sendQueue) stats() SendQueueStats {
+	return SendQueueStats{
+		Depth:    len(q.items),
+		Capacity: cap(q.items),
+		MaxDepth: int(q.maxDepth.Load()),
+		Enqueued: q.enqueued.Load(),
+		Sent:     q.sent.Load(),
+		Dropped:  q.dropped.Load(),
+		Rejected: q.rejected.Load(),
+	}
+}
+
+// SendQueueStats reports the depth and counters of the outbound queue.
+func (c *Connection) SendQueueStats() SendQueueStats {
+	if c.sendQueue == nil {
+		return SendQueueStats{}
+	}
+	return c.sendQueue.stats()
+}
+
+// runWriter writes queued messages to the socket until the queue is stopped
+// or a write fails, which closes the connection. Messages queued before the
+// queue was stopped are flushed so they reach the server ahead of the close frame.
+func (c *Connection) runWriter(q *sendQueue) {
+	err := c.writeQueued(q)
+	close(q.exited)
+	if err != nil {
+		c.notifyDisconnect(wrapError(ErrorSendFailed, "write", err))
+		_ = c.transport.Close()
+	}
+}
+
+func (c *Connection) writeQueued(q *sendQueue) error {
+	for {
+		select {
+		case msg := <-q.items:
+			if err := c.transport.WriteFrame(msg.payload); err != nil {
+				q.stop()
+				q.discard(msg, err)
+				return err
+			}
+			q.sent.Add(1)
+		case <-q.done:
+			for {
+				select {
+				case msg := <-q.items:
+					if err := c.transport.WriteFrame(msg.payload); err != nil {
+						q.discard(msg, err)
+						return err
+					}
+					q.sent.Add(1)
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// discard drops failed and every message still queued behind it after a
+// write error, telling their senders why.
+func (q *sendQueue) discard(failed outboundMessage, err error) {
+	err = wrapError(ErrorSendFailed, "write", err)
+	msg := failed
+	for {
+		q.dropped.Add(1)
+		if msg.onDrop != nil {
+			msg.onDrop(err)
+		}
+		select {
+		case msg = <-q.items:
+		default:
+			return
+		}
+	}
+}
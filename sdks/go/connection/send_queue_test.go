@@ -0,0 +1,202 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+)
+
+func TestSendQueueFailFastRejectsWhenFull(t *testing.T) {
+	q := newSendQueue(1, OverflowFailFast)
+	if err := q.enqueue(context.Background(), outboundMessage{payload: []byte("a")}); err != nil {
+		t.Fatalf("first enqueue: %v", err)
+	}
+	err := q.enqueue(context.Background(), outboundMessage{payload: []byte("b")})
+	if !IsCode(err, ErrorQueueFull) {
+		t.Fatalf("expected ErrorQueueFull, got %v", err)
+	}
+
+	stats := q.stats()
+	want := SendQueueStats{Depth: 1, Capacity: 1, MaxDepth: 1, Enqueued: 1, Rejected: 1}
+	if stats != want {
+		t.Fatalf("unexpected stats: got %+v want %+v", stats, want)
+	}
+}
+
+func TestSendQueueBlockHonorsContext(t *testing.T) {
+	q := newSendQueue(1, OverflowBlock)
+	if err := q.enqueue(context.Background(), outboundMessage{payload: []byte("a")}); err != nil {
+		t.Fatalf("first enqueue: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.enqueue(ctx, outboundMessage{payload: []byte("b")}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline error, got %v", err)
+	}
+
+	q.stop()
+	if err := q.enqueue(context.Background(), outboundMessage{payload: []byte("c")}); !IsCode(err, ErrorConnectionClosed) {
+		t.Fatalf("expected ErrorConnectionClosed after stop, got %v", err)
+	}
+}
+
+func TestDropOldestFailsDroppedCallCallback(t *testing.T) {
+	q := newSendQueue(1, OverflowDropOldest)
+	// Hold the writer back so the queue stays full.
+	q.startOnce.Do(func() {})
+	c := &Connection{messageEncoder: protocol.BSATNMessageEncoder, sendQueue: q}
+
+	dropped := make(chan error, 1)
+	firstID, err := c.CallReducer("first", nil, func(_ protocol.RoutedMessage, err error) { dropped <- err })
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if _, err := c.CallReducer("second", nil, func(protocol.RoutedMessage, error) {
+		t.Errorf("second call should stay queued")
+	}); err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+
+	select {
+	case err := <-dropped:
+		if !IsCode(err, ErrorQueueFull) {
+			t.Fatalf("expected ErrorQueueFull for dropped call, got %v", err)
+		}
+	default:
+		t.Fatalf("expected dropped call callback to run")
+	}
+	if _, ok := c.requestRoutes.Load(firstID); ok {
+		t.Fatalf("expected dropped call route to be cleared")
+	}
+	if stats := c.SendQueueStats(); stats.Dropped != 1 || stats.Depth != 1 || stats.Enqueued != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestDisconnectFlushesQueuedMessages(t *testing.T) {
+	incoming := make(chan []byte, 8)
+	serverURL, cleanup := startWebsocketEchoSink(t, incoming)
+	defer cleanup()
+
+	c, err := buildTestConnection(t, serverURL)
+	if err != nil {
+		t.Fatalf("build test connection: %v", err)
+	}
+	for i := range 3 {
+		if err := c.SendBinary([]byte(fmt.Sprintf("m%d", i))); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+
+	for i := range 3 {
+		select {
+		case payload := <-incoming:
+			if want := fmt.Sprintf("m%d", i); string(payload) != want {
+				t.Fatalf("message %d: got %q want %q", i, payload, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for message %d", i)
+		}
+	}
+	if stats := c.SendQueueStats(); stats.Sent != 3 || stats.Depth != 0 {
+		t.Fatalf("unexpected stats after disconnect: %+v", stats)
+	}
+	if err := c.SendBinary([]byte("late")); !IsCode(err, ErrorConnectionClosed) {
+		t.Fatalf("expected ErrorConnectionClosed after disconnect, got %v", err)
+	}
+}
+
+func TestFailedFlushReportsDisconnectAndDropsRest(t *testing.T) {
+	client, server := NewPipe()
+	_ = server.Close()
+	q := newSendQueue(4, OverflowBlock)
+	q.startOnce.Do(func() {})
+	disconnected := make(chan error, 1)
+	c := &Connection{
+		transport:    client,
+		sendQueue:    q,
+		onDisconnect: func(err error) { disconnected <- err },
+	}
+
+	dropped := make(chan error, 2)
+	for i := range 2 {
+		msg := outboundMessage{payload: []byte(fmt.Sprintf("m%d", i)), onDrop: func(err error) { dropped <- err }}
+		if err := q.enqueue(context.Background(), msg); err != nil {
+			t.Fatalf("enqueue %d: %v", i, err)
+		}
+	}
+	// Flush as Disconnect does, after the queue has been stopped.
+	q.stop()
+	c.runWriter(q)
+
+	select {
+	case err := <-disconnected:
+		if !IsCode(err, ErrorSendFailed) {
+			t.Fatalf("expected ErrorSendFailed, got %v", err)
+		}
+	default:
+		t.Fatalf("expected the failed flush to report a disconnect")
+	}
+	for i := range 2 {
+		select {
+		case err := <-dropped:
+			if !IsCode(err, ErrorSendFailed) {
+				t.Fatalf("message %d: expected ErrorSendFailed, got %v", i, err)
+			}
+		default:
+			t.Fatalf("expected message %d to be reported dropped", i)
+		}
+	}
+	if stats := q.stats(); stats.Dropped != 2 || stats.Sent != 0 || stats.Depth != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestDropOldestSettlesDroppedUnsubscribe(t *testing.T) {
+	q := newSendQueue(1, OverflowDropOldest)
+	q.startOnce.Do(func() {})
+	c := &Connection{messageEncoder: protocol.BSATNMessageEncoder, sendQueue: q}
+
+	messages := make(chan error, 1)
+	queryID, err := c.Subscribe([]string{"SELECT * FROM players"}, func(message protocol.RoutedMessage, err error) {
+		if message.Kind != protocol.MessageKindUnsubscribeApplied {
+			t.Errorf("unexpected message kind %q", message.Kind)
+		}
+		messages <- err
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	// Let the subscribe through, as the writer would.
+	<-q.items
+
+	if _, err := c.Unsubscribe(queryID); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	if err := c.SendBinary([]byte("next")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	select {
+	case err := <-messages:
+		if !IsCode(err, ErrorQueueFull) {
+			t.Fatalf("expected ErrorQueueFull for dropped unsubscribe, got %v", err)
+		}
+	default:
+		t.Fatalf("expected the subscription callback to hear about the dropped unsubscribe")
+	}
+	if _, ok := c.pendingUnsubscribes.Load(queryID); ok {
+		t.Fatalf("expected the dropped unsubscribe to be settled")
+	}
+	if _, ok := c.queryRoutes.Load(queryID); !ok {
+		t.Fatalf("expected the subscription to stay routed")
+	}
+}
@@ -0,0 +1,101 @@
+package connection
+
+import (
+	"context"
+	"time"
+)
+
+// Shutdown closes the connection gracefully. It stops accepting new calls,
+// waits until every pending call callback has run and every unsubscribe has
+// been confirmed, flushes the send queue, performs the close
+// handshake and waits for the read loop to exit.
+//
+// If ctx is done first, the connection is closed as with Disconnect, calls
+// still pending fail with the resulting error, and ctx's error is returned.
+// Shutdown waits for the read loop, so unlike Disconnect it must not be
+// called from a callback.
+func (c *Connection) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.closed.Load() {
+		return nil
+	}
+	c.draining.Store(true)
+	c.state.Transition(StateDraining, nil)
+
+	if err := c.awaitSettled(ctx); err != nil {
+		_ = c.Disconnect()
+		return err
+	}
+	if c.closed.Swap(true) {
+		return nil
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = c.closeDeadline()
+	}
+	c.sendClose(deadline)
+	// The read loop exits once the server answers the close frame. A server
+	// that never answers is cut off at deadline.
+	var err error
+	if c.readLoopDone != nil {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		select {
+		case <-c.readLoopDone:
+		case <-timer.C:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if closeErr := c.transport.Close(); err == nil {
+		err = closeErr
+	}
+	if c.readLoopDone != nil {
+		<-c.readLoopDone
+	}
+	return err
+}
+
+// awaitSettled blocks until no call callback or unsubscribe is pending, or ctx is done.
+func (c *Connection) awaitSettled(ctx context.Context) error {
+	for c.hasPending() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.settled:
+		}
+	}
+	return nil
+}
+
+func (c *Connection) hasPending() bool {
+	pending := false
+	c.callCallbacks.Range(func(_, _ any) bool {
+		pending = true
+		return false
+	})
+	if !pending {
+		c.pendingUnsubscribes.Range(func(_, _ any) bool {
+			pending = true
+			return false
+		})
+	}
+	return pending
+}
+
+func (c *Connection) settleUnsubscribe(queryID uint32) {
+	if _, ok := c.pendingUnsubscribes.LoadAndDelete(queryID); ok {
+		c.signalSettled()
+	}
+}
+
+// signalSettled wakes Shutdown after a pending call or unsubscribe completed.
+func (c *Connection) signalSettled() {
+	select {
+	case c.settled <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,117 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+)
+
+func TestShutdownWaitsForPendingCallsAndUnsubscribes(t *testing.T) {
+	c := newReplyConnection(t, func(message protocol.ClientMessage) []clientapi.ServerMessageVariant {
+		time.Sleep(50 * time.Millisecond)
+		if message.Kind == protocol.ClientMessageUnsubscribe {
+			return []clientapi.ServerMessageVariant{clientapi.ServerMessageUnsubscribeApplied{Value: clientapi.UnsubscribeApplied{
+				RequestId: message.RequestID, QuerySetId: clientapi.QuerySetId{Id: *message.QueryID},
+			}}}
+		}
+		return []clientapi.ServerMessageVariant{reducerResult(message.RequestID, clientapi.ReducerOutcomeOkEmpty{})}
+	})
+
+	results := make(chan error, 1)
+	if _, err := c.CallReducer("slow", nil, func(_ protocol.RoutedMessage, err error) { results <- err }); err != nil {
+		t.Fatalf("call reducer: %v", err)
+	}
+	if _, err := c.Unsubscribe(7); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- c.Shutdown(context.Background()) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for c.State() != StateDraining && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := c.CallReducer("late", nil, nil); !IsCode(err, ErrorConnectionClosed) {
+		t.Fatalf("expected new calls to be rejected while draining, got %v", err)
+	}
+
+	select {
+	case err := <-shutdown:
+		if err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for shutdown")
+	}
+	select {
+	case err := <-results:
+		if err != nil {
+			t.Fatalf("expected the pending call to complete, got %v", err)
+		}
+	default:
+		t.Fatalf("expected the pending call callback to run before Shutdown returned")
+	}
+	if c.hasPending() {
+		t.Fatalf("expected no pending calls or unsubscribes after shutdown")
+	}
+	select {
+	case <-c.readLoopDone:
+	default:
+		t.Fatalf("expected the read loop to have exited")
+	}
+	if c.State() != StateClosed || c.LastError() != nil {
+		t.Fatalf("expected a clean close, got %s / %v", c.State(), c.LastError())
+	}
+}
+
+func TestShutdownContextExpiryFailsPendingCalls(t *testing.T) {
+	c := newReplyConnection(t, func(protocol.ClientMessage) []clientapi.ServerMessageVariant { return nil })
+
+	results := make(chan error, 1)
+	if _, err := c.CallReducer("never", nil, func(_ protocol.RoutedMessage, err error) { results <- err }); err != nil {
+		t.Fatalf("call reducer: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := c.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the shutdown deadline error, got %v", err)
+	}
+	if err := waitCallback(t, results); err == nil {
+		t.Fatalf("expected the pending call to fail after a forced close")
+	}
+	if c.IsActive() {
+		t.Fatalf("expected the connection to be closed")
+	}
+}
+
+func TestShutdownGivesUpOnUnansweredCloseAfterFallbackDeadline(t *testing.T) {
+	// The server end is never read, so the close frame goes unanswered.
+	client, server := NewPipe()
+	defer server.Close()
+	c := &Connection{transport: client, readLoopDone: make(chan struct{}), closeTimeout: 50 * time.Millisecond}
+	go func() {
+		defer close(c.readLoopDone)
+		for {
+			if _, err := client.ReadFrame(); err != nil {
+				return
+			}
+		}
+	}()
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- c.Shutdown(context.Background()) }()
+	select {
+	case err := <-shutdown:
+		if err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected Shutdown to give up on the close handshake")
+	}
+}
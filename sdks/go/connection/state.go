@@ -0,0 +1,106 @@
+package connection
+
+import (
+	"fmt"
+	"sync"
+)
+
+// State is the lifecycle state of a connection.
+type State int32
+
+const (
+	// StateConnecting is the state before the websocket is established.
+	StateConnecting State = iota
+	// StateConnected means the websocket is open but the server has not yet
+	// sent InitialConnection.
+	StateConnected
+	// StateIdentified means InitialConnection was received.
+	StateIdentified
+	// StateReconnecting means the connection was lost and a reconnect is in
+	// progress. Only spacetimedb.DbConnection enters this state.
+	StateReconnecting
+	// StateDraining means Shutdown is waiting for in-flight calls to finish.
+	StateDraining
+	// StateClosed is terminal.
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateIdentified:
+		return "identified"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateDraining:
+		return "draining"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("State(%d)", int32(s))
+	}
+}
+
+// StateChange describes a transition between two states. Err is the error
+// that caused it, if any.
+type StateChange struct {
+	From State
+	To   State
+	Err  error
+}
+
+// StateMachine tracks the state of a connection and reports every
+// transition. It is used by Connection and by wrappers such as
+// spacetimedb.DbConnection. The zero value starts in StateConnecting.
+type StateMachine struct {
+	mu       sync.Mutex
+	state    State
+	lastErr  error
+	onChange func(StateChange)
+}
+
+// NewStateMachine returns a StateMachine in StateConnecting that passes each
+// transition to onChange, which may be nil.
+func NewStateMachine(onChange func(StateChange)) *StateMachine {
+	return &StateMachine{onChange: onChange}
+}
+
+// State returns the current state.
+func (m *StateMachine) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// LastError returns the most recent error that caused a transition.
+func (m *StateMachine) LastError() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastErr
+}
+
+// Transition moves to state to and reports whether the state changed.
+// Nothing leaves StateClosed. The change callback runs on the calling
+// goroutine after the state is updated.
+func (m *StateMachine) Transition(to State, err error) bool {
+	m.mu.Lock()
+	from := m.state
+	if from == to || from == StateClosed {
+		m.mu.Unlock()
+		return false
+	}
+	m.state = to
+	if err != nil {
+		m.lastErr = err
+	}
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	if onChange != nil {
+		onChange(StateChange{From: from, To: to, Err: err})
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestStateMachineTransitions(t *testing.T) {
+	var changes []StateChange
+	m := NewStateMachine(func(change StateChange) { changes = append(changes, change) })
+	if m.State() != StateConnecting {
+		t.Fatalf("expected initial state connecting, got %s", m.State())
+	}
+
+	lost := errors.New("lost")
+	if !m.Transition(StateConnected, nil) {
+		t.Fatalf("expected transition to connected")
+	}
+	if m.Transition(StateConnected, nil) {
+		t.Fatalf("did not expect a transition to the current state")
+	}
+	m.Transition(StateClosed, lost)
+	if m.Transition(StateConnected, nil) {
+		t.Fatalf("did not expect a transition out of closed")
+	}
+
+	want := []StateChange{
+		{From: StateConnecting, To: StateConnected},
+		{From: StateConnected, To: StateClosed, Err: lost},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("unexpected changes: got %+v want %+v", changes, want)
+	}
+	if m.State() != StateClosed || m.LastError() != lost {
+		t.Fatalf("unexpected final state %s / %v", m.State(), m.LastError())
+	}
+}
+
+func TestConnectionStateFollowsLifecycle(t *testing.T) {
+	serverURL, cleanup := startWebsocketServer(t, func(conn *websocket.Conn) {
+		encoded, err := protocol.EncodeServerMessage(clientapi.NewServerMessage(clientapi.ServerMessageInitialConnection{
+			Value: clientapi.InitialConnection{Identity: types.Identity{1}, ConnectionId: types.ConnectionId{2}, Token: "token"},
+		}))
+		if err != nil {
+			t.Errorf("encode initial connection: %v", err)
+			return
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, append([]byte{0}, encoded...)); err != nil {
+			return
+		}
+		// Drop the connection once the client has seen InitialConnection.
+		time.Sleep(50 * time.Millisecond)
+	})
+	defer cleanup()
+
+	var (
+		mu      sync.Mutex
+		changes []StateChange
+	)
+	closed := make(chan struct{})
+	c, err := NewBuilder().
+		WithURI(serverURL).
+		WithDatabaseName("db").
+		OnStateChange(func(change StateChange) {
+			mu.Lock()
+			changes = append(changes, change)
+			mu.Unlock()
+			if change.To == StateClosed {
+				close(closed)
+			}
+		}).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer c.Disconnect()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for the connection to close")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var got []State
+	for _, change := range changes {
+		got = append(got, change.To)
+	}
+	if want := []State{StateConnected, StateIdentified, StateClosed}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected transitions: got %v want %v", got, want)
+	}
+	if c.State() != StateClosed || c.LastError() == nil {
+		t.Fatalf("expected closed state with the read error, got %s / %v", c.State(), c.LastError())
+	}
+}
+
+func TestDisconnectClosesWithoutError(t *testing.T) {
+	c := newSinkConnection(t)
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+	if c.State() != StateClosed || c.LastError() != nil {
+		t.Fatalf("expected closed state without error, got %s / %v", c.State(), c.LastError())
+	}
+}
@@ -0,0 +1,139 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ControlFrame identifies a control frame received from the peer.
+type ControlFrame int
+
+const (
+	ControlPing ControlFrame = iota
+	ControlPong
+)
+
+// Transport carries the frames of one connection to the server. The
+// websocket transport returned by NewWebsocketTransport is the default; see
+// NewPipe for an in-memory transport.
+//
+// A Connection calls ReadFrame from a single goroutine and WriteFrame from a
+// single goroutine. Ping, SendClose and Close may be called concurrently with
+// every other method.
+type Transport interface {
+	// ReadFrame blocks until the next binary message arrives. It returns an
+	// error once the transport is closed or the peer closed it.
+	ReadFrame() ([]byte, error)
+	// WriteFrame sends one binary message.
+	WriteFrame(payload []byte) error
+	// Ping sends a ping carrying payload; the peer echoes it in a pong.
+	Ping(payload []byte, deadline time.Time) error
+	// SendClose starts the close handshake. ReadFrame returns an error once
+	// the peer answers it.
+	SendClose(deadline time.Time) error
+	// Close releases the transport and unblocks ReadFrame.
+	Close() error
+	// OnControl registers fn to observe control frames from the peer. It runs
+	// on the goroutine calling ReadFrame. The transport answers pings itself.
+	OnControl(fn func(frame ControlFrame, payload []byte))
+}
+
+// Dialer opens a transport to endpoint, the subscribe URL of the database.
+// headers carries the Authorization header when a token is sent that way.
+type Dialer func(ctx context.Context, endpoint *url.URL, headers http.Header) (Transport, error)
+
+// DialWebsocket is the default Dialer. It dials with zero DialOptions.
+func DialWebsocket(ctx context.Context, endpoint *url.URL, headers http.Header) (Transport, error) {
+	return NewWebsocketDialer(DialOptions{})(ctx, endpoint, headers)
+}
+
+// NewWebsocketDialer returns a Dialer that dials websockets with opts.
+// Headers in opts.Header are sent unless headers sets the same key.
+func NewWebsocketDialer(opts DialOptions) Dialer {
+	return func(ctx context.Context, endpoint *url.URL, headers http.Header) (Transport, error) {
+		ws, err := dialWebsocket(ctx, opts, endpoint, headers)
+		if err != nil {
+			return nil, err
+		}
+		return NewWebsocketTransport(ws), nil
+	}
+}
+
+type websocketTransport struct {
+	ws *websocket.Conn
+
+	mu        sync.Mutex
+	onControl func(ControlFrame, []byte)
+}
+
+// NewWebsocketTransport wraps an established gorilla websocket connection.
+func NewWebsocketTransport(ws *websocket.Conn) Transport {
+	t := &websocketTransport{ws: ws}
+	ws.SetPongHandler(func(payload string) error {
+		t.control(ControlPong, []byte(payload))
+		return nil
+	})
+	ws.SetPingHandler(func(payload string) error {
+		t.control(ControlPing, []byte(payload))
+		err := ws.WriteControl(websocket.PongMessage, []byte(payload), time.Now().Add(controlWriteTimeout))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil
+		}
+		return err
+	})
+	return t
+}
+
+func (t *websocketTransport) ReadFrame() ([]byte, error) {
+	for {
+		msgType, payload, err := t.ws.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		if msgType == websocket.BinaryMessage {
+			return payload, nil
+		}
+	}
+}
+
+func (t *websocketTransport) WriteFrame(payload []byte) error {
+	return t.ws.WriteMessage(websocket.BinaryMessage, payload)
+}
+
+func (t *websocketTransport) Ping(payload []byte, deadline time.Time) error {
+	return t.ws.WriteControl(websocket.PingMessage, payload, deadline)
+}
+
+func (t *websocketTransport) SendClose(deadline time.Time) error {
+	return t.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+}
+
+func (t *websocketTransport) Close() error {
+	return t.ws.Close()
+}
+
+func (t *websocketTransport) OnControl(fn func(ControlFrame, []byte)) {
+	t.mu.Lock()
+	t.onControl = fn
+	t.mu.Unlock()
+}
+
+func (t *websocketTransport) control(frame ControlFrame, payload []byte) {
+	t.mu.Lock()
+	fn := t.onControl
+	t.mu.Unlock()
+	if fn != nil {
+		fn(frame, payload)
+	}
+}
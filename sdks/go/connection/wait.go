@@ -0,0 +1,50 @@
+package connection
+
+import (
+	"context"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/events"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/await"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+)
+
+// CallReducerWait calls reducer and blocks until its result arrives. A reducer
+// that fails on the server returns its result together with an ErrorCallFailed
+// error. ctx bounds the call as in CallReducerContext.
+func (c *Connection) CallReducerWait(ctx context.Context, reducer string, args []byte) (clientapi.ReducerResult, error) {
+	return await.ReducerResult(await.Call(func(callback events.ResultCallback) (uint32, error) {
+		return c.CallReducerContext(ctx, reducer, args, callback)
+	}))
+}
+
+// CallProcedureWait calls procedure and blocks until its result arrives. A
+// procedure that fails on the server returns its result together with an
+// ErrorCallFailed error.
+func (c *Connection) CallProcedureWait(ctx context.Context, procedure string, args []byte) (clientapi.ProcedureResult, error) {
+	return await.ProcedureResult(await.Call(func(callback events.ResultCallback) (uint32, error) {
+		return c.CallProcedureContext(ctx, procedure, args, callback)
+	}))
+}
+
+// OneOffQueryWait runs query and blocks until its rows arrive. A query
+// rejected by the server returns an ErrorCallFailed error.
+func (c *Connection) OneOffQueryWait(ctx context.Context, query string) (clientapi.QueryRows, error) {
+	return await.OneOffQueryRows(await.Call(func(callback events.ResultCallback) (uint32, error) {
+		return c.OneOffQueryContext(ctx, query, callback)
+	}))
+}
+
+// SubscribeWait subscribes to queryStrings and blocks until the subscription
+// is applied, returning its query id and initial rows. Later messages for the
+// subscription go to callback, which may be nil.
+func (c *Connection) SubscribeWait(ctx context.Context, queryStrings []string, callback SubscriptionCallback) (uint32, clientapi.SubscribeApplied, error) {
+	return await.Applied(ctx,
+		func(route SubscriptionCallback) (uint32, error) {
+			return c.SubscribeContext(ctx, queryStrings, route)
+		},
+		func(queryID uint32) {
+			_, _ = c.Unsubscribe(queryID)
+		},
+		callback,
+	)
+}
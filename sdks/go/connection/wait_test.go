@@ -0,0 +1,165 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/gorilla/websocket"
+)
+
+func TestCallReducerWaitReturnsResult(t *testing.T) {
+	c := newReplyConnection(t, func(message protocol.ClientMessage) []clientapi.ServerMessageVariant {
+		return []clientapi.ServerMessageVariant{reducerResult(message.RequestID, clientapi.ReducerOutcomeOkEmpty{})}
+	})
+
+	result, err := c.CallReducerWait(context.Background(), "add", []byte{1})
+	if err != nil {
+		t.Fatalf("call reducer wait: %v", err)
+	}
+	if _, ok := result.Result.Variant().(clientapi.ReducerOutcomeOkEmpty); !ok {
+		t.Fatalf("unexpected reducer outcome: %v", result.Result)
+	}
+}
+
+func TestCallReducerWaitReportsReducerError(t *testing.T) {
+	payload, err := bsatn.Marshal("name taken")
+	if err != nil {
+		t.Fatalf("marshal error payload: %v", err)
+	}
+	c := newReplyConnection(t, func(message protocol.ClientMessage) []clientapi.ServerMessageVariant {
+		return []clientapi.ServerMessageVariant{reducerResult(message.RequestID, clientapi.ReducerOutcomeErr{Value: payload})}
+	})
+
+	result, err := c.CallReducerWait(context.Background(), "set_name", nil)
+	if !IsCode(err, ErrorCallFailed) || !strings.Contains(err.Error(), "name taken") {
+		t.Fatalf("expected ErrorCallFailed with the reducer message, got %v", err)
+	}
+	if _, ok := result.Result.Variant().(clientapi.ReducerOutcomeErr); !ok {
+		t.Fatalf("expected the failed result alongside the error, got %v", result.Result)
+	}
+}
+
+func TestOneOffQueryWaitReportsQueryError(t *testing.T) {
+	c := newReplyConnection(t, func(message protocol.ClientMessage) []clientapi.ServerMessageVariant {
+		reason := "no such table"
+		return []clientapi.ServerMessageVariant{clientapi.ServerMessageOneOffQueryResult{Value: clientapi.OneOffQueryResult{
+			RequestId: message.RequestID,
+			Result:    clientapi.Result[clientapi.QueryRows, string]{Err: &reason},
+		}}}
+	})
+
+	if _, err := c.OneOffQueryWait(context.Background(), "select * from missing"); !IsCode(err, ErrorCallFailed) {
+		t.Fatalf("expected ErrorCallFailed, got %v", err)
+	}
+}
+
+func TestSubscribeWaitReturnsAppliedAndForwardsLaterMessages(t *testing.T) {
+	c := newReplyConnection(t, func(message protocol.ClientMessage) []clientapi.ServerMessageVariant {
+		querySet := clientapi.QuerySetId{Id: *message.QueryID}
+		if message.Kind == protocol.ClientMessageUnsubscribe {
+			return []clientapi.ServerMessageVariant{clientapi.ServerMessageUnsubscribeApplied{Value: clientapi.UnsubscribeApplied{
+				RequestId: message.RequestID, QuerySetId: querySet,
+			}}}
+		}
+		return []clientapi.ServerMessageVariant{clientapi.ServerMessageSubscribeApplied{Value: clientapi.SubscribeApplied{
+			RequestId: message.RequestID, QuerySetId: querySet,
+		}}}
+	})
+
+	later := make(chan protocol.MessageKind, 1)
+	queryID, applied, err := c.SubscribeWait(context.Background(), []string{"select * from users"}, func(message protocol.RoutedMessage, err error) {
+		later <- message.Kind
+	})
+	if err != nil {
+		t.Fatalf("subscribe wait: %v", err)
+	}
+	if applied.QuerySetId.Id != queryID {
+		t.Fatalf("applied query set %d does not match query id %d", applied.QuerySetId.Id, queryID)
+	}
+	if _, err := c.Unsubscribe(queryID); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	select {
+	case kind := <-later:
+		if kind != protocol.MessageKindUnsubscribeApplied {
+			t.Fatalf("expected unsubscribe_applied after subscribe_applied, got %s", kind)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for forwarded message")
+	}
+}
+
+func TestSubscribeWaitUnsubscribesWhenContextExpires(t *testing.T) {
+	received := make(chan protocol.ClientMessageKind, 2)
+	c := newReplyConnection(t, func(message protocol.ClientMessage) []clientapi.ServerMessageVariant {
+		received <- message.Kind
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := c.SubscribeWait(ctx, []string{"select * from users"}, nil); !IsCode(err, ErrorTimeout) {
+		t.Fatalf("expected ErrorTimeout, got %v", err)
+	}
+	for _, want := range []protocol.ClientMessageKind{protocol.ClientMessageSubscribe, protocol.ClientMessageUnsubscribe} {
+		select {
+		case kind := <-received:
+			if kind != want {
+				t.Fatalf("expected %s, got %s", want, kind)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+}
+
+// newReplyConnection connects to a server that answers each client message
+// with the server messages returned by reply.
+func newReplyConnection(t *testing.T, reply func(protocol.ClientMessage) []clientapi.ServerMessageVariant) *Connection {
+	t.Helper()
+	serverURL, cleanup := startWebsocketServer(t, func(conn *websocket.Conn) {
+		for {
+			_, raw, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			message, err := protocol.BSATNClientMessageDecoder(raw)
+			if err != nil {
+				t.Errorf("decode client message: %v", err)
+				return
+			}
+			for _, variant := range reply(message) {
+				encoded, err := protocol.EncodeServerMessage(clientapi.NewServerMessage(variant))
+				if err != nil {
+					t.Errorf("encode server message: %v", err)
+					return
+				}
+				if err := conn.WriteMessage(websocket.BinaryMessage, append([]byte{0}, encoded...)); err != nil {
+					return
+				}
+			}
+		}
+	})
+	t.Cleanup(cleanup)
+
+	c, err := buildTestConnection(t, serverURL)
+	if err != nil {
+		t.Fatalf("build test connection: %v", err)
+	}
+	c.startReadLoop()
+	t.Cleanup(func() { _ = c.Disconnect() })
+	return c
+}
+
+func reducerResult(requestID uint32, outcome clientapi.ReducerOutcomeVariant) clientapi.ServerMessageReducerResult {
+	return clientapi.ServerMessageReducerResult{Value: clientapi.ReducerResult{
+		RequestId: requestID,
+		Timestamp: time.Unix(1, 0),
+		Result:    clientapi.NewReducerOutcome(outcome),
+	}}
+}
@@ -3,23 +3,143 @@ package connection
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
 	"github.com/gorilla/websocket"
 )
 
+// DialOptions configures the HTTP requests a connection makes: the websocket
+// token exchange and the websocket handshake. The zero value connects
+// directly with the system roots.
+type DialOptions struct {
+	// HTTPClient performs the websocket token exchange. TLSConfig and Proxy
+	// are applied to a copy of its Transport, which must then be nil or an
+	// *http.Transport. When TLSConfig or Proxy is unset and the client's
+	// Transport is an *http.Transport, the websocket handshake uses that
+	// transport's setting as well. A nil Transport connects without a proxy,
+	// as the handshake does.
+	HTTPClient *http.Client
+	// TLSConfig configures wss connections, for example a private CA or a
+	// client certificate.
+	TLSConfig *tls.Config
+	// Proxy returns the proxy for a request, as http.Transport.Proxy does.
+	// Nil means the proxy of HTTPClient's *http.Transport, or no proxy. The
+	// environment's proxy settings apply only through http.ProxyFromEnvironment.
+	Proxy func(*http.Request) (*url.URL, error)
+	// HandshakeTimeout bounds the token exchange and the websocket handshake.
+	// Zero means websocket.DefaultDialer's timeout.
+	HandshakeTimeout time.Duration
+	// Header holds extra headers sent with both requests.
+	Header http.Header
+}
+
+func (o DialOptions) handshakeTimeout() time.Duration {
+	if o.HandshakeTimeout > 0 {
+		return o.HandshakeTimeout
+	}
+	return websocket.DefaultDialer.HandshakeTimeout
+}
+
+// httpTransport returns the *http.Transport of HTTPClient, if it has one.
+func (o DialOptions) httpTransport() *http.Transport {
+	if o.HTTPClient == nil {
+		return nil
+	}
+	transport, _ := o.HTTPClient.Transport.(*http.Transport)
+	return transport
+}
+
+// validate rejects settings that cannot be applied to the token exchange.
+func (o DialOptions) validate() error {
+	if o.HTTPClient == nil || o.HTTPClient.Transport == nil || o.httpTransport() != nil {
+		return nil
+	}
+	if o.TLSConfig != nil || o.Proxy != nil {
+		return errors.New("TLS config and proxy need an HTTP client whose Transport is an *http.Transport")
+	}
+	return nil
+}
+
+// directTransport is http.DefaultTransport without its environment proxy,
+// so the token exchange connects the way the websocket handshake does.
+var directTransport = sync.OnceValue(func() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	return transport
+})
+
+// proxy returns the proxy rule shared by the token exchange and the
+// websocket handshake.
+func (o DialOptions) proxy() func(*http.Request) (*url.URL, error) {
+	if o.Proxy != nil {
+		return o.Proxy
+	}
+	if transport := o.httpTransport(); transport != nil {
+		return transport.Proxy
+	}
+	return nil
+}
+
+func (o DialOptions) httpClient() *http.Client {
+	own := o.httpTransport()
+	if o.HTTPClient != nil && o.HTTPClient.Transport != nil && (own == nil || o.TLSConfig == nil && o.Proxy == nil) {
+		// validate rejects TLSConfig and Proxy for a transport that is not
+		// an *http.Transport.
+		return o.HTTPClient
+	}
+	// Apply TLSConfig and Proxy to a copy so the caller's client is left alone.
+	client := &http.Client{}
+	if o.HTTPClient != nil {
+		*client = *o.HTTPClient
+	}
+	if own == nil && o.TLSConfig == nil && o.Proxy == nil {
+		client.Transport = directTransport()
+		return client
+	}
+	transport := directTransport()
+	if own != nil {
+		transport = own
+	}
+	transport = transport.Clone()
+	if o.TLSConfig != nil {
+		transport.TLSClientConfig = o.TLSConfig
+	}
+	transport.Proxy = o.proxy()
+	client.Transport = transport
+	return client
+}
+
+func (o DialOptions) websocketDialer() *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Subprotocols:     []string{protocol.WSSubprotocolV2},
+		TLSClientConfig:  o.TLSConfig,
+		Proxy:            o.proxy(),
+		HandshakeTimeout: o.handshakeTimeout(),
+	}
+	if transport := o.httpTransport(); transport != nil {
+		if dialer.TLSClientConfig == nil {
+			dialer.TLSClientConfig = transport.TLSClientConfig
+		}
+		dialer.NetDialContext = transport.DialContext
+	}
+	return dialer
+}
+
 type websocketTokenResponse struct {
 	Token string `json:"token"`
 }
 
-func exchangeWebsocketToken(ctx context.Context, host *url.URL, authToken string) (string, error) {
+func exchangeWebsocketToken(ctx context.Context, opts DialOptions, host *url.URL, authToken string) (string, error) {
 	tokenURL := *host
 	switch tokenURL.Scheme {
 	case "wss":
@@ -31,13 +151,18 @@ func exchangeWebsocketToken(ctx context.Context, host *url.URL, authToken string
 	tokenURL.RawQuery = ""
 	tokenURL.Fragment = ""
 
+	ctx, cancel := context.WithTimeout(ctx, opts.handshakeTimeout())
+	defer cancel()
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL.String(), nil)
 	if err != nil {
 		return "", fmt.Errorf("build websocket-token request: %w", err)
 	}
+	for key, values := range opts.Header {
+		req.Header[key] = append([]string(nil), values...)
+	}
 	req.Header.Set("Authorization", "Bearer "+authToken)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := opts.httpClient().Do(req)
 	if err != nil {
 		return "", fmt.Errorf("request websocket-token: %w", err)
 	}
@@ -58,17 +183,18 @@ func exchangeWebsocketToken(ctx context.Context, host *url.URL, authToken string
 	return decoded.Token, nil
 }
 
-func dialWebsocket(ctx context.Context, endpoint *url.URL, headers map[string]string) (*websocket.Conn, error) {
-	dialer := websocket.Dialer{
-		Subprotocols: []string{protocol.WSSubprotocolV2},
+func dialWebsocket(ctx context.Context, opts DialOptions, endpoint *url.URL, headers http.Header) (*websocket.Conn, error) {
+	merged := headers.Clone()
+	if merged == nil {
+		merged = http.Header{}
 	}
-
-	httpHeader := http.Header{}
-	for k, v := range headers {
-		httpHeader.Set(k, v)
+	for key, values := range opts.Header {
+		if _, ok := merged[key]; !ok {
+			merged[key] = values
+		}
 	}
 
-	conn, resp, err := dialer.DialContext(ctx, endpoint.String(), httpHeader)
+	conn, resp, err := opts.websocketDialer().DialContext(ctx, endpoint.String(), merged)
 	if err != nil {
 		if resp != nil && resp.Body != nil {
 			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
@@ -87,7 +213,7 @@ func dialWebsocket(ctx context.Context, endpoint *url.URL, headers map[string]st
 		return nil, fmt.Errorf("unexpected websocket subprotocol: got %q want %q", conn.Subprotocol(), protocol.WSSubprotocolV2)
 	}
 
-	if err := conn.WriteControl(websocket.PingMessage, bytes.Repeat([]byte{0}, 1), time.Now().Add(websocket.DefaultDialer.HandshakeTimeout)); err != nil {
+	if err := conn.WriteControl(websocket.PingMessage, bytes.Repeat([]byte{0}, 1), time.Now().Add(opts.handshakeTimeout())); err != nil {
 		// Ping failure right after connect usually means the socket is already unhealthy.
 		_ = conn.Close()
 		return nil, fmt.Errorf("websocket post-connect ping failed: %w", err)
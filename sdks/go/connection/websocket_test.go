@@ -2,12 +2,16 @@ package connection
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
 	"github.com/gorilla/websocket"
@@ -35,7 +39,7 @@ func TestExchangeWebsocketToken(t *testing.T) {
 			t.Fatalf("parse host: %v", err)
 		}
 
-		token, err := exchangeWebsocketToken(context.Background(), host, "auth-token")
+		token, err := exchangeWebsocketToken(context.Background(), DialOptions{}, host, "auth-token")
 		if err != nil {
 			t.Fatalf("exchange token: %v", err)
 		}
@@ -55,7 +59,7 @@ func TestExchangeWebsocketToken(t *testing.T) {
 			t.Fatalf("parse host: %v", err)
 		}
 
-		_, err = exchangeWebsocketToken(context.Background(), host, "auth-token")
+		_, err = exchangeWebsocketToken(context.Background(), DialOptions{}, host, "auth-token")
 		if err == nil || !strings.Contains(err.Error(), "status=401") {
 			t.Fatalf("expected status error, got: %v", err)
 		}
@@ -72,7 +76,7 @@ func TestExchangeWebsocketToken(t *testing.T) {
 			t.Fatalf("parse host: %v", err)
 		}
 
-		_, err = exchangeWebsocketToken(context.Background(), host, "auth-token")
+		_, err = exchangeWebsocketToken(context.Background(), DialOptions{}, host, "auth-token")
 		if err == nil || !strings.Contains(err.Error(), "missing token") {
 			t.Fatalf("expected missing token error, got: %v", err)
 		}
@@ -101,7 +105,7 @@ func TestDialWebsocket(t *testing.T) {
 		defer server.Close()
 
 		wsURL := toWebsocketURL(t, server.URL)
-		conn, err := dialWebsocket(context.Background(), wsURL, map[string]string{"X-Test": "1"})
+		conn, err := dialWebsocket(context.Background(), DialOptions{}, wsURL, http.Header{"X-Test": []string{"1"}})
 		if err != nil {
 			t.Fatalf("dial websocket: %v", err)
 		}
@@ -126,7 +130,7 @@ func TestDialWebsocket(t *testing.T) {
 		defer server.Close()
 
 		wsURL := toWebsocketURL(t, server.URL)
-		conn, err := dialWebsocket(context.Background(), wsURL, nil)
+		conn, err := dialWebsocket(context.Background(), DialOptions{}, wsURL, nil)
 		if conn != nil {
 			_ = conn.Close()
 		}
@@ -136,6 +140,175 @@ func TestDialWebsocket(t *testing.T) {
 	})
 }
 
+func TestBuildUsesDialOptionsForTokenExchangeAndDial(t *testing.T) {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{protocol.WSSubprotocolV2},
+		CheckOrigin:  func(r *http.Request) bool { return true },
+	}
+	tenants := make(chan string, 2)
+	server := newLocalTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenants <- r.URL.Path + " " + r.Header.Get("X-Tenant")
+		if r.URL.Path == "/v1/identity/websocket-token" {
+			_, _ = w.Write([]byte(`{"token":"ws-token"}`))
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	newBuilder := func() *Builder {
+		return NewBuilder().
+			WithURI(server.URL).
+			WithDatabaseName("db").
+			WithToken("auth-token").
+			WithHeader("X-Tenant", "acme").
+			WithHandshakeTimeout(2 * time.Second)
+	}
+	expectRequests := func() {
+		t.Helper()
+		for _, want := range []string{"/v1/identity/websocket-token acme", "/v1/database/db/subscribe acme"} {
+			if got := <-tenants; got != want {
+				t.Fatalf("unexpected request %q, want %q", got, want)
+			}
+		}
+	}
+
+	t.Run("tls config and proxy", func(t *testing.T) {
+		var proxied atomic.Int32
+		c, err := newBuilder().
+			WithTLSConfig(&tls.Config{RootCAs: roots}).
+			WithProxy(func(*http.Request) (*url.URL, error) {
+				proxied.Add(1)
+				return nil, nil
+			}).
+			Build(context.Background())
+		if err != nil {
+			t.Fatalf("build: %v", err)
+		}
+		defer c.Disconnect()
+		expectRequests()
+		if got := proxied.Load(); got != 2 {
+			t.Fatalf("expected the proxy func to see both requests, got %d", got)
+		}
+	})
+
+	t.Run("http client", func(t *testing.T) {
+		c, err := newBuilder().WithHTTPClient(server.Client()).Build(context.Background())
+		if err != nil {
+			t.Fatalf("build: %v", err)
+		}
+		defer c.Disconnect()
+		expectRequests()
+	})
+
+	t.Run("http client with tls config and proxy", func(t *testing.T) {
+		var proxied atomic.Int32
+		client := &http.Client{Transport: &http.Transport{}}
+		c, err := newBuilder().
+			WithHTTPClient(client).
+			WithTLSConfig(&tls.Config{RootCAs: roots}).
+			WithProxy(func(*http.Request) (*url.URL, error) {
+				proxied.Add(1)
+				return nil, nil
+			}).
+			Build(context.Background())
+		if err != nil {
+			t.Fatalf("build: %v", err)
+		}
+		defer c.Disconnect()
+		expectRequests()
+		if got := proxied.Load(); got != 2 {
+			t.Fatalf("expected the proxy func to see both requests, got %d", got)
+		}
+		transport := client.Transport.(*http.Transport)
+		if transport.Proxy != nil || (transport.TLSClientConfig != nil && transport.TLSClientConfig.RootCAs != nil) {
+			t.Fatalf("expected the caller's transport to be left alone")
+		}
+	})
+
+	t.Run("tls config with a custom round tripper", func(t *testing.T) {
+		client := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+		_, err := newBuilder().WithHTTPClient(client).WithTLSConfig(&tls.Config{RootCAs: roots}).Build(context.Background())
+		if err == nil {
+			t.Fatalf("expected a TLS config that cannot be applied to be rejected")
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		if _, err := newBuilder().WithUseWebsocketToken(false).Build(context.Background()); err == nil {
+			t.Fatalf("expected the system roots to reject the test certificate")
+		}
+	})
+}
+
+func TestDialOptionsUseOneProxyRule(t *testing.T) {
+	proxyTo := func(host string) func(*http.Request) (*url.URL, error) {
+		return func(*http.Request) (*url.URL, error) { return &url.URL{Scheme: "http", Host: host}, nil }
+	}
+	cases := []struct {
+		name string
+		opts DialOptions
+		want string
+	}{
+		{name: "default", want: ""},
+		{name: "client without transport", opts: DialOptions{HTTPClient: &http.Client{}}, want: ""},
+		{name: "proxy", opts: DialOptions{Proxy: proxyTo("option:1")}, want: "option:1"},
+		{
+			name: "client transport proxy",
+			opts: DialOptions{HTTPClient: &http.Client{Transport: &http.Transport{Proxy: proxyTo("client:1")}}},
+			want: "client:1",
+		},
+		{
+			name: "proxy overrides client transport",
+			opts: DialOptions{
+				HTTPClient: &http.Client{Transport: &http.Transport{Proxy: proxyTo("client:1")}},
+				Proxy:      proxyTo("option:1"),
+			},
+			want: "option:1",
+		},
+	}
+	request := httptest.NewRequest(http.MethodGet, "https://example.com/v1/identity/websocket-token", nil)
+	proxyHost := func(proxy func(*http.Request) (*url.URL, error)) string {
+		if proxy == nil {
+			return ""
+		}
+		u, err := proxy(request)
+		if err != nil || u == nil {
+			return ""
+		}
+		return u.Host
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport, ok := tc.opts.httpClient().Transport.(*http.Transport)
+			if !ok {
+				t.Fatalf("expected the token exchange to use an *http.Transport")
+			}
+			if got := proxyHost(transport.Proxy); got != tc.want {
+				t.Fatalf("token exchange proxy = %q, want %q", got, tc.want)
+			}
+			if got := proxyHost(tc.opts.websocketDialer().Proxy); got != tc.want {
+				t.Fatalf("websocket handshake proxy = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
 func toWebsocketURL(t *testing.T, raw string) *url.URL {
 	t.Helper()
 	u, err := url.Parse(raw)
@@ -162,6 +335,20 @@ func toWebsocketURL(t *testing.T, raw string) *url.URL {
 	return u
 }
 
+func newLocalTLSServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("local listen unavailable in this environment: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.StartTLS()
+	return server
+}
+
 func newLocalHTTPServer(t *testing.T, handler http.Handler) *httptest.Server {
 	t.Helper()
 
@@ -2,12 +2,18 @@ package spacetimedb
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"net/http"
+	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/clockworklabs/spacetimedb/sdks/go/cache"
 	"github.com/clockworklabs/spacetimedb/sdks/go/connection"
 	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
 )
 
 type ReducerResultCallback = connection.ReducerResultCallback
@@ -20,39 +26,141 @@ type ConnectErrorCallback func(error)
 type DisconnectCallback func(*DbConnection, error)
 type MessageCallback func([]byte)
 type ConnectInfoCallback func(*DbConnection, ConnectionInfo)
+type StateChangeCallback func(*DbConnection, connection.StateChange)
 
 // ConnectionInfo captures identity/session metadata from initial_connection.
 type ConnectionInfo struct {
-	Identity     string
-	ConnectionID string
+	Identity     types.Identity
+	ConnectionID types.ConnectionId
 	Token        string
 	ReceivedAt   time.Time
 }
 
 // DbConnection is a high-level SDK connection facade over connection.Connection.
+//
+// With a reconnect policy the underlying connection.Connection is replaced
+// after every successful reconnect, so Raw should not be cached.
 type DbConnection struct {
-	conn *connection.Connection
+	connMu sync.RWMutex
+	conn   *connection.Connection
 
 	infoMu         sync.RWMutex
 	connectionInfo *ConnectionInfo
+
+	subscriptions subscriptionTracker
+	db            *cache.Store
+	state         *connection.StateMachine
+
+	reconnects    bool
+	redial        *connection.Builder
+	closing       atomic.Bool
+	reconnectCtx  context.Context
+	stopReconnect context.CancelFunc
 }
 
 func (c *DbConnection) Raw() *connection.Connection {
 	if c == nil {
 		return nil
 	}
-	return c.conn
+	return c.current()
 }
 
 func (c *DbConnection) IsActive() bool {
-	return c != nil && c.conn != nil && c.conn.IsActive()
+	if c == nil {
+		return false
+	}
+	conn := c.current()
+	return conn != nil && conn.IsActive()
+}
+
+// Db returns the client cache, which holds the rows matched by the applied
+// subscriptions and is updated from the server's messages as they arrive.
+// See cache.Store for the row callbacks and lookups, and Store.DefineTable to
+// key a table's rows by primary key before subscribing to it.
+func (c *DbConnection) Db() *cache.Store {
+	if c == nil {
+		return nil
+	}
+	return c.db
+}
+
+// State returns the lifecycle state of the connection. Unlike the state of
+// the underlying connection.Connection it spans reconnects: a lost connection
+// moves to StateReconnecting, and only Disconnect or giving up moves it to
+// StateClosed.
+func (c *DbConnection) State() connection.State {
+	if c == nil || c.state == nil {
+		return connection.StateClosed
+	}
+	return c.state.State()
+}
+
+// LastError returns the error that most recently changed the connection
+// state, such as the error that started a reconnect.
+func (c *DbConnection) LastError() error {
+	if c == nil || c.state == nil {
+		return nil
+	}
+	return c.state.LastError()
 }
 
+// Disconnect closes the connection and stops any reconnect in progress.
 func (c *DbConnection) Disconnect() error {
-	if c == nil || c.conn == nil {
+	if c == nil {
 		return nil
 	}
-	return c.conn.Disconnect()
+	c.closing.Store(true)
+	c.setState(connection.StateClosed, nil)
+	if c.stopReconnect != nil {
+		c.stopReconnect()
+	}
+	conn := c.current()
+	if conn == nil {
+		return nil
+	}
+	return conn.Disconnect()
+}
+
+// Shutdown closes the connection gracefully and stops any reconnect in
+// progress. It stops accepting new calls, waits for pending call callbacks
+// and unsubscribes until ctx is done, then closes the websocket and waits for
+// its read loop to exit. See connection.Connection.Shutdown.
+func (c *DbConnection) Shutdown(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	c.closing.Store(true)
+	if c.stopReconnect != nil {
+		c.stopReconnect()
+	}
+	conn := c.current()
+	if conn == nil {
+		c.setState(connection.StateClosed, nil)
+		return nil
+	}
+	c.setState(connection.StateDraining, nil)
+	err := conn.Shutdown(ctx)
+	c.setState(connection.StateClosed, nil)
+	return err
+}
+
+// RTT returns the round-trip time of the most recent keepalive ping, or zero
+// if none has completed on the current connection.
+func (c *DbConnection) RTT() time.Duration {
+	conn := c.Raw()
+	if conn == nil {
+		return 0
+	}
+	return conn.RTT()
+}
+
+// SendQueueStats reports the outbound queue of the current connection.
+func (c *DbConnection) SendQueueStats() connection.SendQueueStats {
+	conn := c.Raw()
+	if conn == nil {
+		return connection.SendQueueStats{}
+	}
+	return conn.SendQueueStats()
 }
 
 func (c *DbConnection) ConnectionInfo() (ConnectionInfo, bool) {
@@ -67,30 +175,34 @@ func (c *DbConnection) ConnectionInfo() (ConnectionInfo, bool) {
 	return *c.connectionInfo, true
 }
 
-func (c *DbConnection) Identity() (string, bool) {
+func (c *DbConnection) Identity() (types.Identity, bool) {
 	info, ok := c.ConnectionInfo()
 	if !ok {
-		return "", false
+		return types.Identity{}, false
 	}
 	return info.Identity, true
 }
 
-func (c *DbConnection) InitialConnectionID() (string, bool) {
+func (c *DbConnection) InitialConnectionID() (types.ConnectionId, bool) {
 	info, ok := c.ConnectionInfo()
 	if !ok {
-		return "", false
+		return types.ConnectionId{}, false
 	}
 	return info.ConnectionID, true
 }
 
+// CallReducer calls reducer with BSATN-encoded args. If ctx is done before
+// the result arrives, callback receives context.Canceled, or a
+// connection.ErrorTimeout error once its deadline passes.
 func (c *DbConnection) CallReducer(ctx context.Context, reducer string, args []byte, callback ReducerResultCallback) (uint32, error) {
 	if err := validateContext(ctx); err != nil {
 		return 0, err
 	}
-	if c == nil || c.conn == nil {
+	conn := c.Raw()
+	if conn == nil {
 		return 0, notConnectedError("call_reducer")
 	}
-	return c.conn.CallReducer(reducer, args, callback)
+	return conn.CallReducerContext(ctx, reducer, args, c.subscriptions.observeReducerResult(reducer, callback))
 }
 
 func (c *DbConnection) CallProcedure(
@@ -102,40 +214,50 @@ func (c *DbConnection) CallProcedure(
 	if err := validateContext(ctx); err != nil {
 		return 0, err
 	}
-	if c == nil || c.conn == nil {
+	conn := c.Raw()
+	if conn == nil {
 		return 0, notConnectedError("call_procedure")
 	}
-	return c.conn.CallProcedure(procedure, args, callback)
+	return conn.CallProcedureContext(ctx, procedure, args, callback)
 }
 
 func (c *DbConnection) OneOffQuery(ctx context.Context, query string, callback OneOffQueryResultCallback) (uint32, error) {
 	if err := validateContext(ctx); err != nil {
 		return 0, err
 	}
-	if c == nil || c.conn == nil {
+	conn := c.Raw()
+	if conn == nil {
 		return 0, notConnectedError("one_off_query")
 	}
-	return c.conn.OneOffQuery(query, callback)
+	return conn.OneOffQueryContext(ctx, query, callback)
 }
 
+// Subscribe subscribes to queryStrings and returns a handle for Unsubscribe.
+//
+// Once applied, the subscription is re-sent automatically after a reconnect.
+// The callback then receives a transaction_update with the rows that changed
+// while disconnected instead of a second subscribe_applied. Messages passed
+// to the callback carry the handle as their QueryID.
 func (c *DbConnection) Subscribe(ctx context.Context, queryStrings []string, callback SubscriptionCallback) (uint32, error) {
 	if err := validateContext(ctx); err != nil {
 		return 0, err
 	}
-	if c == nil || c.conn == nil {
+	conn := c.Raw()
+	if conn == nil {
 		return 0, notConnectedError("subscribe")
 	}
-	return c.conn.Subscribe(queryStrings, callback)
+	return c.subscriptions.subscribe(ctx, c, conn, queryStrings, callback)
 }
 
 func (c *DbConnection) Unsubscribe(ctx context.Context, queryID uint32) (uint32, error) {
 	if err := validateContext(ctx); err != nil {
 		return 0, err
 	}
-	if c == nil || c.conn == nil {
+	conn := c.Raw()
+	if conn == nil {
 		return 0, notConnectedError("unsubscribe")
 	}
-	return c.conn.Unsubscribe(queryID)
+	return c.subscriptions.unsubscribe(ctx, conn, queryID)
 }
 
 func validateContext(ctx context.Context) error {
@@ -166,9 +288,14 @@ type DbConnectionBuilder struct {
 	onConnectInfo  ConnectInfoCallback
 	onConnectError ConnectErrorCallback
 	onDisconnect   DisconnectCallback
+	onStateChange  StateChangeCallback
 
 	connectRetryMaxAttempts int
 	connectRetryBackoff     time.Duration
+
+	reconnectPolicy *ReconnectPolicy
+	onReconnecting  ReconnectingCallback
+	onReconnected   ReconnectedCallback
 }
 
 func NewDbConnectionBuilder() *DbConnectionBuilder {
@@ -223,6 +350,84 @@ func (b *DbConnectionBuilder) WithLightMode(light bool) *DbConnectionBuilder {
 	return b
 }
 
+// WithPingInterval sets how often the connection pings the server. A value <= 0 disables pings.
+func (b *DbConnectionBuilder) WithPingInterval(interval time.Duration) *DbConnectionBuilder {
+	b.inner.WithPingInterval(interval)
+	return b
+}
+
+// WithIdleTimeout sets how long the connection may go without receiving any
+// frame before it is closed with a connection.ErrorTimeout error. A value <= 0
+// disables the timeout.
+func (b *DbConnectionBuilder) WithIdleTimeout(timeout time.Duration) *DbConnectionBuilder {
+	b.inner.WithIdleTimeout(timeout)
+	return b
+}
+
+// WithCallTimeout sets how long reducer, procedure and one-off query calls
+// wait for their result before failing with a connection.ErrorTimeout error.
+// A value <= 0 disables the timeout.
+func (b *DbConnectionBuilder) WithCallTimeout(timeout time.Duration) *DbConnectionBuilder {
+	b.inner.WithCallTimeout(timeout)
+	return b
+}
+
+// WithSendQueue sets the capacity of the outbound message queue and what
+// happens when a message is sent while it is full.
+func (b *DbConnectionBuilder) WithSendQueue(size int, policy connection.OverflowPolicy) *DbConnectionBuilder {
+	b.inner.WithSendQueue(size, policy)
+	return b
+}
+
+// WithHTTPClient sets the client used for the websocket token exchange. Its
+// TLS config and proxy also apply to the websocket handshake unless set with
+// WithTLSConfig or WithProxy.
+func (b *DbConnectionBuilder) WithHTTPClient(client *http.Client) *DbConnectionBuilder {
+	b.inner.WithHTTPClient(client)
+	return b
+}
+
+// WithTLSConfig sets the TLS configuration for the token exchange and the
+// websocket handshake.
+func (b *DbConnectionBuilder) WithTLSConfig(config *tls.Config) *DbConnectionBuilder {
+	b.inner.WithTLSConfig(config)
+	return b
+}
+
+// WithProxy sets the proxy function for the token exchange and the websocket
+// handshake. By default no proxy is used.
+func (b *DbConnectionBuilder) WithProxy(proxy func(*http.Request) (*url.URL, error)) *DbConnectionBuilder {
+	b.inner.WithProxy(proxy)
+	return b
+}
+
+// WithHandshakeTimeout bounds the token exchange and the websocket handshake.
+func (b *DbConnectionBuilder) WithHandshakeTimeout(timeout time.Duration) *DbConnectionBuilder {
+	b.inner.WithHandshakeTimeout(timeout)
+	return b
+}
+
+// WithHeader adds a header sent with the token exchange and the websocket
+// handshake.
+func (b *DbConnectionBuilder) WithHeader(key, value string) *DbConnectionBuilder {
+	b.inner.WithHeader(key, value)
+	return b
+}
+
+// WithDialer sets how the connection opens its transport, both initially and
+// on reconnect. The default is connection.DialWebsocket.
+func (b *DbConnectionBuilder) WithDialer(dialer connection.Dialer) *DbConnectionBuilder {
+	b.inner.WithDialer(dialer)
+	return b
+}
+
+// WithTransport makes Build use an already open transport, such as one end
+// of connection.NewPipe, instead of dialing. Reconnects use the dialer.
+func (b *DbConnectionBuilder) WithTransport(transport connection.Transport) *DbConnectionBuilder {
+	b.inner.WithTransport(transport)
+	return b
+}
+
 func (b *DbConnectionBuilder) WithMessageDecoder(decoder protocol.MessageDecoder) *DbConnectionBuilder {
 	b.inner.WithMessageDecoder(decoder)
 	return b
@@ -253,6 +458,13 @@ func (b *DbConnectionBuilder) OnDisconnect(cb DisconnectCallback) *DbConnectionB
 	return b
 }
 
+// OnStateChange registers a callback for every state transition of the
+// connection, including those caused by reconnects.
+func (b *DbConnectionBuilder) OnStateChange(cb StateChangeCallback) *DbConnectionBuilder {
+	b.onStateChange = cb
+	return b
+}
+
 func (b *DbConnectionBuilder) OnMessage(cb MessageCallback) *DbConnectionBuilder {
 	b.inner.OnMessage(func(bytes []byte) {
 		if cb != nil {
@@ -276,14 +488,35 @@ func (b *DbConnectionBuilder) WithConnectRetry(maxAttempts int, backoff time.Dur
 	return b
 }
 
+// WithReconnect enables automatic reconnection after an established connection
+// is lost. The SDK redials the same database with exponential backoff and
+// jitter, reusing the token from ConnectionInfo. OnDisconnect fires only once
+// reconnecting gives up or Disconnect is called.
+//
+// WithConnectRetry still governs the initial Build.
+func (b *DbConnectionBuilder) WithReconnect(policy ReconnectPolicy) *DbConnectionBuilder {
+	b.reconnectPolicy = &policy
+	return b
+}
+
+// OnReconnecting registers a callback invoked before each reconnect attempt.
+func (b *DbConnectionBuilder) OnReconnecting(cb ReconnectingCallback) *DbConnectionBuilder {
+	b.onReconnecting = cb
+	return b
+}
+
+// OnReconnected registers a callback invoked after a reconnect attempt succeeds.
+// ConnectionInfo is refreshed when the server's initial connection message arrives.
+func (b *DbConnectionBuilder) OnReconnected(cb ReconnectedCallback) *DbConnectionBuilder {
+	b.onReconnected = cb
+	return b
+}
+
 func (b *DbConnectionBuilder) Build(ctx context.Context) (*DbConnection, error) {
-	var dbConn *DbConnection
+	dbConn := newDbConnection(b.onStateChange)
 	var onConnectInfoOnce sync.Once
 
 	invokeConnectInfo := func(payload protocol.InitialConnectionPayload) {
-		if dbConn == nil {
-			return
-		}
 		info := ConnectionInfo{
 			Identity:     payload.Identity,
 			ConnectionID: payload.ConnectionID,
@@ -293,6 +526,7 @@ func (b *DbConnectionBuilder) Build(ctx context.Context) (*DbConnection, error)
 		dbConn.infoMu.Lock()
 		dbConn.connectionInfo = &info
 		dbConn.infoMu.Unlock()
+		dbConn.setState(connection.StateIdentified, nil)
 
 		if b.onConnectInfo != nil {
 			onConnectInfoOnce.Do(func() {
@@ -301,8 +535,12 @@ func (b *DbConnectionBuilder) Build(ctx context.Context) (*DbConnection, error)
 		}
 	}
 
+	dbConn.reconnects = b.reconnectPolicy != nil
 	b.inner.OnConnect(func(conn *connection.Connection) {
-		dbConn = &DbConnection{conn: conn}
+		first := dbConn.setConn(conn)
+		dbConn.setState(connection.StateConnected, nil)
+		conn.OnKind(protocol.MessageKindTransactionUpdate, dbConn.subscriptions.observeMessage)
+		conn.OnKind(protocol.MessageKindReducerResult, dbConn.subscriptions.observeLateReducerResult)
 		conn.OnKind(protocol.MessageKindInitialConnection, func(message protocol.RoutedMessage) {
 			payload, err := protocol.DecodeInitialConnectionPayload(message.Payload)
 			if err != nil {
@@ -314,7 +552,7 @@ func (b *DbConnectionBuilder) Build(ctx context.Context) (*DbConnection, error)
 			invokeConnectInfo(payload)
 		})
 
-		if b.onConnect != nil {
+		if first && b.onConnect != nil {
 			b.onConnect(dbConn)
 		}
 	})
@@ -324,11 +562,18 @@ func (b *DbConnectionBuilder) Build(ctx context.Context) (*DbConnection, error)
 		}
 	})
 	b.inner.OnDisconnect(func(err error) {
-		if b.onDisconnect != nil {
-			b.onDisconnect(dbConn, err)
+		if dbConn.willReconnect() {
+			dbConn.setState(connection.StateReconnecting, err)
+			go b.reconnect(dbConn, err)
+			return
 		}
+		b.notifyDisconnect(dbConn, err)
 	})
 
+	// Reconnects build from a copy, so the token they add and later changes
+	// to b stay out of each other's way. The transport is single-use.
+	dbConn.redial = b.inner.Clone().WithTransport(nil)
+
 	attempts := b.connectRetryMaxAttempts
 	if attempts <= 0 {
 		attempts = 1
@@ -338,14 +583,12 @@ func (b *DbConnectionBuilder) Build(ctx context.Context) (*DbConnection, error)
 	var lastErr error
 	for attempt := 1; attempt <= attempts; attempt++ {
 		if err := validateContext(ctx); err != nil {
+			dbConn.setState(connection.StateClosed, err)
 			return nil, err
 		}
 
-		conn, err := b.inner.Build(ctx)
+		_, err := b.inner.Build(ctx)
 		if err == nil {
-			if dbConn == nil {
-				dbConn = &DbConnection{conn: conn}
-			}
 			return dbConn, nil
 		}
 		lastErr = err
@@ -360,10 +603,12 @@ func (b *DbConnectionBuilder) Build(ctx context.Context) (*DbConnection, error)
 		select {
 		case <-ctx.Done():
 			timer.Stop()
+			dbConn.setState(connection.StateClosed, ctx.Err())
 			return nil, ctx.Err()
 		case <-timer.C:
 		}
 	}
 
+	dbConn.setState(connection.StateClosed, lastErr)
 	return nil, lastErr
 }
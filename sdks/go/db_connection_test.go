@@ -5,9 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"net/url"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/connection"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
 )
 
 func TestDbConnectionContextCancellation(t *testing.T) {
@@ -86,3 +93,81 @@ func lowestUnusedUnprivilegedPort(t *testing.T) int {
 	t.Fatal("failed to find an unused unprivileged localhost port")
 	return 0
 }
+
+func TestDbConnectionRunsOverPipeDialer(t *testing.T) {
+	var dials atomic.Int32
+	dialer := func(ctx context.Context, _ *url.URL, _ http.Header) (connection.Transport, error) {
+		client, server := connection.NewPipe()
+		n := dials.Add(1)
+		go func() {
+			defer server.Close()
+			writePipeMessage(t, server, clientapi.ServerMessageInitialConnection{
+				Value: clientapi.InitialConnection{Identity: types.Identity{1}, ConnectionId: types.ConnectionId{2}, Token: "minted-token"},
+			})
+			for {
+				frame, err := server.ReadFrame()
+				if err != nil {
+					return
+				}
+				message, err := protocol.BSATNClientMessageDecoder(frame)
+				if err != nil {
+					t.Errorf("decode client message: %v", err)
+					return
+				}
+				writePipeMessage(t, server, clientapi.ServerMessageReducerResult{Value: clientapi.ReducerResult{
+					RequestId: message.RequestID,
+					Timestamp: time.Unix(1, 0),
+					Result:    clientapi.NewReducerOutcome(clientapi.ReducerOutcomeOkEmpty{}),
+				}})
+				if n == 1 {
+					// Drop the first connection after its first call.
+					return
+				}
+			}
+		}()
+		return client, nil
+	}
+
+	reconnected := make(chan struct{}, 1)
+	conn, err := NewDbConnectionBuilder().
+		WithURI("http://in-memory").
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithDialer(dialer).
+		WithReconnect(ReconnectPolicy{InitialBackoff: 5 * time.Millisecond}).
+		OnReconnected(func(*DbConnection) { reconnected <- struct{}{} }).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	if _, err := conn.CallReducerWait(context.Background(), "first", nil); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	select {
+	case <-reconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for reconnect")
+	}
+	if _, err := conn.CallReducerWait(context.Background(), "second", nil); err != nil {
+		t.Fatalf("call after reconnect: %v", err)
+	}
+	if identity, ok := conn.Identity(); !ok || identity != (types.Identity{1}) {
+		t.Fatalf("unexpected identity %v (known=%v)", identity, ok)
+	}
+	if got := dials.Load(); got != 2 {
+		t.Fatalf("expected two dials, got %d", got)
+	}
+}
+
+// writePipeMessage sends an uncompressed BSATN server message over the server
+// end of a pipe. It runs on server goroutines, so failures use t.Errorf.
+func writePipeMessage(t *testing.T, server connection.Transport, variant clientapi.ServerMessageVariant) {
+	encoded, err := protocol.EncodeServerMessage(clientapi.NewServerMessage(variant))
+	if err != nil {
+		t.Errorf("encode server message: %v", err)
+		return
+	}
+	_ = server.WriteFrame(append([]byte{0}, encoded...))
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kind says what kind of message changed the client cache.
+type Kind int
+
+const (
+	// KindSubscribeApplied is the initial rows of a subscription, or the
+	// rows that changed while a subscription was re-sent after a reconnect.
+	KindSubscribeApplied Kind = iota
+	// KindUnsubscribeApplied is the rows of a subscription that ended.
+	KindUnsubscribeApplied
+	// KindSubscriptionError is the rows of a subscription the server dropped.
+	KindSubscriptionError
+	// KindReducer is the transaction of a reducer this client called.
+	KindReducer
+	// KindTransaction is a transaction the server broadcast, such as one
+	// run by another client's reducer.
+	KindTransaction
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindSubscribeApplied:
+		return "subscribe_applied"
+	case KindUnsubscribeApplied:
+		return "unsubscribe_applied"
+	case KindSubscriptionError:
+		return "subscription_error"
+	case KindReducer:
+		return "reducer"
+	case KindTransaction:
+		return "transaction"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Event is passed to row callbacks and describes the message whose atomic
+// apply to the cache produced the row change.
+type Event struct {
+	Kind Kind
+	// QuerySet is the subscription handle for the subscription kinds.
+	QuerySet uint32
+	// Reducer describes the call for KindReducer and is nil otherwise.
+	Reducer *ReducerEvent
+}
+
+// ReducerEvent describes a reducer call made by this client.
+type ReducerEvent struct {
+	Name      string
+	RequestID uint32
+	Timestamp time.Time
+}
@@ -1,5 +1,8 @@
 module github.com/clockworklabs/spacetimedb/sdks/go
 
-go 1.22
+go 1.24
 
-require github.com/gorilla/websocket v1.5.3
+require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/gorilla/websocket v1.5.3
+)
@@ -0,0 +1,173 @@
+// Package await turns the callback-based calls of the SDK into blocking
+// ones. It backs the *Wait methods of connection.Connection and
+// DbConnection.
+package await
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/events"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/sdkerror"
+	sdksubscription "github.com/clockworklabs/spacetimedb/sdks/go/subscription"
+)
+
+// Call issues a callback-based call and blocks until its callback runs.
+// The call is expected to expire through its own context or the call
+// timeout, as the call methods of connection.Connection do.
+func Call(call func(callback events.ResultCallback) (uint32, error)) (protocol.RoutedMessage, error) {
+	type outcome struct {
+		message protocol.RoutedMessage
+		err     error
+	}
+	done := make(chan outcome, 1)
+	if _, err := call(func(message protocol.RoutedMessage, err error) {
+		done <- outcome{message, err}
+	}); err != nil {
+		return protocol.RoutedMessage{}, err
+	}
+	out := <-done
+	return out.message, out.err
+}
+
+// Applied subscribes through subscribe and blocks until the subscription
+// is applied, fails or ctx is done. In the last case the subscription is
+// dropped with unsubscribe. Messages after the first are passed to callback.
+func Applied(
+	ctx context.Context,
+	subscribe func(route sdksubscription.Callback) (uint32, error),
+	unsubscribe func(queryID uint32),
+	callback sdksubscription.Callback,
+) (uint32, clientapi.SubscribeApplied, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	type outcome struct {
+		message protocol.RoutedMessage
+		err     error
+	}
+	var (
+		mu        sync.Mutex
+		waiting   = true
+		abandoned bool
+	)
+	first := make(chan outcome, 1)
+	queryID, err := subscribe(func(message protocol.RoutedMessage, err error) {
+		mu.Lock()
+		if waiting {
+			waiting = false
+			mu.Unlock()
+			first <- outcome{message, err}
+			return
+		}
+		forward := !abandoned && callback != nil
+		mu.Unlock()
+		if forward {
+			callback(message, err)
+		}
+	})
+	if err != nil {
+		return queryID, clientapi.SubscribeApplied{}, err
+	}
+
+	select {
+	case out := <-first:
+		applied, err := SubscribeApplied(out.message, out.err)
+		return queryID, applied, err
+	case <-ctx.Done():
+		mu.Lock()
+		waiting = false
+		abandoned = true
+		mu.Unlock()
+		unsubscribe(queryID)
+		return queryID, clientapi.SubscribeApplied{}, sdkerror.Expired("subscribe", ctx.Err())
+	}
+}
+
+// ReducerResult extracts the result passed to a ReducerResultCallback. A
+// reducer that failed on the server is reported as a CallFailed error
+// alongside the result.
+func ReducerResult(message protocol.RoutedMessage, err error) (clientapi.ReducerResult, error) {
+	if err != nil {
+		return clientapi.ReducerResult{}, err
+	}
+	result, ok := message.AsReducerResult()
+	if !ok {
+		return clientapi.ReducerResult{}, sdkerror.NewUnexpectedKind("reducer_result", string(message.Kind), string(protocol.MessageKindReducerResult))
+	}
+	switch outcome := result.Result.Variant().(type) {
+	case clientapi.ReducerOutcomeErr:
+		return result, sdkerror.Wrap(sdkerror.CallFailed, "call_reducer", errors.New(reducerErrorMessage(outcome.Value)))
+	case clientapi.ReducerOutcomeInternalError:
+		return result, sdkerror.Wrap(sdkerror.CallFailed, "call_reducer", fmt.Errorf("internal error: %s", outcome.Value))
+	}
+	return result, nil
+}
+
+// ProcedureResult extracts the result passed to a ProcedureResultCallback. A
+// procedure that failed on the server is reported as a CallFailed
+// error alongside the result.
+func ProcedureResult(message protocol.RoutedMessage, err error) (clientapi.ProcedureResult, error) {
+	if err != nil {
+		return clientapi.ProcedureResult{}, err
+	}
+	result, ok := message.AsProcedureResult()
+	if !ok {
+		return clientapi.ProcedureResult{}, sdkerror.NewUnexpectedKind("procedure_result", string(message.Kind), string(protocol.MessageKindProcedureResult))
+	}
+	if status, ok := result.Status.Variant().(clientapi.ProcedureStatusInternalError); ok {
+		return result, sdkerror.Wrap(sdkerror.CallFailed, "call_procedure", fmt.Errorf("internal error: %s", status.Value))
+	}
+	return result, nil
+}
+
+// OneOffQueryRows extracts the rows passed to a OneOffQueryResultCallback. A
+// query rejected by the server is reported as a CallFailed error.
+func OneOffQueryRows(message protocol.RoutedMessage, err error) (clientapi.QueryRows, error) {
+	if err != nil {
+		return clientapi.QueryRows{}, err
+	}
+	result, ok := message.AsOneOffQueryResult()
+	if !ok {
+		return clientapi.QueryRows{}, sdkerror.NewUnexpectedKind("one_off_query_result", string(message.Kind), string(protocol.MessageKindOneOffQueryResult))
+	}
+	switch {
+	case result.Result.Err != nil:
+		return clientapi.QueryRows{}, sdkerror.Wrap(sdkerror.CallFailed, "one_off_query", errors.New(*result.Result.Err))
+	case result.Result.Ok == nil:
+		return clientapi.QueryRows{}, nil
+	}
+	return *result.Result.Ok, nil
+}
+
+// SubscribeApplied extracts the applied subscription from the first message
+// passed to a sdksubscription.Callback. A subscription rejected by the server is
+// reported as a CallFailed error.
+func SubscribeApplied(message protocol.RoutedMessage, err error) (clientapi.SubscribeApplied, error) {
+	if err != nil {
+		return clientapi.SubscribeApplied{}, err
+	}
+	if failure, ok := message.AsSubscriptionError(); ok {
+		return clientapi.SubscribeApplied{}, sdkerror.Wrap(sdkerror.CallFailed, "subscribe", errors.New(failure.Error))
+	}
+	applied, ok := message.AsSubscribeApplied()
+	if !ok {
+		return clientapi.SubscribeApplied{}, sdkerror.NewUnexpectedKind("subscribe", string(message.Kind), string(protocol.MessageKindSubscribeApplied))
+	}
+	return applied, nil
+}
+
+// reducerErrorMessage renders the error payload of a failed reducer, which is
+// a BSATN string for reducers that return a string error.
+func reducerErrorMessage(payload []byte) string {
+	r := bsatn.NewReader(payload)
+	if message, err := r.ReadString(); err == nil && r.Done() {
+		return message
+	}
+	return fmt.Sprintf("reducer returned an error (%d bytes)", len(payload))
+}
@@ -1,2 +1,19 @@
-// Package bsatn contains shared helpers for BSATN serialization and decoding.
+// Package bsatn implements the BSATN binary encoding used by SpacetimeDB for
+// rows, reducer arguments and v2 websocket messages.
+//
+// Writer and Reader offer a streaming API over primitive SATS values, while
+// Marshal and Unmarshal map Go values onto SATS types via reflection:
+//
+//   - bool, sized integers and floats map to the matching primitive types
+//   - string maps to String and []byte to Array<U8>
+//   - slices and arrays map to Array
+//   - structs map to products, field by field in declaration order
+//   - pointers map to Option (tag 0 = some, tag 1 = none)
+//   - time.Time and time.Duration map to Timestamp and TimeDuration
+//   - big.Int maps to I128/U128/I256/U256 when the field carries a width tag
+//
+// Struct fields may be tagged with `bsatn:"-"` to skip them or with
+// `bsatn:"u128"`, `bsatn:"i128"`, `bsatn:"u256"` or `bsatn:"i256"` to select
+// the wire width of a big.Int. Types that implement Marshaler or Unmarshaler
+// take over their own encoding, which is how sum types are represented.
 package bsatn
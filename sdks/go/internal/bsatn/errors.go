@@ -0,0 +1,64 @@
+package bsatn
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrUnexpectedEOF is returned when the input ends in the middle of a value.
+	ErrUnexpectedEOF = errors.New("bsatn: unexpected end of input")
+	// ErrInvalidBool is returned when a bool byte is neither 0 nor 1.
+	ErrInvalidBool = errors.New("bsatn: invalid bool")
+	// ErrInvalidTag is returned when a sum tag does not name a known variant.
+	ErrInvalidTag = errors.New("bsatn: invalid sum tag")
+	// ErrInvalidUTF8 is returned when a string payload is not valid UTF-8.
+	ErrInvalidUTF8 = errors.New("bsatn: invalid utf-8 string")
+	// ErrTrailingBytes is returned by Unmarshal when input remains after the value.
+	ErrTrailingBytes = errors.New("bsatn: trailing bytes after value")
+	// ErrLengthOverflow is returned when an array or string is too long to encode.
+	ErrLengthOverflow = errors.New("bsatn: length overflows u32")
+	// ErrArrayTooLong is returned when an array length prefix claims more
+	// elements than the rest of the input can hold.
+	ErrArrayTooLong = errors.New("bsatn: array length exceeds input")
+	// ErrIntegerOverflow is returned when a big.Int does not fit the requested width.
+	ErrIntegerOverflow = errors.New("bsatn: integer overflows target width")
+)
+
+// DecodeError records the input offset at which decoding failed.
+type DecodeError struct {
+	Offset int
+	Op     string
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%v (%s at offset %d)", e.Err, e.Op, e.Offset)
+}
+
+func (e *DecodeError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// UnsupportedTypeError is returned when a Go type has no BSATN mapping.
+type UnsupportedTypeError struct {
+	Type   reflect.Type
+	Reason string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Reason == "" {
+		return fmt.Sprintf("bsatn: unsupported type %s", e.Type)
+	}
+	return fmt.Sprintf("bsatn: unsupported type %s: %s", e.Type, e.Reason)
+}
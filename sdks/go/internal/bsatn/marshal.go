@@ -0,0 +1,237 @@
+package bsatn
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Marshaler is implemented by types that write their own BSATN encoding.
+type Marshaler interface {
+	MarshalBSATN(w *Writer) error
+}
+
+// Unmarshaler is implemented by types that read their own BSATN encoding.
+type Unmarshaler interface {
+	UnmarshalBSATN(r *Reader) error
+}
+
+var (
+	marshalerType   = reflect.TypeFor[Marshaler]()
+	unmarshalerType = reflect.TypeFor[Unmarshaler]()
+	timeType        = reflect.TypeFor[time.Time]()
+	durationType    = reflect.TypeFor[time.Duration]()
+	bigIntType      = reflect.TypeFor[big.Int]()
+	bigIntPtrType   = reflect.TypeFor[*big.Int]()
+)
+
+// fieldOptions carries per-field encoding hints parsed from `bsatn` tags.
+type fieldOptions struct {
+	intBits   int
+	intSigned bool
+}
+
+type structField struct {
+	index int
+	name  string
+	opts  fieldOptions
+}
+
+var structFieldsCache sync.Map // map[reflect.Type][]structField
+
+// Marshal returns the BSATN encoding of v.
+//
+// A top-level pointer is dereferenced rather than encoded as an Option.
+func Marshal(v any) ([]byte, error) {
+	w := &Writer{}
+	if err := Encode(w, v); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// Encode writes the BSATN encoding of v to w.
+//
+// A top-level pointer is dereferenced rather than encoded as an Option.
+func Encode(w *Writer, v any) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return &UnsupportedTypeError{Type: nil, Reason: "cannot encode nil"}
+	}
+	if rv.Kind() == reflect.Pointer && rv.Type() != bigIntPtrType {
+		if rv.IsNil() {
+			return &UnsupportedTypeError{Type: rv.Type(), Reason: "cannot encode nil pointer"}
+		}
+		rv = rv.Elem()
+	}
+	return encodeValue(w, rv, fieldOptions{})
+}
+
+func encodeValue(w *Writer, v reflect.Value, opts fieldOptions) error {
+	t := v.Type()
+
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface {
+		if t.Implements(marshalerType) {
+			return v.Interface().(Marshaler).MarshalBSATN(w)
+		}
+		if reflect.PointerTo(t).Implements(marshalerType) {
+			if v.CanAddr() {
+				return v.Addr().Interface().(Marshaler).MarshalBSATN(w)
+			}
+			p := reflect.New(t)
+			p.Elem().Set(v)
+			return p.Interface().(Marshaler).MarshalBSATN(w)
+		}
+	}
+
+	switch t {
+	case timeType:
+		w.WriteI64(v.Interface().(time.Time).UnixMicro())
+		return nil
+	case durationType:
+		w.WriteI64(v.Interface().(time.Duration).Microseconds())
+		return nil
+	case bigIntType:
+		if opts.intBits == 0 {
+			return &UnsupportedTypeError{Type: t, Reason: "big.Int fields need a width tag such as `bsatn:\"u128\"`"}
+		}
+		bi := v.Interface().(big.Int)
+		return w.WriteBigInt(&bi, opts.intBits, opts.intSigned)
+	case bigIntPtrType:
+		if opts.intBits != 0 {
+			return w.WriteBigInt(v.Interface().(*big.Int), opts.intBits, opts.intSigned)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		w.WriteBool(v.Bool())
+	case reflect.Int8:
+		w.WriteI8(int8(v.Int()))
+	case reflect.Int16:
+		w.WriteI16(int16(v.Int()))
+	case reflect.Int32:
+		w.WriteI32(int32(v.Int()))
+	case reflect.Int64:
+		w.WriteI64(v.Int())
+	case reflect.Uint8:
+		w.WriteU8(uint8(v.Uint()))
+	case reflect.Uint16:
+		w.WriteU16(uint16(v.Uint()))
+	case reflect.Uint32:
+		w.WriteU32(uint32(v.Uint()))
+	case reflect.Uint64:
+		w.WriteU64(v.Uint())
+	case reflect.Float32:
+		w.WriteF32(float32(v.Float()))
+	case reflect.Float64:
+		w.WriteF64(v.Float())
+	case reflect.String:
+		return w.WriteString(v.String())
+	case reflect.Slice:
+		if isPlainByteType(t.Elem()) {
+			return w.WriteBytes(v.Bytes())
+		}
+		return encodeSequence(w, v)
+	case reflect.Array:
+		return encodeSequence(w, v)
+	case reflect.Struct:
+		fields, err := structFields(t)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			if err := encodeValue(w, v.Field(field.index), field.opts); err != nil {
+				return fmt.Errorf("%s.%s: %w", t.Name(), field.name, err)
+			}
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			w.WriteSumTag(OptionNoneTag)
+			return nil
+		}
+		w.WriteSumTag(OptionSomeTag)
+		return encodeValue(w, v.Elem(), opts)
+	case reflect.Interface:
+		if v.IsNil() {
+			return &UnsupportedTypeError{Type: t, Reason: "cannot encode nil interface"}
+		}
+		if m, ok := v.Interface().(Marshaler); ok {
+			return m.MarshalBSATN(w)
+		}
+		return &UnsupportedTypeError{Type: t, Reason: "interface values must implement Marshaler"}
+	default:
+		return &UnsupportedTypeError{Type: t}
+	}
+	return nil
+}
+
+func encodeSequence(w *Writer, v reflect.Value) error {
+	n := v.Len()
+	if err := w.WriteArrayLen(n); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := encodeValue(w, v.Index(i), fieldOptions{}); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// isPlainByteType reports whether t is a byte type without custom encoding,
+// so that []t can be written and read as a single block.
+func isPlainByteType(t reflect.Type) bool {
+	return t.Kind() == reflect.Uint8 &&
+		!t.Implements(marshalerType) &&
+		!reflect.PointerTo(t).Implements(unmarshalerType)
+}
+
+func structFields(t reflect.Type) ([]structField, error) {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.([]structField), nil
+	}
+
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get("bsatn")
+		if tag == "-" {
+			continue
+		}
+		opts, err := parseFieldOptions(tag)
+		if err != nil {
+			return nil, &UnsupportedTypeError{Type: t, Reason: fmt.Sprintf("field %s: %v", sf.Name, err)}
+		}
+		fields = append(fields, structField{index: i, name: sf.Name, opts: opts})
+	}
+
+	actual, _ := structFieldsCache.LoadOrStore(t, fields)
+	return actual.([]structField), nil
+}
+
+func parseFieldOptions(tag string) (fieldOptions, error) {
+	var opts fieldOptions
+	for _, part := range strings.Split(tag, ",") {
+		switch strings.TrimSpace(part) {
+		case "":
+		case "u128":
+			opts = fieldOptions{intBits: 128}
+		case "i128":
+			opts = fieldOptions{intBits: 128, intSigned: true}
+		case "u256":
+			opts = fieldOptions{intBits: 256}
+		case "i256":
+			opts = fieldOptions{intBits: 256, intSigned: true}
+		default:
+			return fieldOptions{}, fmt.Errorf("unknown bsatn tag option %q", part)
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,236 @@
+package bsatn
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testShape struct {
+	Tag    uint8
+	Radius float32
+	Width  uint32
+}
+
+// MarshalBSATN encodes testShape as a two-variant sum: Circle(f32) | Rect(u32).
+func (s testShape) MarshalBSATN(w *Writer) error {
+	w.WriteSumTag(s.Tag)
+	switch s.Tag {
+	case 0:
+		w.WriteF32(s.Radius)
+	case 1:
+		w.WriteU32(s.Width)
+	}
+	return nil
+}
+
+func (s *testShape) UnmarshalBSATN(r *Reader) error {
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return err
+	}
+	*s = testShape{Tag: tag}
+	switch tag {
+	case 0:
+		s.Radius, err = r.ReadF32()
+	case 1:
+		s.Width, err = r.ReadU32()
+	default:
+		return r.InvalidTag("testShape", tag)
+	}
+	return err
+}
+
+type testInner struct {
+	Name  string
+	Flags []bool
+}
+
+type testRow struct {
+	ID       uint64
+	Score    int32
+	Ratio    float64
+	Alive    bool
+	Name     string
+	Payload  []byte
+	Tags     []string
+	Pair     [2]int16
+	Inner    testInner
+	Nick     *string
+	Missing  *testInner
+	Shape    testShape
+	Shapes   []testShape
+	Balance  *big.Int `bsatn:"u128"`
+	Delta    big.Int  `bsatn:"i256"`
+	Seen     time.Time
+	Elapsed  time.Duration
+	Unit     struct{}
+	Ignored  string `bsatn:"-"`
+	internal int
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	nick := "ally"
+	balance, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+	var delta big.Int
+	delta.SetInt64(-42)
+
+	in := testRow{
+		ID:      1 << 50,
+		Score:   -7,
+		Ratio:   0.125,
+		Alive:   true,
+		Name:    "alice",
+		Payload: []byte{1, 2, 3},
+		Tags:    []string{"a", "bc"},
+		Pair:    [2]int16{-1, 1},
+		Inner:   testInner{Name: "inner", Flags: []bool{true, false}},
+		Nick:    &nick,
+		Shape:   testShape{Tag: 1, Width: 9},
+		Shapes:  []testShape{{Tag: 0, Radius: 2.5}},
+		Balance: balance,
+		Delta:   delta,
+		Seen:    time.UnixMicro(1_700_000_000_123_456).UTC(),
+		Elapsed: 1500 * time.Millisecond,
+		Ignored: "not encoded",
+	}
+
+	encoded, err := Marshal(&in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var out testRow
+	if err := Unmarshal(encoded, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if out.Ignored != "" {
+		t.Fatalf("ignored field should not round-trip, got %q", out.Ignored)
+	}
+	if out.Balance.Cmp(in.Balance) != 0 || out.Delta.Cmp(&in.Delta) != 0 {
+		t.Fatalf("big ints mismatch: balance=%s delta=%s", out.Balance, &out.Delta)
+	}
+	if !out.Seen.Equal(in.Seen) {
+		t.Fatalf("timestamp mismatch: got %v want %v", out.Seen, in.Seen)
+	}
+
+	in.Ignored = ""
+	out.Balance, in.Balance = nil, nil
+	out.Delta, in.Delta = big.Int{}, big.Int{}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", out, in)
+	}
+
+	reencoded, err := Marshal(out)
+	if err != nil {
+		t.Fatalf("re-marshal: %v", err)
+	}
+	if bytes.Equal(reencoded, encoded) {
+		t.Fatalf("expected cleared big ints to change the encoding")
+	}
+}
+
+func TestMarshalProductLayout(t *testing.T) {
+	type point struct {
+		X int32
+		Y *uint8
+	}
+	y := uint8(5)
+
+	encoded, err := Marshal(point{X: 1, Y: &y})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := []byte{1, 0, 0, 0, OptionSomeTag, 5}
+	if !bytes.Equal(encoded, want) {
+		t.Fatalf("unexpected encoding: %v", encoded)
+	}
+
+	encoded, err = Marshal(point{X: 2})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want = []byte{2, 0, 0, 0, OptionNoneTag}
+	if !bytes.Equal(encoded, want) {
+		t.Fatalf("unexpected encoding: %v", encoded)
+	}
+}
+
+func TestMarshalRejectsUnsupportedTypes(t *testing.T) {
+	cases := []struct {
+		name  string
+		value any
+	}{
+		{name: "nil", value: nil},
+		{name: "platform int", value: 5},
+		{name: "map", value: map[string]int32{}},
+		{name: "untagged big int", value: struct{ V *big.Int }{V: big.NewInt(1)}},
+		{name: "plain interface", value: struct{ V any }{V: int32(1)}},
+		{name: "unknown tag option", value: struct {
+			V uint8 `bsatn:"u512"`
+		}{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Marshal(tc.value)
+			var unsupported *UnsupportedTypeError
+			if !errors.As(err, &unsupported) {
+				t.Fatalf("expected UnsupportedTypeError, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var n uint16
+	if err := Unmarshal([]byte{1, 0, 0}, &n); !errors.Is(err, ErrTrailingBytes) {
+		t.Fatalf("expected ErrTrailingBytes, got: %v", err)
+	}
+	if err := Unmarshal([]byte{1, 0}, n); err == nil {
+		t.Fatalf("expected non-pointer target to fail")
+	}
+
+	var opt *uint8
+	if err := Unmarshal([]byte{7}, &opt); !errors.Is(err, ErrInvalidTag) {
+		t.Fatalf("expected ErrInvalidTag for option, got: %v", err)
+	}
+
+	var shape testShape
+	if err := Unmarshal([]byte{3}, &shape); !errors.Is(err, ErrInvalidTag) {
+		t.Fatalf("expected ErrInvalidTag for custom sum, got: %v", err)
+	}
+
+	var pair [2]uint8
+	if err := Unmarshal([]byte{3, 0, 0, 0, 1, 2, 3}, &pair); err == nil {
+		t.Fatalf("expected array length mismatch to fail")
+	}
+
+	var row testInner
+	if err := Unmarshal([]byte{1, 0, 0, 0, 'x', 9, 0, 0, 0}, &row); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Fatalf("expected ErrUnexpectedEOF for truncated slice, got: %v", err)
+	}
+
+	var empties []struct{}
+	if err := Unmarshal([]byte{0xff, 0xff, 0xff, 0xff}, &empties); !errors.Is(err, ErrArrayTooLong) {
+		t.Fatalf("expected ErrArrayTooLong for hostile zero-sized slice, got: %v", err)
+	}
+	if err := Unmarshal([]byte{3, 0, 0, 0}, &empties); err != nil || len(empties) != 3 {
+		t.Fatalf("expected three empty structs, got %d: %v", len(empties), err)
+	}
+}
+
+func TestUnmarshalCopiesBytes(t *testing.T) {
+	data := []byte{2, 0, 0, 0, 'a', 'b'}
+	var out []byte
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	data[4] = 'z'
+	if out[0] != 'a' {
+		t.Fatalf("Unmarshal should copy byte slices out of its input")
+	}
+}
@@ -0,0 +1,255 @@
+package bsatn
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"unicode/utf8"
+)
+
+// Reader decodes BSATN values from a byte slice.
+//
+// Byte and raw reads return sub-slices of the input without copying; callers
+// that retain them beyond the lifetime of the input must copy them.
+type Reader struct {
+	data []byte
+	off  int
+}
+
+// NewReader returns a Reader positioned at the start of data.
+func NewReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+// Offset returns the number of bytes consumed so far.
+func (r *Reader) Offset() int {
+	return r.off
+}
+
+// Remaining returns the number of unread bytes.
+func (r *Reader) Remaining() int {
+	return len(r.data) - r.off
+}
+
+// Done reports whether all input has been consumed.
+func (r *Reader) Done() bool {
+	return r.off >= len(r.data)
+}
+
+func (r *Reader) fail(op string, err error) error {
+	return &DecodeError{Offset: r.off, Op: op, Err: err}
+}
+
+func (r *Reader) take(op string, n int) ([]byte, error) {
+	if n < 0 || r.Remaining() < n {
+		return nil, r.fail(op, ErrUnexpectedEOF)
+	}
+	out := r.data[r.off : r.off+n : r.off+n]
+	r.off += n
+	return out, nil
+}
+
+func (r *Reader) ReadBool() (bool, error) {
+	b, err := r.take("bool", 1)
+	if err != nil {
+		return false, err
+	}
+	switch b[0] {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		r.off--
+		return false, r.fail("bool", fmt.Errorf("%w: %d", ErrInvalidBool, b[0]))
+	}
+}
+
+func (r *Reader) ReadU8() (uint8, error) {
+	b, err := r.take("u8", 1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *Reader) ReadI8() (int8, error) {
+	v, err := r.ReadU8()
+	return int8(v), err
+}
+
+func (r *Reader) ReadU16() (uint16, error) {
+	b, err := r.take("u16", 2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *Reader) ReadI16() (int16, error) {
+	v, err := r.ReadU16()
+	return int16(v), err
+}
+
+func (r *Reader) ReadU32() (uint32, error) {
+	b, err := r.take("u32", 4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (r *Reader) ReadI32() (int32, error) {
+	v, err := r.ReadU32()
+	return int32(v), err
+}
+
+func (r *Reader) ReadU64() (uint64, error) {
+	b, err := r.take("u64", 8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (r *Reader) ReadI64() (int64, error) {
+	v, err := r.ReadU64()
+	return int64(v), err
+}
+
+// ReadU128 reads a 128-bit integer as its low and high 64-bit words.
+func (r *Reader) ReadU128() (lo, hi uint64, err error) {
+	b, err := r.take("u128", 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:8]), binary.LittleEndian.Uint64(b[8:]), nil
+}
+
+// ReadI128 reads a two's-complement 128-bit integer as its low and high words.
+func (r *Reader) ReadI128() (lo uint64, hi int64, err error) {
+	lo, uhi, err := r.ReadU128()
+	return lo, int64(uhi), err
+}
+
+// ReadU256 reads a 256-bit integer as four 64-bit words, least significant first.
+func (r *Reader) ReadU256() ([4]uint64, error) {
+	var words [4]uint64
+	b, err := r.take("u256", 32)
+	if err != nil {
+		return words, err
+	}
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(b[i*8:])
+	}
+	return words, nil
+}
+
+// ReadI256 reads a two's-complement 256-bit integer as four 64-bit words,
+// least significant first.
+func (r *Reader) ReadI256() ([4]uint64, error) {
+	return r.ReadU256()
+}
+
+// ReadBigInt reads a bits-wide integer (128 or 256), signed or not.
+func (r *Reader) ReadBigInt(bits int, signed bool) (*big.Int, error) {
+	if bits != 128 && bits != 256 {
+		return nil, &UnsupportedTypeError{Type: bigIntType, Reason: "width must be 128 or 256 bits"}
+	}
+	b, err := r.take(fmt.Sprintf("int%d", bits), bits/8)
+	if err != nil {
+		return nil, err
+	}
+	return bigIntFromLittleEndian(b, signed), nil
+}
+
+func (r *Reader) ReadF32() (float32, error) {
+	v, err := r.ReadU32()
+	return math.Float32frombits(v), err
+}
+
+func (r *Reader) ReadF64() (float64, error) {
+	v, err := r.ReadU64()
+	return math.Float64frombits(v), err
+}
+
+// ReadString reads a u32 length prefix followed by that many UTF-8 bytes.
+func (r *Reader) ReadString() (string, error) {
+	start := r.off
+	n, err := r.ReadArrayLen()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.take("string", n)
+	if err != nil {
+		r.off = start
+		return "", err
+	}
+	if !utf8.Valid(b) {
+		r.off = start
+		return "", r.fail("string", ErrInvalidUTF8)
+	}
+	return string(b), nil
+}
+
+// ReadBytes reads an Array<U8>. The returned slice aliases the input.
+func (r *Reader) ReadBytes() ([]byte, error) {
+	start := r.off
+	n, err := r.ReadArrayLen()
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.take("bytes", n)
+	if err != nil {
+		r.off = start
+		return nil, err
+	}
+	return b, nil
+}
+
+// ReadRaw reads exactly n bytes without a length prefix. The returned slice
+// aliases the input.
+func (r *Reader) ReadRaw(n int) ([]byte, error) {
+	return r.take("raw", n)
+}
+
+// ReadArrayLen reads the u32 element count that prefixes arrays and strings.
+func (r *Reader) ReadArrayLen() (int, error) {
+	n, err := r.ReadU32()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// MaxZeroSizedElements bounds arrays whose elements occupy no bytes, such as
+// arrays of empty products, whose length the input size cannot bound.
+const MaxZeroSizedElements = 1 << 16
+
+// ReadElementCount reads the length prefix of an array of values. Every
+// element but a zero-sized one takes at least one byte, so a count beyond
+// both the remaining input and MaxZeroSizedElements cannot be valid.
+func (r *Reader) ReadElementCount() (int, error) {
+	start := r.off
+	n, err := r.ReadArrayLen()
+	if err != nil {
+		return 0, err
+	}
+	if n > max(r.Remaining(), MaxZeroSizedElements) {
+		r.off = start
+		return 0, r.fail("array", fmt.Errorf("%w: %d elements", ErrArrayTooLong, n))
+	}
+	return n, nil
+}
+
+// ReadSumTag reads the u8 variant tag that prefixes every sum value.
+func (r *Reader) ReadSumTag() (uint8, error) {
+	return r.ReadU8()
+}
+
+// InvalidTag returns a DecodeError for a sum tag that the caller does not
+// recognize. typeName names the sum type being decoded.
+func (r *Reader) InvalidTag(typeName string, tag uint8) error {
+	return &DecodeError{Offset: r.off - 1, Op: typeName, Err: fmt.Errorf("%w: %d", ErrInvalidTag, tag)}
+}
@@ -0,0 +1,142 @@
+package bsatn
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestReaderRoundTripsWriterOutput(t *testing.T) {
+	w := &Writer{}
+	w.WriteBool(false)
+	w.WriteI16(-300)
+	w.WriteU64(1 << 40)
+	w.WriteF64(3.5)
+	w.WriteU128(7, 9)
+	_ = w.WriteString("héllo")
+	_ = w.WriteBytes([]byte{1, 2, 3})
+	w.WriteSumTag(OptionNoneTag)
+
+	r := NewReader(w.Bytes())
+	if v, err := r.ReadBool(); err != nil || v {
+		t.Fatalf("read bool: %v %v", v, err)
+	}
+	if v, err := r.ReadI16(); err != nil || v != -300 {
+		t.Fatalf("read i16: %v %v", v, err)
+	}
+	if v, err := r.ReadU64(); err != nil || v != 1<<40 {
+		t.Fatalf("read u64: %v %v", v, err)
+	}
+	if v, err := r.ReadF64(); err != nil || v != 3.5 {
+		t.Fatalf("read f64: %v %v", v, err)
+	}
+	if lo, hi, err := r.ReadU128(); err != nil || lo != 7 || hi != 9 {
+		t.Fatalf("read u128: %v %v %v", lo, hi, err)
+	}
+	if v, err := r.ReadString(); err != nil || v != "héllo" {
+		t.Fatalf("read string: %q %v", v, err)
+	}
+	if v, err := r.ReadBytes(); err != nil || len(v) != 3 || v[2] != 3 {
+		t.Fatalf("read bytes: %v %v", v, err)
+	}
+	if v, err := r.ReadSumTag(); err != nil || v != OptionNoneTag {
+		t.Fatalf("read sum tag: %v %v", v, err)
+	}
+	if !r.Done() || r.Remaining() != 0 || r.Offset() != len(w.Bytes()) {
+		t.Fatalf("expected reader to be exhausted, remaining=%d", r.Remaining())
+	}
+}
+
+func TestReaderBytesAreZeroCopy(t *testing.T) {
+	data := []byte{2, 0, 0, 0, 'a', 'b'}
+	b, err := NewReader(data).ReadBytes()
+	if err != nil {
+		t.Fatalf("read bytes: %v", err)
+	}
+	data[4] = 'z'
+	if b[0] != 'z' {
+		t.Fatalf("expected ReadBytes to alias its input")
+	}
+	if cap(b) != len(b) {
+		t.Fatalf("expected returned slice capacity to be clipped, got cap=%d len=%d", cap(b), len(b))
+	}
+}
+
+func TestReaderBigInt(t *testing.T) {
+	w := &Writer{}
+	want := new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 100))
+	if err := w.WriteBigInt(want, 256, true); err != nil {
+		t.Fatalf("write big int: %v", err)
+	}
+	got, err := NewReader(w.Bytes()).ReadBigInt(256, true)
+	if err != nil {
+		t.Fatalf("read big int: %v", err)
+	}
+	if got.Cmp(want) != 0 {
+		t.Fatalf("unexpected big int: got %s want %s", got, want)
+	}
+
+	unsigned, err := NewReader(w.Bytes()).ReadBigInt(256, false)
+	if err != nil {
+		t.Fatalf("read unsigned big int: %v", err)
+	}
+	if unsigned.Sign() <= 0 || unsigned.BitLen() != 256 {
+		t.Fatalf("expected unsigned reinterpretation to be a large positive value, got %s", unsigned)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		read func(*Reader) error
+		want error
+	}{
+		{
+			name: "short u32",
+			data: []byte{1, 2},
+			read: func(r *Reader) error { _, err := r.ReadU32(); return err },
+			want: ErrUnexpectedEOF,
+		},
+		{
+			name: "invalid bool",
+			data: []byte{2},
+			read: func(r *Reader) error { _, err := r.ReadBool(); return err },
+			want: ErrInvalidBool,
+		},
+		{
+			name: "string longer than input",
+			data: []byte{5, 0, 0, 0, 'a'},
+			read: func(r *Reader) error { _, err := r.ReadString(); return err },
+			want: ErrUnexpectedEOF,
+		},
+		{
+			name: "element count beyond input",
+			data: []byte{0xff, 0xff, 0xff, 0xff, 1},
+			read: func(r *Reader) error { _, err := r.ReadElementCount(); return err },
+			want: ErrArrayTooLong,
+		},
+		{
+			name: "invalid utf8",
+			data: []byte{1, 0, 0, 0, 0xff},
+			read: func(r *Reader) error { _, err := r.ReadString(); return err },
+			want: ErrInvalidUTF8,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewReader(tc.data)
+			err := tc.read(r)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got: %v", tc.want, err)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected DecodeError, got %T", err)
+			}
+			if r.Offset() != 0 {
+				t.Fatalf("failed read should not consume input, offset=%d", r.Offset())
+			}
+		})
+	}
+}
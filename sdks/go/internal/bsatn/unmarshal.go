@@ -0,0 +1,228 @@
+package bsatn
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// Unmarshal decodes the BSATN value in data into the value pointed to by v.
+//
+// All of data must be consumed; leftover bytes are reported as ErrTrailingBytes.
+// Decoded byte slices are copied, so data may be reused after Unmarshal returns.
+func Unmarshal(data []byte, v any) error {
+	r := NewReader(data)
+	if err := Decode(r, v); err != nil {
+		return err
+	}
+	if !r.Done() {
+		return r.fail("unmarshal", ErrTrailingBytes)
+	}
+	return nil
+}
+
+// Decode reads one BSATN value from r into the value pointed to by v.
+func Decode(r *Reader, v any) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &UnsupportedTypeError{Type: reflect.TypeOf(v), Reason: "decode target must be a non-nil pointer"}
+	}
+	if rv.Type() == bigIntPtrType {
+		return &UnsupportedTypeError{Type: rv.Type(), Reason: "use Reader.ReadBigInt to decode a bare big.Int"}
+	}
+	return decodeValue(r, rv.Elem(), fieldOptions{})
+}
+
+func decodeValue(r *Reader, v reflect.Value, opts fieldOptions) error {
+	t := v.Type()
+
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(unmarshalerType) {
+		return v.Addr().Interface().(Unmarshaler).UnmarshalBSATN(r)
+	}
+
+	switch t {
+	case timeType:
+		micros, err := r.ReadI64()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(time.UnixMicro(micros).UTC()))
+		return nil
+	case durationType:
+		micros, err := r.ReadI64()
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(time.Duration(micros) * time.Microsecond))
+		return nil
+	case bigIntType:
+		if opts.intBits == 0 {
+			return &UnsupportedTypeError{Type: t, Reason: "big.Int fields need a width tag such as `bsatn:\"u128\"`"}
+		}
+		bi, err := r.ReadBigInt(opts.intBits, opts.intSigned)
+		if err != nil {
+			return err
+		}
+		v.Addr().Interface().(*big.Int).Set(bi)
+		return nil
+	case bigIntPtrType:
+		if opts.intBits != 0 {
+			bi, err := r.ReadBigInt(opts.intBits, opts.intSigned)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(bi))
+			return nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := r.ReadBool()
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int8:
+		n, err := r.ReadI8()
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Int16:
+		n, err := r.ReadI16()
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Int32:
+		n, err := r.ReadI32()
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Int64:
+		n, err := r.ReadI64()
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint8:
+		n, err := r.ReadU8()
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(n))
+	case reflect.Uint16:
+		n, err := r.ReadU16()
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(n))
+	case reflect.Uint32:
+		n, err := r.ReadU32()
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(n))
+	case reflect.Uint64:
+		n, err := r.ReadU64()
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32:
+		f, err := r.ReadF32()
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(f))
+	case reflect.Float64:
+		f, err := r.ReadF64()
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		s, err := r.ReadString()
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Slice:
+		if isPlainByteType(t.Elem()) {
+			b, err := r.ReadBytes()
+			if err != nil {
+				return err
+			}
+			out := reflect.MakeSlice(t, len(b), len(b))
+			reflect.Copy(out, reflect.ValueOf(b))
+			v.Set(out)
+			return nil
+		}
+		return decodeSlice(r, v)
+	case reflect.Array:
+		n, err := r.ReadArrayLen()
+		if err != nil {
+			return err
+		}
+		if n != t.Len() {
+			return r.fail(t.String(), fmt.Errorf("bsatn: array length %d does not match %d", n, t.Len()))
+		}
+		for i := 0; i < n; i++ {
+			if err := decodeValue(r, v.Index(i), fieldOptions{}); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+	case reflect.Struct:
+		fields, err := structFields(t)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			if err := decodeValue(r, v.Field(field.index), field.opts); err != nil {
+				return fmt.Errorf("%s.%s: %w", t.Name(), field.name, err)
+			}
+		}
+	case reflect.Pointer:
+		tag, err := r.ReadSumTag()
+		if err != nil {
+			return err
+		}
+		switch tag {
+		case OptionSomeTag:
+			p := reflect.New(t.Elem())
+			if err := decodeValue(r, p.Elem(), opts); err != nil {
+				return err
+			}
+			v.Set(p)
+		case OptionNoneTag:
+			v.SetZero()
+		default:
+			return r.InvalidTag("option", tag)
+		}
+	default:
+		return &UnsupportedTypeError{Type: t}
+	}
+	return nil
+}
+
+func decodeSlice(r *Reader, v reflect.Value) error {
+	n, err := r.ReadElementCount()
+	if err != nil {
+		return err
+	}
+	// Elements may be zero-sized, so cap the initial capacity by the input size.
+	capacity := min(n, r.Remaining())
+	out := reflect.MakeSlice(v.Type(), 0, capacity)
+	zero := reflect.Zero(v.Type().Elem())
+	for i := 0; i < n; i++ {
+		out = reflect.Append(out, zero)
+		if err := decodeValue(r, out.Index(i), fieldOptions{}); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	v.Set(out)
+	return nil
+}
@@ -0,0 +1,212 @@
+package bsatn
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+)
+
+const (
+	// OptionSomeTag is the sum tag for a present Option value.
+	OptionSomeTag uint8 = 0
+	// OptionNoneTag is the sum tag for an absent Option value.
+	OptionNoneTag uint8 = 1
+)
+
+// Writer appends BSATN-encoded values to an in-memory buffer.
+//
+// The zero value is ready to use.
+type Writer struct {
+	buf []byte
+}
+
+// NewWriter returns a Writer whose buffer has the given initial capacity.
+func NewWriter(capacity int) *Writer {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &Writer{buf: make([]byte, 0, capacity)}
+}
+
+// Bytes returns the encoded bytes. The slice aliases the writer's buffer
+// and is only valid until the next write or Reset.
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+// Len returns the number of bytes written so far.
+func (w *Writer) Len() int {
+	return len(w.buf)
+}
+
+// Reset discards written bytes while keeping the allocated buffer.
+func (w *Writer) Reset() {
+	w.buf = w.buf[:0]
+}
+
+func (w *Writer) WriteBool(v bool) {
+	if v {
+		w.buf = append(w.buf, 1)
+		return
+	}
+	w.buf = append(w.buf, 0)
+}
+
+func (w *Writer) WriteU8(v uint8) {
+	w.buf = append(w.buf, v)
+}
+
+func (w *Writer) WriteI8(v int8) {
+	w.buf = append(w.buf, uint8(v))
+}
+
+func (w *Writer) WriteU16(v uint16) {
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, v)
+}
+
+func (w *Writer) WriteI16(v int16) {
+	w.WriteU16(uint16(v))
+}
+
+func (w *Writer) WriteU32(v uint32) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+}
+
+func (w *Writer) WriteI32(v int32) {
+	w.WriteU32(uint32(v))
+}
+
+func (w *Writer) WriteU64(v uint64) {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, v)
+}
+
+func (w *Writer) WriteI64(v int64) {
+	w.WriteU64(uint64(v))
+}
+
+// WriteU128 writes a 128-bit integer given as its low and high 64-bit words.
+func (w *Writer) WriteU128(lo, hi uint64) {
+	w.WriteU64(lo)
+	w.WriteU64(hi)
+}
+
+// WriteI128 writes a two's-complement 128-bit integer given as its low and
+// high 64-bit words. The wire form is identical to WriteU128.
+func (w *Writer) WriteI128(lo uint64, hi int64) {
+	w.WriteU128(lo, uint64(hi))
+}
+
+// WriteU256 writes a 256-bit integer given as four 64-bit words, least
+// significant first.
+func (w *Writer) WriteU256(words [4]uint64) {
+	for _, word := range words {
+		w.WriteU64(word)
+	}
+}
+
+// WriteI256 writes a two's-complement 256-bit integer given as four 64-bit
+// words, least significant first. The wire form is identical to WriteU256.
+func (w *Writer) WriteI256(words [4]uint64) {
+	w.WriteU256(words)
+}
+
+// WriteBigInt writes v as a bits-wide integer (128 or 256), signed or not.
+//
+// A nil v is written as zero.
+func (w *Writer) WriteBigInt(v *big.Int, bits int, signed bool) error {
+	buf, err := bigIntToLittleEndian(v, bits, signed)
+	if err != nil {
+		return err
+	}
+	w.buf = append(w.buf, buf...)
+	return nil
+}
+
+func (w *Writer) WriteF32(v float32) {
+	w.WriteU32(math.Float32bits(v))
+}
+
+func (w *Writer) WriteF64(v float64) {
+	w.WriteU64(math.Float64bits(v))
+}
+
+// WriteString writes a u32 length prefix followed by the UTF-8 bytes of v.
+func (w *Writer) WriteString(v string) error {
+	if err := w.WriteArrayLen(len(v)); err != nil {
+		return err
+	}
+	w.buf = append(w.buf, v...)
+	return nil
+}
+
+// WriteBytes writes v as an Array<U8>: a u32 length prefix and the raw bytes.
+func (w *Writer) WriteBytes(v []byte) error {
+	if err := w.WriteArrayLen(len(v)); err != nil {
+		return err
+	}
+	w.buf = append(w.buf, v...)
+	return nil
+}
+
+// WriteRaw appends already-encoded bytes without a length prefix.
+func (w *Writer) WriteRaw(v []byte) {
+	w.buf = append(w.buf, v...)
+}
+
+// WriteArrayLen writes the u32 element count that prefixes arrays and strings.
+func (w *Writer) WriteArrayLen(n int) error {
+	if n < 0 || uint64(n) > math.MaxUint32 {
+		return ErrLengthOverflow
+	}
+	w.WriteU32(uint32(n))
+	return nil
+}
+
+// WriteSumTag writes the u8 variant tag that prefixes every sum value.
+func (w *Writer) WriteSumTag(tag uint8) {
+	w.WriteU8(tag)
+}
+
+func bigIntToLittleEndian(v *big.Int, bits int, signed bool) ([]byte, error) {
+	if bits != 128 && bits != 256 {
+		return nil, &UnsupportedTypeError{Type: bigIntType, Reason: "width must be 128 or 256 bits"}
+	}
+	size := bits / 8
+	out := make([]byte, size)
+	if v == nil || v.Sign() == 0 {
+		return out, nil
+	}
+
+	value := v
+	if signed {
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+		minValue := new(big.Int).Neg(limit)
+		if v.Cmp(minValue) < 0 || v.Cmp(limit) >= 0 {
+			return nil, ErrIntegerOverflow
+		}
+		if v.Sign() < 0 {
+			value = new(big.Int).Add(v, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+		}
+	} else if v.Sign() < 0 || v.BitLen() > bits {
+		return nil, ErrIntegerOverflow
+	}
+
+	be := value.FillBytes(make([]byte, size))
+	for i := range be {
+		out[i] = be[size-1-i]
+	}
+	return out, nil
+}
+
+func bigIntFromLittleEndian(le []byte, signed bool) *big.Int {
+	size := len(le)
+	be := make([]byte, size)
+	for i := range le {
+		be[i] = le[size-1-i]
+	}
+	out := new(big.Int).SetBytes(be)
+	if signed && size > 0 && be[0]&0x80 != 0 {
+		out.Sub(out, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package bsatn
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestWriterPrimitivesAreLittleEndian(t *testing.T) {
+	w := NewWriter(0)
+	w.WriteBool(true)
+	w.WriteU8(0xab)
+	w.WriteI8(-1)
+	w.WriteU16(0x0102)
+	w.WriteI16(-2)
+	w.WriteU32(0x01020304)
+	w.WriteI32(-3)
+	w.WriteU64(0x0102030405060708)
+	w.WriteI64(-4)
+	w.WriteF32(1.5)
+	w.WriteF64(-2.25)
+
+	want := []byte{
+		1,
+		0xab,
+		0xff,
+		0x02, 0x01,
+		0xfe, 0xff,
+		0x04, 0x03, 0x02, 0x01,
+		0xfd, 0xff, 0xff, 0xff,
+		0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01,
+		0xfc, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0x00, 0x00, 0xc0, 0x3f,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xc0,
+	}
+	if !bytes.Equal(w.Bytes(), want) {
+		t.Fatalf("unexpected encoding:\n got %v\nwant %v", w.Bytes(), want)
+	}
+}
+
+func TestWriterStringsAndBytesArePrefixed(t *testing.T) {
+	w := &Writer{}
+	if err := w.WriteString("hi"); err != nil {
+		t.Fatalf("write string: %v", err)
+	}
+	if err := w.WriteBytes([]byte{9, 8}); err != nil {
+		t.Fatalf("write bytes: %v", err)
+	}
+	w.WriteRaw([]byte{7})
+
+	want := []byte{2, 0, 0, 0, 'h', 'i', 2, 0, 0, 0, 9, 8, 7}
+	if !bytes.Equal(w.Bytes(), want) {
+		t.Fatalf("unexpected encoding: %v", w.Bytes())
+	}
+
+	w.Reset()
+	if w.Len() != 0 {
+		t.Fatalf("expected reset writer to be empty, got %d bytes", w.Len())
+	}
+}
+
+func TestWriterWideIntegers(t *testing.T) {
+	w := &Writer{}
+	w.WriteI128(math.MaxUint64, -1)
+	if got := w.Bytes(); !bytes.Equal(got, bytes.Repeat([]byte{0xff}, 16)) {
+		t.Fatalf("unexpected i128(-1) encoding: %v", got)
+	}
+
+	w.Reset()
+	w.WriteU256([4]uint64{1, 0, 0, 1 << 63})
+	got := w.Bytes()
+	if len(got) != 32 || got[0] != 1 || got[31] != 0x80 {
+		t.Fatalf("unexpected u256 encoding: %v", got)
+	}
+}
+
+func TestWriterBigInt(t *testing.T) {
+	cases := []struct {
+		name   string
+		value  *big.Int
+		bits   int
+		signed bool
+		first  byte
+		last   byte
+	}{
+		{name: "nil is zero", value: nil, bits: 128, first: 0, last: 0},
+		{name: "u128 one", value: big.NewInt(1), bits: 128, first: 1, last: 0},
+		{name: "i128 minus one", value: big.NewInt(-1), bits: 128, signed: true, first: 0xff, last: 0xff},
+		{name: "i256 min", value: new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 255)), bits: 256, signed: true, first: 0, last: 0x80},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &Writer{}
+			if err := w.WriteBigInt(tc.value, tc.bits, tc.signed); err != nil {
+				t.Fatalf("write big int: %v", err)
+			}
+			got := w.Bytes()
+			if len(got) != tc.bits/8 || got[0] != tc.first || got[len(got)-1] != tc.last {
+				t.Fatalf("unexpected encoding: %v", got)
+			}
+		})
+	}
+
+	overflows := []struct {
+		name   string
+		value  *big.Int
+		bits   int
+		signed bool
+	}{
+		{name: "negative unsigned", value: big.NewInt(-1), bits: 128},
+		{name: "u128 too wide", value: new(big.Int).Lsh(big.NewInt(1), 128), bits: 128},
+		{name: "i128 too large", value: new(big.Int).Lsh(big.NewInt(1), 127), bits: 128, signed: true},
+	}
+	for _, tc := range overflows {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &Writer{}
+			if err := w.WriteBigInt(tc.value, tc.bits, tc.signed); !errors.Is(err, ErrIntegerOverflow) {
+				t.Fatalf("expected ErrIntegerOverflow, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// THIS FILE IS AUTOMATICALLY GENERATED BY SPACETIMEDB. EDITS TO THIS FILE
+// WILL NOT BE SAVED. MODIFY TABLES IN YOUR MODULE SOURCE CODE INSTEAD.
+
+package clientapi
+
+import (
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+	"time"
+)
+
+type Result[T any, E any] struct {
+	Ok  *T
+	Err *E
+}
+
+type ScheduleAt struct {
+	types.Sum[scheduleAtSchema]
+}
+
+type ScheduleAtVariant interface {
+	types.SumVariant
+	isScheduleAtVariant()
+}
+
+type ScheduleAtInterval struct {
+	Value time.Duration
+}
+
+type ScheduleAtTime struct {
+	Value time.Time
+}
+
+func NewScheduleAt(v ScheduleAtVariant) ScheduleAt {
+	return ScheduleAt{types.NewSum[scheduleAtSchema](v)}
+}
+
+func (s ScheduleAt) Variant() ScheduleAtVariant {
+	v, _ := s.Sum.Variant().(ScheduleAtVariant)
+	return v
+}
+
+type ScheduleAtVisitor[R any] interface {
+	VisitInterval(time.Duration) R
+	VisitTime(time.Time) R
+}
+
+func VisitScheduleAt[R any](s ScheduleAt, visitor ScheduleAtVisitor[R]) R {
+	return MatchScheduleAt(s, visitor.VisitInterval, visitor.VisitTime)
+}
+
+func MatchScheduleAt[R any](s ScheduleAt, onInterval func(time.Duration) R, onTime func(time.Time) R) R {
+	switch v := s.Variant().(type) {
+	case ScheduleAtInterval:
+		return onInterval(v.Value)
+	case ScheduleAtTime:
+		return onTime(v.Value)
+	}
+	var zero R
+	return zero
+}
+
+type scheduleAtSchema struct{}
+
+func (scheduleAtSchema) SumName() string { return "ScheduleAt" }
+
+func (scheduleAtSchema) SumVariants() []types.SumVariant {
+	return []types.SumVariant{
+		ScheduleAtInterval{},
+		ScheduleAtTime{},
+	}
+}
+
+func (ScheduleAtInterval) SumVariantName() string { return "Interval" }
+func (ScheduleAtInterval) isScheduleAtVariant()   {}
+
+func (ScheduleAtTime) SumVariantName() string { return "Time" }
+func (ScheduleAtTime) isScheduleAtVariant()   {}
@@ -3,17 +3,102 @@
 
 package clientapi
 
-type ClientMessageTag string
-
-const (
-	ClientMessageTagSubscribe     ClientMessageTag = "Subscribe"
-	ClientMessageTagUnsubscribe   ClientMessageTag = "Unsubscribe"
-	ClientMessageTagOneOffQuery   ClientMessageTag = "OneOffQuery"
-	ClientMessageTagCallReducer   ClientMessageTag = "CallReducer"
-	ClientMessageTagCallProcedure ClientMessageTag = "CallProcedure"
+import (
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
 )
 
 type ClientMessage struct {
-	Tag   ClientMessageTag `json:"tag"`
-	Value any              `json:"value,omitempty"`
+	types.Sum[clientMessageSchema]
 }
+
+type ClientMessageVariant interface {
+	types.SumVariant
+	isClientMessageVariant()
+}
+
+type ClientMessageSubscribe struct {
+	Value Subscribe
+}
+
+type ClientMessageUnsubscribe struct {
+	Value Unsubscribe
+}
+
+type ClientMessageOneOffQuery struct {
+	Value OneOffQuery
+}
+
+type ClientMessageCallReducer struct {
+	Value CallReducer
+}
+
+type ClientMessageCallProcedure struct {
+	Value CallProcedure
+}
+
+func NewClientMessage(v ClientMessageVariant) ClientMessage {
+	return ClientMessage{types.NewSum[clientMessageSchema](v)}
+}
+
+func (s ClientMessage) Variant() ClientMessageVariant {
+	v, _ := s.Sum.Variant().(ClientMessageVariant)
+	return v
+}
+
+type ClientMessageVisitor[R any] interface {
+	VisitSubscribe(Subscribe) R
+	VisitUnsubscribe(Unsubscribe) R
+	VisitOneOffQuery(OneOffQuery) R
+	VisitCallReducer(CallReducer) R
+	VisitCallProcedure(CallProcedure) R
+}
+
+func VisitClientMessage[R any](s ClientMessage, visitor ClientMessageVisitor[R]) R {
+	return MatchClientMessage(s, visitor.VisitSubscribe, visitor.VisitUnsubscribe, visitor.VisitOneOffQuery, visitor.VisitCallReducer, visitor.VisitCallProcedure)
+}
+
+func MatchClientMessage[R any](s ClientMessage, onSubscribe func(Subscribe) R, onUnsubscribe func(Unsubscribe) R, onOneOffQuery func(OneOffQuery) R, onCallReducer func(CallReducer) R, onCallProcedure func(CallProcedure) R) R {
+	switch v := s.Variant().(type) {
+	case ClientMessageSubscribe:
+		return onSubscribe(v.Value)
+	case ClientMessageUnsubscribe:
+		return onUnsubscribe(v.Value)
+	case ClientMessageOneOffQuery:
+		return onOneOffQuery(v.Value)
+	case ClientMessageCallReducer:
+		return onCallReducer(v.Value)
+	case ClientMessageCallProcedure:
+		return onCallProcedure(v.Value)
+	}
+	var zero R
+	return zero
+}
+
+type clientMessageSchema struct{}
+
+func (clientMessageSchema) SumName() string { return "ClientMessage" }
+
+func (clientMessageSchema) SumVariants() []types.SumVariant {
+	return []types.SumVariant{
+		ClientMessageSubscribe{},
+		ClientMessageUnsubscribe{},
+		ClientMessageOneOffQuery{},
+		ClientMessageCallReducer{},
+		ClientMessageCallProcedure{},
+	}
+}
+
+func (ClientMessageSubscribe) SumVariantName() string  { return "Subscribe" }
+func (ClientMessageSubscribe) isClientMessageVariant() {}
+
+func (ClientMessageUnsubscribe) SumVariantName() string  { return "Unsubscribe" }
+func (ClientMessageUnsubscribe) isClientMessageVariant() {}
+
+func (ClientMessageOneOffQuery) SumVariantName() string  { return "OneOffQuery" }
+func (ClientMessageOneOffQuery) isClientMessageVariant() {}
+
+func (ClientMessageCallReducer) SumVariantName() string  { return "CallReducer" }
+func (ClientMessageCallReducer) isClientMessageVariant() {}
+
+func (ClientMessageCallProcedure) SumVariantName() string  { return "CallProcedure" }
+func (ClientMessageCallProcedure) isClientMessageVariant() {}
@@ -3,8 +3,12 @@
 
 package clientapi
 
+import (
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
 type InitialConnection struct {
-	Identity     string `json:"identity"`
-	ConnectionId string `json:"connection_id"`
-	Token        string `json:"token"`
+	Identity     types.Identity     `json:"identity"`
+	ConnectionId types.ConnectionId `json:"connection_id"`
+	Token        string             `json:"token"`
 }
@@ -3,14 +3,69 @@
 
 package clientapi
 
-type ProcedureStatusTag string
-
-const (
-	ProcedureStatusTagReturned      ProcedureStatusTag = "Returned"
-	ProcedureStatusTagInternalError ProcedureStatusTag = "InternalError"
+import (
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
 )
 
 type ProcedureStatus struct {
-	Tag   ProcedureStatusTag `json:"tag"`
-	Value any                `json:"value,omitempty"`
+	types.Sum[procedureStatusSchema]
+}
+
+type ProcedureStatusVariant interface {
+	types.SumVariant
+	isProcedureStatusVariant()
+}
+
+type ProcedureStatusReturned struct {
+	Value []uint8
+}
+
+type ProcedureStatusInternalError struct {
+	Value string
+}
+
+func NewProcedureStatus(v ProcedureStatusVariant) ProcedureStatus {
+	return ProcedureStatus{types.NewSum[procedureStatusSchema](v)}
 }
+
+func (s ProcedureStatus) Variant() ProcedureStatusVariant {
+	v, _ := s.Sum.Variant().(ProcedureStatusVariant)
+	return v
+}
+
+type ProcedureStatusVisitor[R any] interface {
+	VisitReturned([]uint8) R
+	VisitInternalError(string) R
+}
+
+func VisitProcedureStatus[R any](s ProcedureStatus, visitor ProcedureStatusVisitor[R]) R {
+	return MatchProcedureStatus(s, visitor.VisitReturned, visitor.VisitInternalError)
+}
+
+func MatchProcedureStatus[R any](s ProcedureStatus, onReturned func([]uint8) R, onInternalError func(string) R) R {
+	switch v := s.Variant().(type) {
+	case ProcedureStatusReturned:
+		return onReturned(v.Value)
+	case ProcedureStatusInternalError:
+		return onInternalError(v.Value)
+	}
+	var zero R
+	return zero
+}
+
+type procedureStatusSchema struct{}
+
+func (procedureStatusSchema) SumName() string { return "ProcedureStatus" }
+
+func (procedureStatusSchema) SumVariants() []types.SumVariant {
+	return []types.SumVariant{
+		ProcedureStatusReturned{},
+		ProcedureStatusInternalError{},
+	}
+}
+
+func (ProcedureStatusReturned) SumVariantName() string    { return "Returned" }
+func (ProcedureStatusReturned) isProcedureStatusVariant() {}
+
+func (ProcedureStatusInternalError) SumVariantName() string    { return "InternalError" }
+func (ProcedureStatusInternalError) isProcedureStatusVariant() {}
@@ -3,16 +3,89 @@
 
 package clientapi
 
-type ReducerOutcomeTag string
-
-const (
-	ReducerOutcomeTagOk            ReducerOutcomeTag = "Ok"
-	ReducerOutcomeTagOkEmpty       ReducerOutcomeTag = "OkEmpty"
-	ReducerOutcomeTagErr           ReducerOutcomeTag = "Err"
-	ReducerOutcomeTagInternalError ReducerOutcomeTag = "InternalError"
+import (
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
 )
 
 type ReducerOutcome struct {
-	Tag   ReducerOutcomeTag `json:"tag"`
-	Value any               `json:"value,omitempty"`
+	types.Sum[reducerOutcomeSchema]
+}
+
+type ReducerOutcomeVariant interface {
+	types.SumVariant
+	isReducerOutcomeVariant()
+}
+
+type ReducerOutcomeOk struct {
+	Value ReducerOk
+}
+
+type ReducerOutcomeOkEmpty struct{}
+
+type ReducerOutcomeErr struct {
+	Value []uint8
+}
+
+type ReducerOutcomeInternalError struct {
+	Value string
+}
+
+func NewReducerOutcome(v ReducerOutcomeVariant) ReducerOutcome {
+	return ReducerOutcome{types.NewSum[reducerOutcomeSchema](v)}
+}
+
+func (s ReducerOutcome) Variant() ReducerOutcomeVariant {
+	v, _ := s.Sum.Variant().(ReducerOutcomeVariant)
+	return v
 }
+
+type ReducerOutcomeVisitor[R any] interface {
+	VisitOk(ReducerOk) R
+	VisitOkEmpty() R
+	VisitErr([]uint8) R
+	VisitInternalError(string) R
+}
+
+func VisitReducerOutcome[R any](s ReducerOutcome, visitor ReducerOutcomeVisitor[R]) R {
+	return MatchReducerOutcome(s, visitor.VisitOk, visitor.VisitOkEmpty, visitor.VisitErr, visitor.VisitInternalError)
+}
+
+func MatchReducerOutcome[R any](s ReducerOutcome, onOk func(ReducerOk) R, onOkEmpty func() R, onErr func([]uint8) R, onInternalError func(string) R) R {
+	switch v := s.Variant().(type) {
+	case ReducerOutcomeOk:
+		return onOk(v.Value)
+	case ReducerOutcomeOkEmpty:
+		return onOkEmpty()
+	case ReducerOutcomeErr:
+		return onErr(v.Value)
+	case ReducerOutcomeInternalError:
+		return onInternalError(v.Value)
+	}
+	var zero R
+	return zero
+}
+
+type reducerOutcomeSchema struct{}
+
+func (reducerOutcomeSchema) SumName() string { return "ReducerOutcome" }
+
+func (reducerOutcomeSchema) SumVariants() []types.SumVariant {
+	return []types.SumVariant{
+		ReducerOutcomeOk{},
+		ReducerOutcomeOkEmpty{},
+		ReducerOutcomeErr{},
+		ReducerOutcomeInternalError{},
+	}
+}
+
+func (ReducerOutcomeOk) SumVariantName() string   { return "Ok" }
+func (ReducerOutcomeOk) isReducerOutcomeVariant() {}
+
+func (ReducerOutcomeOkEmpty) SumVariantName() string   { return "OkEmpty" }
+func (ReducerOutcomeOkEmpty) isReducerOutcomeVariant() {}
+
+func (ReducerOutcomeErr) SumVariantName() string   { return "Err" }
+func (ReducerOutcomeErr) isReducerOutcomeVariant() {}
+
+func (ReducerOutcomeInternalError) SumVariantName() string   { return "InternalError" }
+func (ReducerOutcomeInternalError) isReducerOutcomeVariant() {}
@@ -3,14 +3,69 @@
 
 package clientapi
 
-type RowSizeHintTag string
-
-const (
-	RowSizeHintTagFixedSize  RowSizeHintTag = "FixedSize"
-	RowSizeHintTagRowOffsets RowSizeHintTag = "RowOffsets"
+import (
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
 )
 
 type RowSizeHint struct {
-	Tag   RowSizeHintTag `json:"tag"`
-	Value any            `json:"value,omitempty"`
+	types.Sum[rowSizeHintSchema]
+}
+
+type RowSizeHintVariant interface {
+	types.SumVariant
+	isRowSizeHintVariant()
+}
+
+type RowSizeHintFixedSize struct {
+	Value uint16
+}
+
+type RowSizeHintRowOffsets struct {
+	Value []uint64
+}
+
+func NewRowSizeHint(v RowSizeHintVariant) RowSizeHint {
+	return RowSizeHint{types.NewSum[rowSizeHintSchema](v)}
 }
+
+func (s RowSizeHint) Variant() RowSizeHintVariant {
+	v, _ := s.Sum.Variant().(RowSizeHintVariant)
+	return v
+}
+
+type RowSizeHintVisitor[R any] interface {
+	VisitFixedSize(uint16) R
+	VisitRowOffsets([]uint64) R
+}
+
+func VisitRowSizeHint[R any](s RowSizeHint, visitor RowSizeHintVisitor[R]) R {
+	return MatchRowSizeHint(s, visitor.VisitFixedSize, visitor.VisitRowOffsets)
+}
+
+func MatchRowSizeHint[R any](s RowSizeHint, onFixedSize func(uint16) R, onRowOffsets func([]uint64) R) R {
+	switch v := s.Variant().(type) {
+	case RowSizeHintFixedSize:
+		return onFixedSize(v.Value)
+	case RowSizeHintRowOffsets:
+		return onRowOffsets(v.Value)
+	}
+	var zero R
+	return zero
+}
+
+type rowSizeHintSchema struct{}
+
+func (rowSizeHintSchema) SumName() string { return "RowSizeHint" }
+
+func (rowSizeHintSchema) SumVariants() []types.SumVariant {
+	return []types.SumVariant{
+		RowSizeHintFixedSize{},
+		RowSizeHintRowOffsets{},
+	}
+}
+
+func (RowSizeHintFixedSize) SumVariantName() string { return "FixedSize" }
+func (RowSizeHintFixedSize) isRowSizeHintVariant()  {}
+
+func (RowSizeHintRowOffsets) SumVariantName() string { return "RowOffsets" }
+func (RowSizeHintRowOffsets) isRowSizeHintVariant()  {}
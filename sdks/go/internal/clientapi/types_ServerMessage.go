@@ -3,20 +3,135 @@
 
 package clientapi
 
-type ServerMessageTag string
-
-const (
-	ServerMessageTagInitialConnection  ServerMessageTag = "InitialConnection"
-	ServerMessageTagSubscribeApplied   ServerMessageTag = "SubscribeApplied"
-	ServerMessageTagUnsubscribeApplied ServerMessageTag = "UnsubscribeApplied"
-	ServerMessageTagSubscriptionError  ServerMessageTag = "SubscriptionError"
-	ServerMessageTagTransactionUpdate  ServerMessageTag = "TransactionUpdate"
-	ServerMessageTagOneOffQueryResult  ServerMessageTag = "OneOffQueryResult"
-	ServerMessageTagReducerResult      ServerMessageTag = "ReducerResult"
-	ServerMessageTagProcedureResult    ServerMessageTag = "ProcedureResult"
+import (
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
 )
 
 type ServerMessage struct {
-	Tag   ServerMessageTag `json:"tag"`
-	Value any              `json:"value,omitempty"`
+	types.Sum[serverMessageSchema]
 }
+
+type ServerMessageVariant interface {
+	types.SumVariant
+	isServerMessageVariant()
+}
+
+type ServerMessageInitialConnection struct {
+	Value InitialConnection
+}
+
+type ServerMessageSubscribeApplied struct {
+	Value SubscribeApplied
+}
+
+type ServerMessageUnsubscribeApplied struct {
+	Value UnsubscribeApplied
+}
+
+type ServerMessageSubscriptionError struct {
+	Value SubscriptionError
+}
+
+type ServerMessageTransactionUpdate struct {
+	Value TransactionUpdate
+}
+
+type ServerMessageOneOffQueryResult struct {
+	Value OneOffQueryResult
+}
+
+type ServerMessageReducerResult struct {
+	Value ReducerResult
+}
+
+type ServerMessageProcedureResult struct {
+	Value ProcedureResult
+}
+
+func NewServerMessage(v ServerMessageVariant) ServerMessage {
+	return ServerMessage{types.NewSum[serverMessageSchema](v)}
+}
+
+func (s ServerMessage) Variant() ServerMessageVariant {
+	v, _ := s.Sum.Variant().(ServerMessageVariant)
+	return v
+}
+
+type ServerMessageVisitor[R any] interface {
+	VisitInitialConnection(InitialConnection) R
+	VisitSubscribeApplied(SubscribeApplied) R
+	VisitUnsubscribeApplied(UnsubscribeApplied) R
+	VisitSubscriptionError(SubscriptionError) R
+	VisitTransactionUpdate(TransactionUpdate) R
+	VisitOneOffQueryResult(OneOffQueryResult) R
+	VisitReducerResult(ReducerResult) R
+	VisitProcedureResult(ProcedureResult) R
+}
+
+func VisitServerMessage[R any](s ServerMessage, visitor ServerMessageVisitor[R]) R {
+	return MatchServerMessage(s, visitor.VisitInitialConnection, visitor.VisitSubscribeApplied, visitor.VisitUnsubscribeApplied, visitor.VisitSubscriptionError, visitor.VisitTransactionUpdate, visitor.VisitOneOffQueryResult, visitor.VisitReducerResult, visitor.VisitProcedureResult)
+}
+
+func MatchServerMessage[R any](s ServerMessage, onInitialConnection func(InitialConnection) R, onSubscribeApplied func(SubscribeApplied) R, onUnsubscribeApplied func(UnsubscribeApplied) R, onSubscriptionError func(SubscriptionError) R, onTransactionUpdate func(TransactionUpdate) R, onOneOffQueryResult func(OneOffQueryResult) R, onReducerResult func(ReducerResult) R, onProcedureResult func(ProcedureResult) R) R {
+	switch v := s.Variant().(type) {
+	case ServerMessageInitialConnection:
+		return onInitialConnection(v.Value)
+	case ServerMessageSubscribeApplied:
+		return onSubscribeApplied(v.Value)
+	case ServerMessageUnsubscribeApplied:
+		return onUnsubscribeApplied(v.Value)
+	case ServerMessageSubscriptionError:
+		return onSubscriptionError(v.Value)
+	case ServerMessageTransactionUpdate:
+		return onTransactionUpdate(v.Value)
+	case ServerMessageOneOffQueryResult:
+		return onOneOffQueryResult(v.Value)
+	case ServerMessageReducerResult:
+		return onReducerResult(v.Value)
+	case ServerMessageProcedureResult:
+		return onProcedureResult(v.Value)
+	}
+	var zero R
+	return zero
+}
+
+type serverMessageSchema struct{}
+
+func (serverMessageSchema) SumName() string { return "ServerMessage" }
+
+func (serverMessageSchema) SumVariants() []types.SumVariant {
+	return []types.SumVariant{
+		ServerMessageInitialConnection{},
+		ServerMessageSubscribeApplied{},
+		ServerMessageUnsubscribeApplied{},
+		ServerMessageSubscriptionError{},
+		ServerMessageTransactionUpdate{},
+		ServerMessageOneOffQueryResult{},
+		ServerMessageReducerResult{},
+		ServerMessageProcedureResult{},
+	}
+}
+
+func (ServerMessageInitialConnection) SumVariantName() string  { return "InitialConnection" }
+func (ServerMessageInitialConnection) isServerMessageVariant() {}
+
+func (ServerMessageSubscribeApplied) SumVariantName() string  { return "SubscribeApplied" }
+func (ServerMessageSubscribeApplied) isServerMessageVariant() {}
+
+func (ServerMessageUnsubscribeApplied) SumVariantName() string  { return "UnsubscribeApplied" }
+func (ServerMessageUnsubscribeApplied) isServerMessageVariant() {}
+
+func (ServerMessageSubscriptionError) SumVariantName() string  { return "SubscriptionError" }
+func (ServerMessageSubscriptionError) isServerMessageVariant() {}
+
+func (ServerMessageTransactionUpdate) SumVariantName() string  { return "TransactionUpdate" }
+func (ServerMessageTransactionUpdate) isServerMessageVariant() {}
+
+func (ServerMessageOneOffQueryResult) SumVariantName() string  { return "OneOffQueryResult" }
+func (ServerMessageOneOffQueryResult) isServerMessageVariant() {}
+
+func (ServerMessageReducerResult) SumVariantName() string  { return "ReducerResult" }
+func (ServerMessageReducerResult) isServerMessageVariant() {}
+
+func (ServerMessageProcedureResult) SumVariantName() string  { return "ProcedureResult" }
+func (ServerMessageProcedureResult) isServerMessageVariant() {}
@@ -3,14 +3,69 @@
 
 package clientapi
 
-type TableUpdateRowsTag string
-
-const (
-	TableUpdateRowsTagPersistentTable TableUpdateRowsTag = "PersistentTable"
-	TableUpdateRowsTagEventTable      TableUpdateRowsTag = "EventTable"
+import (
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
 )
 
 type TableUpdateRows struct {
-	Tag   TableUpdateRowsTag `json:"tag"`
-	Value any                `json:"value,omitempty"`
+	types.Sum[tableUpdateRowsSchema]
+}
+
+type TableUpdateRowsVariant interface {
+	types.SumVariant
+	isTableUpdateRowsVariant()
+}
+
+type TableUpdateRowsPersistentTable struct {
+	Value PersistentTableRows
+}
+
+type TableUpdateRowsEventTable struct {
+	Value EventTableRows
+}
+
+func NewTableUpdateRows(v TableUpdateRowsVariant) TableUpdateRows {
+	return TableUpdateRows{types.NewSum[tableUpdateRowsSchema](v)}
 }
+
+func (s TableUpdateRows) Variant() TableUpdateRowsVariant {
+	v, _ := s.Sum.Variant().(TableUpdateRowsVariant)
+	return v
+}
+
+type TableUpdateRowsVisitor[R any] interface {
+	VisitPersistentTable(PersistentTableRows) R
+	VisitEventTable(EventTableRows) R
+}
+
+func VisitTableUpdateRows[R any](s TableUpdateRows, visitor TableUpdateRowsVisitor[R]) R {
+	return MatchTableUpdateRows(s, visitor.VisitPersistentTable, visitor.VisitEventTable)
+}
+
+func MatchTableUpdateRows[R any](s TableUpdateRows, onPersistentTable func(PersistentTableRows) R, onEventTable func(EventTableRows) R) R {
+	switch v := s.Variant().(type) {
+	case TableUpdateRowsPersistentTable:
+		return onPersistentTable(v.Value)
+	case TableUpdateRowsEventTable:
+		return onEventTable(v.Value)
+	}
+	var zero R
+	return zero
+}
+
+type tableUpdateRowsSchema struct{}
+
+func (tableUpdateRowsSchema) SumName() string { return "TableUpdateRows" }
+
+func (tableUpdateRowsSchema) SumVariants() []types.SumVariant {
+	return []types.SumVariant{
+		TableUpdateRowsPersistentTable{},
+		TableUpdateRowsEventTable{},
+	}
+}
+
+func (TableUpdateRowsPersistentTable) SumVariantName() string    { return "PersistentTable" }
+func (TableUpdateRowsPersistentTable) isTableUpdateRowsVariant() {}
+
+func (TableUpdateRowsEventTable) SumVariantName() string    { return "EventTable" }
+func (TableUpdateRowsEventTable) isTableUpdateRowsVariant() {}
@@ -0,0 +1,280 @@
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+// ClientMessage variant tags, in declaration order.
+const (
+	clientMessageSubscribe uint8 = iota
+	clientMessageUnsubscribe
+	clientMessageOneOffQuery
+	clientMessageCallReducer
+	clientMessageCallProcedure
+)
+
+// BSATNMessageEncoder encodes a ClientMessage as a binary v2 ClientMessage.
+func BSATNMessageEncoder(message ClientMessage) ([]byte, error) {
+	wire, err := ToClientAPIMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeClientMessage(wire)
+}
+
+// BSATNClientMessageDecoder is the inverse of BSATNMessageEncoder. The SDK
+// never receives client messages; this exists for fake servers in tests.
+func BSATNClientMessageDecoder(payload []byte) (ClientMessage, error) {
+	wire, err := DecodeClientMessage(payload)
+	if err != nil {
+		return ClientMessage{}, err
+	}
+	return FromClientAPIMessage(wire)
+}
+
+// ToClientAPIMessage converts a ClientMessage into its v2 wire representation.
+func ToClientAPIMessage(message ClientMessage) (clientapi.ClientMessage, error) {
+	switch message.Kind {
+	case ClientMessageSubscribe:
+		if message.QueryID == nil {
+			return clientapi.ClientMessage{}, fmt.Errorf("%s requires a query id", message.Kind)
+		}
+		return clientapi.NewClientMessage(clientapi.ClientMessageSubscribe{Value: clientapi.Subscribe{
+			RequestId:    message.RequestID,
+			QuerySetId:   clientapi.QuerySetId{Id: *message.QueryID},
+			QueryStrings: message.QueryStrings,
+		}}), nil
+	case ClientMessageUnsubscribe:
+		if message.QueryID == nil {
+			return clientapi.ClientMessage{}, fmt.Errorf("%s requires a query id", message.Kind)
+		}
+		return clientapi.NewClientMessage(clientapi.ClientMessageUnsubscribe{Value: clientapi.Unsubscribe{
+			RequestId:  message.RequestID,
+			QuerySetId: clientapi.QuerySetId{Id: *message.QueryID},
+			Flags:      clientapi.UnsubscribeFlagsDefault,
+		}}), nil
+	case ClientMessageOneOffQuery:
+		return clientapi.NewClientMessage(clientapi.ClientMessageOneOffQuery{Value: clientapi.OneOffQuery{
+			RequestId:   message.RequestID,
+			QueryString: message.Query,
+		}}), nil
+	case ClientMessageCallReducer:
+		return clientapi.NewClientMessage(clientapi.ClientMessageCallReducer{Value: clientapi.CallReducer{
+			RequestId: message.RequestID,
+			Reducer:   message.Reducer,
+			Args:      message.Args,
+		}}), nil
+	case ClientMessageCallProcedure:
+		return clientapi.NewClientMessage(clientapi.ClientMessageCallProcedure{Value: clientapi.CallProcedure{
+			RequestId: message.RequestID,
+			Procedure: message.Procedure,
+			Args:      message.Args,
+		}}), nil
+	default:
+		return clientapi.ClientMessage{}, fmt.Errorf("unknown client message kind %q", message.Kind)
+	}
+}
+
+// FromClientAPIMessage converts a v2 wire ClientMessage back into a ClientMessage.
+func FromClientAPIMessage(message clientapi.ClientMessage) (ClientMessage, error) {
+	switch v := message.Variant().(type) {
+	case clientapi.ClientMessageSubscribe:
+		return ClientMessage{
+			Kind:         ClientMessageSubscribe,
+			RequestID:    v.Value.RequestId,
+			QueryID:      uint32Ptr(v.Value.QuerySetId.Id),
+			QueryStrings: v.Value.QueryStrings,
+		}, nil
+	case clientapi.ClientMessageUnsubscribe:
+		return ClientMessage{
+			Kind:      ClientMessageUnsubscribe,
+			RequestID: v.Value.RequestId,
+			QueryID:   uint32Ptr(v.Value.QuerySetId.Id),
+		}, nil
+	case clientapi.ClientMessageOneOffQuery:
+		return ClientMessage{
+			Kind:      ClientMessageOneOffQuery,
+			RequestID: v.Value.RequestId,
+			Query:     v.Value.QueryString,
+		}, nil
+	case clientapi.ClientMessageCallReducer:
+		return ClientMessage{
+			Kind:      ClientMessageCallReducer,
+			RequestID: v.Value.RequestId,
+			Reducer:   v.Value.Reducer,
+			Args:      v.Value.Args,
+		}, nil
+	case clientapi.ClientMessageCallProcedure:
+		return ClientMessage{
+			Kind:      ClientMessageCallProcedure,
+			RequestID: v.Value.RequestId,
+			Procedure: v.Value.Procedure,
+			Args:      v.Value.Args,
+		}, nil
+	default:
+		return ClientMessage{}, fmt.Errorf("ClientMessage: %w", types.ErrEmptySum)
+	}
+}
+
+// EncodeClientMessage encodes a v2 wire ClientMessage.
+func EncodeClientMessage(message clientapi.ClientMessage) ([]byte, error) {
+	w := &bsatn.Writer{}
+	if err := writeClientMessage(w, message); err != nil {
+		return nil, fmt.Errorf("encode client message: %w", err)
+	}
+	return w.Bytes(), nil
+}
+
+// DecodeClientMessage decodes a v2 wire ClientMessage.
+func DecodeClientMessage(payload []byte) (clientapi.ClientMessage, error) {
+	r := bsatn.NewReader(payload)
+	message, err := readClientMessage(r)
+	if err != nil {
+		return clientapi.ClientMessage{}, fmt.Errorf("decode client message: %w", err)
+	}
+	if !r.Done() {
+		return clientapi.ClientMessage{}, fmt.Errorf("decode client message: %w (%d bytes)", bsatn.ErrTrailingBytes, r.Remaining())
+	}
+	return message, nil
+}
+
+func writeClientMessage(w *bsatn.Writer, message clientapi.ClientMessage) error {
+	switch v := message.Variant().(type) {
+	case clientapi.ClientMessageSubscribe:
+		w.WriteSumTag(clientMessageSubscribe)
+		w.WriteU32(v.Value.RequestId)
+		writeQuerySetID(w, v.Value.QuerySetId)
+		if err := w.WriteArrayLen(len(v.Value.QueryStrings)); err != nil {
+			return err
+		}
+		for _, query := range v.Value.QueryStrings {
+			if err := w.WriteString(query); err != nil {
+				return err
+			}
+		}
+		return nil
+	case clientapi.ClientMessageUnsubscribe:
+		w.WriteSumTag(clientMessageUnsubscribe)
+		w.WriteU32(v.Value.RequestId)
+		writeQuerySetID(w, v.Value.QuerySetId)
+		w.WriteSumTag(uint8(v.Value.Flags))
+		return nil
+	case clientapi.ClientMessageOneOffQuery:
+		w.WriteSumTag(clientMessageOneOffQuery)
+		w.WriteU32(v.Value.RequestId)
+		return w.WriteString(v.Value.QueryString)
+	case clientapi.ClientMessageCallReducer:
+		w.WriteSumTag(clientMessageCallReducer)
+		w.WriteU32(v.Value.RequestId)
+		w.WriteU8(v.Value.Flags)
+		if err := w.WriteString(v.Value.Reducer); err != nil {
+			return err
+		}
+		return w.WriteBytes(v.Value.Args)
+	case clientapi.ClientMessageCallProcedure:
+		w.WriteSumTag(clientMessageCallProcedure)
+		w.WriteU32(v.Value.RequestId)
+		w.WriteU8(v.Value.Flags)
+		if err := w.WriteString(v.Value.Procedure); err != nil {
+			return err
+		}
+		return w.WriteBytes(v.Value.Args)
+	default:
+		return fmt.Errorf("ClientMessage: %w", types.ErrEmptySum)
+	}
+}
+
+func readClientMessage(r *bsatn.Reader) (clientapi.ClientMessage, error) {
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return clientapi.ClientMessage{}, err
+	}
+
+	var variant clientapi.ClientMessageVariant
+	switch tag {
+	case clientMessageSubscribe:
+		var v clientapi.Subscribe
+		if v.RequestId, err = r.ReadU32(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if v.QuerySetId, err = readQuerySetID(r); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		n, err := r.ReadArrayLen()
+		if err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		v.QueryStrings = make([]string, 0, min(n, r.Remaining()))
+		for i := 0; i < n; i++ {
+			query, err := r.ReadString()
+			if err != nil {
+				return clientapi.ClientMessage{}, err
+			}
+			v.QueryStrings = append(v.QueryStrings, query)
+		}
+		variant = clientapi.ClientMessageSubscribe{Value: v}
+	case clientMessageUnsubscribe:
+		var v clientapi.Unsubscribe
+		if v.RequestId, err = r.ReadU32(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if v.QuerySetId, err = readQuerySetID(r); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		flags, err := r.ReadSumTag()
+		if err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if flags > uint8(clientapi.UnsubscribeFlagsSendDroppedRows) {
+			return clientapi.ClientMessage{}, r.InvalidTag("UnsubscribeFlags", flags)
+		}
+		v.Flags = clientapi.UnsubscribeFlags(flags)
+		variant = clientapi.ClientMessageUnsubscribe{Value: v}
+	case clientMessageOneOffQuery:
+		var v clientapi.OneOffQuery
+		if v.RequestId, err = r.ReadU32(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if v.QueryString, err = r.ReadString(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		variant = clientapi.ClientMessageOneOffQuery{Value: v}
+	case clientMessageCallReducer:
+		var v clientapi.CallReducer
+		if v.RequestId, err = r.ReadU32(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if v.Flags, err = r.ReadU8(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if v.Reducer, err = r.ReadString(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if v.Args, err = r.ReadBytes(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		variant = clientapi.ClientMessageCallReducer{Value: v}
+	case clientMessageCallProcedure:
+		var v clientapi.CallProcedure
+		if v.RequestId, err = r.ReadU32(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if v.Flags, err = r.ReadU8(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if v.Procedure, err = r.ReadString(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		if v.Args, err = r.ReadBytes(); err != nil {
+			return clientapi.ClientMessage{}, err
+		}
+		variant = clientapi.ClientMessageCallProcedure{Value: v}
+	default:
+		return clientapi.ClientMessage{}, r.InvalidTag("ClientMessage", tag)
+	}
+	return clientapi.NewClientMessage(variant), nil
+}
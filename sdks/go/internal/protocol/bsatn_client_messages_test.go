@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBSATNMessageEncoderFixtures(t *testing.T) {
+	queryID := uint32(4)
+	cases := []struct {
+		name    string
+		message ClientMessage
+		raw     []byte
+	}{
+		{
+			name:    "subscribe",
+			message: ClientMessage{Kind: ClientMessageSubscribe, RequestID: 1, QueryID: &queryID, QueryStrings: []string{"select * from users"}},
+			raw:     fixture([]byte{0}, le32(1), le32(4), le32(1), str("select * from users")),
+		},
+		{
+			name:    "unsubscribe",
+			message: ClientMessage{Kind: ClientMessageUnsubscribe, RequestID: 2, QueryID: &queryID},
+			raw:     fixture([]byte{1}, le32(2), le32(4), []byte{0}),
+		},
+		{
+			name:    "one off query",
+			message: ClientMessage{Kind: ClientMessageOneOffQuery, RequestID: 3, Query: "select 1"},
+			raw:     fixture([]byte{2}, le32(3), str("select 1")),
+		},
+		{
+			name:    "call reducer",
+			message: ClientMessage{Kind: ClientMessageCallReducer, RequestID: 4, Reducer: "set_name", Args: []byte{9}},
+			raw:     fixture([]byte{3}, le32(4), []byte{0}, str("set_name"), le32(1), []byte{9}),
+		},
+		{
+			name:    "call procedure",
+			message: ClientMessage{Kind: ClientMessageCallProcedure, RequestID: 5, Procedure: "get_user", Args: []byte{}},
+			raw:     fixture([]byte{4}, le32(5), []byte{0}, str("get_user"), le32(0)),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := BSATNMessageEncoder(tc.message)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if !bytes.Equal(encoded, tc.raw) {
+				t.Fatalf("encoded message mismatch:\n got %v\nwant %v", encoded, tc.raw)
+			}
+
+			decoded, err := BSATNClientMessageDecoder(encoded)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tc.message) {
+				t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", decoded, tc.message)
+			}
+		})
+	}
+}
+
+func TestBSATNMessageEncoderRejectsInvalidMessages(t *testing.T) {
+	cases := []ClientMessage{
+		{Kind: ClientMessageSubscribe, QueryStrings: []string{"select 1"}},
+		{Kind: ClientMessageUnsubscribe},
+		{Kind: ClientMessageKind("bogus")},
+	}
+	for _, message := range cases {
+		if _, err := BSATNMessageEncoder(message); err == nil {
+			t.Fatalf("expected %q message to fail encoding", message.Kind)
+		}
+	}
+}
+
+func TestDecodeClientMessageRejectsUnknownTag(t *testing.T) {
+	if _, err := DecodeClientMessage([]byte{9}); err == nil {
+		t.Fatalf("expected unknown client message tag to fail")
+	}
+}
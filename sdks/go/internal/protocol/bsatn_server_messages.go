@@ -0,0 +1,797 @@
+package protocol
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+// Variant tags follow the declaration order of the Rust enums in
+// crates/client-api-messages/src/websocket/v2.rs.
+const (
+	serverMessageInitialConnection uint8 = iota
+	serverMessageSubscribeApplied
+	serverMessageUnsubscribeApplied
+	serverMessageSubscriptionError
+	serverMessageTransactionUpdate
+	serverMessageOneOffQueryResult
+	serverMessageReducerResult
+	serverMessageProcedureResult
+)
+
+const (
+	reducerOutcomeOk uint8 = iota
+	reducerOutcomeOkEmpty
+	reducerOutcomeErr
+	reducerOutcomeInternalError
+)
+
+const (
+	procedureStatusReturned uint8 = iota
+	procedureStatusInternalError
+)
+
+const (
+	rowSizeHintFixedSize uint8 = iota
+	rowSizeHintRowOffsets
+)
+
+const (
+	tableUpdateRowsPersistentTable uint8 = iota
+	tableUpdateRowsEventTable
+)
+
+const (
+	resultOkTag  uint8 = 0
+	resultErrTag uint8 = 1
+)
+
+// BSATNMessageDecoder decodes a binary v2 ServerMessage into a RoutedMessage.
+//
+// The payload is the concrete clientapi struct for the message variant, for
+// example clientapi.ReducerResult. Byte fields such as BsatnRowList row data
+// alias the input buffer rather than being copied.
+func BSATNMessageDecoder(payload []byte) (RoutedMessage, error) {
+	message, err := DecodeServerMessage(payload)
+	if err != nil {
+		return RoutedMessage{}, err
+	}
+	return routedFromServerMessage(message)
+}
+
+// DecodeServerMessage decodes a binary v2 ServerMessage.
+func DecodeServerMessage(payload []byte) (clientapi.ServerMessage, error) {
+	r := bsatn.NewReader(payload)
+	message, err := readServerMessage(r)
+	if err != nil {
+		return clientapi.ServerMessage{}, fmt.Errorf("decode server message: %w", err)
+	}
+	if !r.Done() {
+		return clientapi.ServerMessage{}, fmt.Errorf("decode server message: %w (%d bytes)", bsatn.ErrTrailingBytes, r.Remaining())
+	}
+	return message, nil
+}
+
+// EncodeServerMessage encodes a v2 ServerMessage. The SDK never sends server
+// messages; this exists for fixtures and fake servers in tests.
+func EncodeServerMessage(message clientapi.ServerMessage) ([]byte, error) {
+	w := &bsatn.Writer{}
+	if err := writeServerMessage(w, message); err != nil {
+		return nil, fmt.Errorf("encode server message: %w", err)
+	}
+	return w.Bytes(), nil
+}
+
+func routedFromServerMessage(message clientapi.ServerMessage) (RoutedMessage, error) {
+	var kind MessageKind
+	var payload any
+	switch v := message.Variant().(type) {
+	case clientapi.ServerMessageInitialConnection:
+		kind, payload = MessageKindInitialConnection, v.Value
+	case clientapi.ServerMessageSubscribeApplied:
+		kind, payload = MessageKindSubscribeApplied, v.Value
+	case clientapi.ServerMessageUnsubscribeApplied:
+		kind, payload = MessageKindUnsubscribeApplied, v.Value
+	case clientapi.ServerMessageSubscriptionError:
+		kind, payload = MessageKindSubscriptionError, v.Value
+	case clientapi.ServerMessageTransactionUpdate:
+		kind, payload = MessageKindTransactionUpdate, v.Value
+	case clientapi.ServerMessageOneOffQueryResult:
+		kind, payload = MessageKindOneOffQueryResult, v.Value
+	case clientapi.ServerMessageReducerResult:
+		kind, payload = MessageKindReducerResult, v.Value
+	case clientapi.ServerMessageProcedureResult:
+		kind, payload = MessageKindProcedureResult, v.Value
+	default:
+		return RoutedMessage{}, fmt.Errorf("ServerMessage: %w", types.ErrEmptySum)
+	}
+	return routedFromPayload(kind, payload), nil
+}
+
+// routedFromPayload builds a RoutedMessage for the decoded payload of a known
+// message kind, taking the request and query ids from the payload.
+func routedFromPayload(kind MessageKind, payload any) RoutedMessage {
+	msg := RoutedMessage{Kind: kind, Payload: payload}
+	switch v := payload.(type) {
+	case clientapi.SubscribeApplied:
+		msg.RequestID = uint32Ptr(v.RequestId)
+		msg.QueryID = uint32Ptr(v.QuerySetId.Id)
+	case clientapi.UnsubscribeApplied:
+		msg.RequestID = uint32Ptr(v.RequestId)
+		msg.QueryID = uint32Ptr(v.QuerySetId.Id)
+	case clientapi.SubscriptionError:
+		if v.RequestId != nil {
+			msg.RequestID = uint32Ptr(*v.RequestId)
+		}
+		msg.QueryID = uint32Ptr(v.QuerySetId.Id)
+	case clientapi.OneOffQueryResult:
+		msg.RequestID = uint32Ptr(v.RequestId)
+	case clientapi.ReducerResult:
+		msg.RequestID = uint32Ptr(v.RequestId)
+	case clientapi.ProcedureResult:
+		msg.RequestID = uint32Ptr(v.RequestId)
+	}
+	return msg
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func readServerMessage(r *bsatn.Reader) (clientapi.ServerMessage, error) {
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return clientapi.ServerMessage{}, err
+	}
+
+	var variant clientapi.ServerMessageVariant
+	switch tag {
+	case serverMessageInitialConnection:
+		var v clientapi.InitialConnection
+		v, err = readInitialConnection(r)
+		variant = clientapi.ServerMessageInitialConnection{Value: v}
+	case serverMessageSubscribeApplied:
+		var v clientapi.SubscribeApplied
+		v, err = readSubscribeApplied(r)
+		variant = clientapi.ServerMessageSubscribeApplied{Value: v}
+	case serverMessageUnsubscribeApplied:
+		var v clientapi.UnsubscribeApplied
+		v, err = readUnsubscribeApplied(r)
+		variant = clientapi.ServerMessageUnsubscribeApplied{Value: v}
+	case serverMessageSubscriptionError:
+		var v clientapi.SubscriptionError
+		v, err = readSubscriptionError(r)
+		variant = clientapi.ServerMessageSubscriptionError{Value: v}
+	case serverMessageTransactionUpdate:
+		var v clientapi.TransactionUpdate
+		v, err = readTransactionUpdate(r)
+		variant = clientapi.ServerMessageTransactionUpdate{Value: v}
+	case serverMessageOneOffQueryResult:
+		var v clientapi.OneOffQueryResult
+		v, err = readOneOffQueryResult(r)
+		variant = clientapi.ServerMessageOneOffQueryResult{Value: v}
+	case serverMessageReducerResult:
+		var v clientapi.ReducerResult
+		v, err = readReducerResult(r)
+		variant = clientapi.ServerMessageReducerResult{Value: v}
+	case serverMessageProcedureResult:
+		var v clientapi.ProcedureResult
+		v, err = readProcedureResult(r)
+		variant = clientapi.ServerMessageProcedureResult{Value: v}
+	default:
+		return clientapi.ServerMessage{}, r.InvalidTag("ServerMessage", tag)
+	}
+	if err != nil {
+		return clientapi.ServerMessage{}, fmt.Errorf("%s: %w", variant.SumVariantName(), err)
+	}
+	return clientapi.NewServerMessage(variant), nil
+}
+
+func writeServerMessage(w *bsatn.Writer, message clientapi.ServerMessage) error {
+	switch v := message.Variant().(type) {
+	case clientapi.ServerMessageInitialConnection:
+		w.WriteSumTag(serverMessageInitialConnection)
+		return writeInitialConnection(w, v.Value)
+	case clientapi.ServerMessageSubscribeApplied:
+		w.WriteSumTag(serverMessageSubscribeApplied)
+		return writeSubscribeApplied(w, v.Value)
+	case clientapi.ServerMessageUnsubscribeApplied:
+		w.WriteSumTag(serverMessageUnsubscribeApplied)
+		return writeUnsubscribeApplied(w, v.Value)
+	case clientapi.ServerMessageSubscriptionError:
+		w.WriteSumTag(serverMessageSubscriptionError)
+		return writeSubscriptionError(w, v.Value)
+	case clientapi.ServerMessageTransactionUpdate:
+		w.WriteSumTag(serverMessageTransactionUpdate)
+		return writeTransactionUpdate(w, v.Value)
+	case clientapi.ServerMessageOneOffQueryResult:
+		w.WriteSumTag(serverMessageOneOffQueryResult)
+		return writeOneOffQueryResult(w, v.Value)
+	case clientapi.ServerMessageReducerResult:
+		w.WriteSumTag(serverMessageReducerResult)
+		return writeReducerResult(w, v.Value)
+	case clientapi.ServerMessageProcedureResult:
+		w.WriteSumTag(serverMessageProcedureResult)
+		return writeProcedureResult(w, v.Value)
+	default:
+		return fmt.Errorf("ServerMessage: %w", types.ErrEmptySum)
+	}
+}
+
+func readInitialConnection(r *bsatn.Reader) (clientapi.InitialConnection, error) {
+	var out clientapi.InitialConnection
+	if err := out.Identity.UnmarshalBSATN(r); err != nil {
+		return out, err
+	}
+	if err := out.ConnectionId.UnmarshalBSATN(r); err != nil {
+		return out, err
+	}
+	var err error
+	out.Token, err = r.ReadString()
+	return out, err
+}
+
+func writeInitialConnection(w *bsatn.Writer, v clientapi.InitialConnection) error {
+	if err := v.Identity.MarshalBSATN(w); err != nil {
+		return err
+	}
+	if err := v.ConnectionId.MarshalBSATN(w); err != nil {
+		return err
+	}
+	return w.WriteString(v.Token)
+}
+
+func readQuerySetID(r *bsatn.Reader) (clientapi.QuerySetId, error) {
+	id, err := r.ReadU32()
+	return clientapi.QuerySetId{Id: id}, err
+}
+
+func writeQuerySetID(w *bsatn.Writer, v clientapi.QuerySetId) {
+	w.WriteU32(v.Id)
+}
+
+func readSubscribeApplied(r *bsatn.Reader) (clientapi.SubscribeApplied, error) {
+	var out clientapi.SubscribeApplied
+	var err error
+	if out.RequestId, err = r.ReadU32(); err != nil {
+		return out, err
+	}
+	if out.QuerySetId, err = readQuerySetID(r); err != nil {
+		return out, err
+	}
+	out.Rows, err = readQueryRows(r)
+	return out, err
+}
+
+func writeSubscribeApplied(w *bsatn.Writer, v clientapi.SubscribeApplied) error {
+	w.WriteU32(v.RequestId)
+	writeQuerySetID(w, v.QuerySetId)
+	return writeQueryRows(w, v.Rows)
+}
+
+func readUnsubscribeApplied(r *bsatn.Reader) (clientapi.UnsubscribeApplied, error) {
+	var out clientapi.UnsubscribeApplied
+	var err error
+	if out.RequestId, err = r.ReadU32(); err != nil {
+		return out, err
+	}
+	if out.QuerySetId, err = readQuerySetID(r); err != nil {
+		return out, err
+	}
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return out, err
+	}
+	switch tag {
+	case bsatn.OptionSomeTag:
+		rows, err := readQueryRows(r)
+		if err != nil {
+			return out, err
+		}
+		out.Rows = &rows
+	case bsatn.OptionNoneTag:
+	default:
+		return out, r.InvalidTag("Option<QueryRows>", tag)
+	}
+	return out, nil
+}
+
+func writeUnsubscribeApplied(w *bsatn.Writer, v clientapi.UnsubscribeApplied) error {
+	w.WriteU32(v.RequestId)
+	writeQuerySetID(w, v.QuerySetId)
+	if v.Rows == nil {
+		w.WriteSumTag(bsatn.OptionNoneTag)
+		return nil
+	}
+	w.WriteSumTag(bsatn.OptionSomeTag)
+	return writeQueryRows(w, *v.Rows)
+}
+
+func readSubscriptionError(r *bsatn.Reader) (clientapi.SubscriptionError, error) {
+	var out clientapi.SubscriptionError
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return out, err
+	}
+	switch tag {
+	case bsatn.OptionSomeTag:
+		id, err := r.ReadU32()
+		if err != nil {
+			return out, err
+		}
+		out.RequestId = &id
+	case bsatn.OptionNoneTag:
+	default:
+		return out, r.InvalidTag("Option<u32>", tag)
+	}
+	if out.QuerySetId, err = readQuerySetID(r); err != nil {
+		return out, err
+	}
+	out.Error, err = r.ReadString()
+	return out, err
+}
+
+func writeSubscriptionError(w *bsatn.Writer, v clientapi.SubscriptionError) error {
+	if v.RequestId == nil {
+		w.WriteSumTag(bsatn.OptionNoneTag)
+	} else {
+		w.WriteSumTag(bsatn.OptionSomeTag)
+		w.WriteU32(*v.RequestId)
+	}
+	writeQuerySetID(w, v.QuerySetId)
+	return w.WriteString(v.Error)
+}
+
+func readTransactionUpdate(r *bsatn.Reader) (clientapi.TransactionUpdate, error) {
+	n, err := r.ReadArrayLen()
+	if err != nil {
+		return clientapi.TransactionUpdate{}, err
+	}
+	querySets := make([]clientapi.QuerySetUpdate, 0, min(n, r.Remaining()))
+	for i := 0; i < n; i++ {
+		querySet, err := readQuerySetUpdate(r)
+		if err != nil {
+			return clientapi.TransactionUpdate{}, err
+		}
+		querySets = append(querySets, querySet)
+	}
+	return clientapi.TransactionUpdate{QuerySets: querySets}, nil
+}
+
+func writeTransactionUpdate(w *bsatn.Writer, v clientapi.TransactionUpdate) error {
+	if err := w.WriteArrayLen(len(v.QuerySets)); err != nil {
+		return err
+	}
+	for _, querySet := range v.QuerySets {
+		if err := writeQuerySetUpdate(w, querySet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readQuerySetUpdate(r *bsatn.Reader) (clientapi.QuerySetUpdate, error) {
+	var out clientapi.QuerySetUpdate
+	var err error
+	if out.QuerySetId, err = readQuerySetID(r); err != nil {
+		return out, err
+	}
+	n, err := r.ReadArrayLen()
+	if err != nil {
+		return out, err
+	}
+	out.Tables = make([]clientapi.TableUpdate, 0, min(n, r.Remaining()))
+	for i := 0; i < n; i++ {
+		table, err := readTableUpdate(r)
+		if err != nil {
+			return out, err
+		}
+		out.Tables = append(out.Tables, table)
+	}
+	return out, nil
+}
+
+func writeQuerySetUpdate(w *bsatn.Writer, v clientapi.QuerySetUpdate) error {
+	writeQuerySetID(w, v.QuerySetId)
+	if err := w.WriteArrayLen(len(v.Tables)); err != nil {
+		return err
+	}
+	for _, table := range v.Tables {
+		if err := writeTableUpdate(w, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readTableUpdate(r *bsatn.Reader) (clientapi.TableUpdate, error) {
+	var out clientapi.TableUpdate
+	var err error
+	if out.TableName, err = r.ReadString(); err != nil {
+		return out, err
+	}
+	n, err := r.ReadArrayLen()
+	if err != nil {
+		return out, err
+	}
+	out.Rows = make([]clientapi.TableUpdateRows, 0, min(n, r.Remaining()))
+	for i := 0; i < n; i++ {
+		rows, err := readTableUpdateRows(r)
+		if err != nil {
+			return out, err
+		}
+		out.Rows = append(out.Rows, rows)
+	}
+	return out, nil
+}
+
+func writeTableUpdate(w *bsatn.Writer, v clientapi.TableUpdate) error {
+	if err := w.WriteString(v.TableName); err != nil {
+		return err
+	}
+	if err := w.WriteArrayLen(len(v.Rows)); err != nil {
+		return err
+	}
+	for _, rows := range v.Rows {
+		if err := writeTableUpdateRows(w, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readTableUpdateRows(r *bsatn.Reader) (clientapi.TableUpdateRows, error) {
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return clientapi.TableUpdateRows{}, err
+	}
+
+	switch tag {
+	case tableUpdateRowsPersistentTable:
+		var rows clientapi.PersistentTableRows
+		if rows.Inserts, err = readBsatnRowList(r); err != nil {
+			return clientapi.TableUpdateRows{}, err
+		}
+		if rows.Deletes, err = readBsatnRowList(r); err != nil {
+			return clientapi.TableUpdateRows{}, err
+		}
+		return clientapi.NewTableUpdateRows(clientapi.TableUpdateRowsPersistentTable{Value: rows}), nil
+	case tableUpdateRowsEventTable:
+		events, err := readBsatnRowList(r)
+		if err != nil {
+			return clientapi.TableUpdateRows{}, err
+		}
+		return clientapi.NewTableUpdateRows(clientapi.TableUpdateRowsEventTable{Value: clientapi.EventTableRows{Events: events}}), nil
+	default:
+		return clientapi.TableUpdateRows{}, r.InvalidTag("TableUpdateRows", tag)
+	}
+}
+
+func writeTableUpdateRows(w *bsatn.Writer, v clientapi.TableUpdateRows) error {
+	switch rows := v.Variant().(type) {
+	case clientapi.TableUpdateRowsPersistentTable:
+		w.WriteSumTag(tableUpdateRowsPersistentTable)
+		if err := writeBsatnRowList(w, rows.Value.Inserts); err != nil {
+			return err
+		}
+		return writeBsatnRowList(w, rows.Value.Deletes)
+	case clientapi.TableUpdateRowsEventTable:
+		w.WriteSumTag(tableUpdateRowsEventTable)
+		return writeBsatnRowList(w, rows.Value.Events)
+	default:
+		return fmt.Errorf("TableUpdateRows: %w", types.ErrEmptySum)
+	}
+}
+
+func readQueryRows(r *bsatn.Reader) (clientapi.QueryRows, error) {
+	n, err := r.ReadArrayLen()
+	if err != nil {
+		return clientapi.QueryRows{}, err
+	}
+	tables := make([]clientapi.SingleTableRows, 0, min(n, r.Remaining()))
+	for i := 0; i < n; i++ {
+		var table clientapi.SingleTableRows
+		if table.Table, err = r.ReadString(); err != nil {
+			return clientapi.QueryRows{}, err
+		}
+		if table.Rows, err = readBsatnRowList(r); err != nil {
+			return clientapi.QueryRows{}, err
+		}
+		tables = append(tables, table)
+	}
+	return clientapi.QueryRows{Tables: tables}, nil
+}
+
+func writeQueryRows(w *bsatn.Writer, v clientapi.QueryRows) error {
+	if err := w.WriteArrayLen(len(v.Tables)); err != nil {
+		return err
+	}
+	for _, table := range v.Tables {
+		if err := w.WriteString(table.Table); err != nil {
+			return err
+		}
+		if err := writeBsatnRowList(w, table.Rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readBsatnRowList(r *bsatn.Reader) (clientapi.BsatnRowList, error) {
+	var out clientapi.BsatnRowList
+	var err error
+	if out.SizeHint, err = readRowSizeHint(r); err != nil {
+		return out, err
+	}
+	// Row data is left aliasing the decoded frame to avoid a copy per table.
+	out.RowsData, err = r.ReadBytes()
+	return out, err
+}
+
+func writeBsatnRowList(w *bsatn.Writer, v clientapi.BsatnRowList) error {
+	if err := writeRowSizeHint(w, v.SizeHint); err != nil {
+		return err
+	}
+	return w.WriteBytes(v.RowsData)
+}
+
+func readRowSizeHint(r *bsatn.Reader) (clientapi.RowSizeHint, error) {
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return clientapi.RowSizeHint{}, err
+	}
+
+	switch tag {
+	case rowSizeHintFixedSize:
+		size, err := r.ReadU16()
+		if err != nil {
+			return clientapi.RowSizeHint{}, err
+		}
+		return clientapi.NewRowSizeHint(clientapi.RowSizeHintFixedSize{Value: size}), nil
+	case rowSizeHintRowOffsets:
+		n, err := r.ReadArrayLen()
+		if err != nil {
+			return clientapi.RowSizeHint{}, err
+		}
+		offsets := make([]uint64, 0, min(n, r.Remaining()/8))
+		for i := 0; i < n; i++ {
+			offset, err := r.ReadU64()
+			if err != nil {
+				return clientapi.RowSizeHint{}, err
+			}
+			offsets = append(offsets, offset)
+		}
+		return clientapi.NewRowSizeHint(clientapi.RowSizeHintRowOffsets{Value: offsets}), nil
+	default:
+		return clientapi.RowSizeHint{}, r.InvalidTag("RowSizeHint", tag)
+	}
+}
+
+func writeRowSizeHint(w *bsatn.Writer, v clientapi.RowSizeHint) error {
+	switch hint := v.Variant().(type) {
+	case clientapi.RowSizeHintFixedSize:
+		w.WriteSumTag(rowSizeHintFixedSize)
+		w.WriteU16(hint.Value)
+		return nil
+	case clientapi.RowSizeHintRowOffsets:
+		w.WriteSumTag(rowSizeHintRowOffsets)
+		if err := w.WriteArrayLen(len(hint.Value)); err != nil {
+			return err
+		}
+		for _, offset := range hint.Value {
+			w.WriteU64(offset)
+		}
+		return nil
+	default:
+		return fmt.Errorf("RowSizeHint: %w", types.ErrEmptySum)
+	}
+}
+
+func readOneOffQueryResult(r *bsatn.Reader) (clientapi.OneOffQueryResult, error) {
+	var out clientapi.OneOffQueryResult
+	var err error
+	if out.RequestId, err = r.ReadU32(); err != nil {
+		return out, err
+	}
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return out, err
+	}
+	switch tag {
+	case resultOkTag:
+		rows, err := readQueryRows(r)
+		if err != nil {
+			return out, err
+		}
+		out.Result.Ok = &rows
+	case resultErrTag:
+		message, err := r.ReadString()
+		if err != nil {
+			return out, err
+		}
+		out.Result.Err = &message
+	default:
+		return out, r.InvalidTag("Result<QueryRows, String>", tag)
+	}
+	return out, nil
+}
+
+func writeOneOffQueryResult(w *bsatn.Writer, v clientapi.OneOffQueryResult) error {
+	w.WriteU32(v.RequestId)
+	switch {
+	case v.Result.Ok != nil:
+		w.WriteSumTag(resultOkTag)
+		return writeQueryRows(w, *v.Result.Ok)
+	case v.Result.Err != nil:
+		w.WriteSumTag(resultErrTag)
+		return w.WriteString(*v.Result.Err)
+	default:
+		return fmt.Errorf("one-off query result has neither ok nor err set")
+	}
+}
+
+func readReducerResult(r *bsatn.Reader) (clientapi.ReducerResult, error) {
+	var out clientapi.ReducerResult
+	var err error
+	if out.RequestId, err = r.ReadU32(); err != nil {
+		return out, err
+	}
+	if out.Timestamp, err = readTimestamp(r); err != nil {
+		return out, err
+	}
+	out.Result, err = readReducerOutcome(r)
+	return out, err
+}
+
+func writeReducerResult(w *bsatn.Writer, v clientapi.ReducerResult) error {
+	w.WriteU32(v.RequestId)
+	writeTimestamp(w, v.Timestamp)
+	return writeReducerOutcome(w, v.Result)
+}
+
+func readReducerOutcome(r *bsatn.Reader) (clientapi.ReducerOutcome, error) {
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return clientapi.ReducerOutcome{}, err
+	}
+
+	switch tag {
+	case reducerOutcomeOk:
+		var ok clientapi.ReducerOk
+		if ok.RetValue, err = r.ReadBytes(); err != nil {
+			return clientapi.ReducerOutcome{}, err
+		}
+		if ok.TransactionUpdate, err = readTransactionUpdate(r); err != nil {
+			return clientapi.ReducerOutcome{}, err
+		}
+		return clientapi.NewReducerOutcome(clientapi.ReducerOutcomeOk{Value: ok}), nil
+	case reducerOutcomeOkEmpty:
+		return clientapi.NewReducerOutcome(clientapi.ReducerOutcomeOkEmpty{}), nil
+	case reducerOutcomeErr:
+		payload, err := r.ReadBytes()
+		if err != nil {
+			return clientapi.ReducerOutcome{}, err
+		}
+		return clientapi.NewReducerOutcome(clientapi.ReducerOutcomeErr{Value: payload}), nil
+	case reducerOutcomeInternalError:
+		message, err := r.ReadString()
+		if err != nil {
+			return clientapi.ReducerOutcome{}, err
+		}
+		return clientapi.NewReducerOutcome(clientapi.ReducerOutcomeInternalError{Value: message}), nil
+	default:
+		return clientapi.ReducerOutcome{}, r.InvalidTag("ReducerOutcome", tag)
+	}
+}
+
+func writeReducerOutcome(w *bsatn.Writer, v clientapi.ReducerOutcome) error {
+	switch outcome := v.Variant().(type) {
+	case clientapi.ReducerOutcomeOk:
+		w.WriteSumTag(reducerOutcomeOk)
+		if err := w.WriteBytes(outcome.Value.RetValue); err != nil {
+			return err
+		}
+		return writeTransactionUpdate(w, outcome.Value.TransactionUpdate)
+	case clientapi.ReducerOutcomeOkEmpty:
+		w.WriteSumTag(reducerOutcomeOkEmpty)
+		return nil
+	case clientapi.ReducerOutcomeErr:
+		w.WriteSumTag(reducerOutcomeErr)
+		return w.WriteBytes(outcome.Value)
+	case clientapi.ReducerOutcomeInternalError:
+		w.WriteSumTag(reducerOutcomeInternalError)
+		return w.WriteString(outcome.Value)
+	default:
+		return fmt.Errorf("ReducerOutcome: %w", types.ErrEmptySum)
+	}
+}
+
+func readProcedureResult(r *bsatn.Reader) (clientapi.ProcedureResult, error) {
+	var out clientapi.ProcedureResult
+	var err error
+	if out.Status, err = readProcedureStatus(r); err != nil {
+		return out, err
+	}
+	if out.Timestamp, err = readTimestamp(r); err != nil {
+		return out, err
+	}
+	if out.TotalHostExecutionDuration, err = readTimeDuration(r); err != nil {
+		return out, err
+	}
+	out.RequestId, err = r.ReadU32()
+	return out, err
+}
+
+func writeProcedureResult(w *bsatn.Writer, v clientapi.ProcedureResult) error {
+	if err := writeProcedureStatus(w, v.Status); err != nil {
+		return err
+	}
+	writeTimestamp(w, v.Timestamp)
+	writeTimeDuration(w, v.TotalHostExecutionDuration)
+	w.WriteU32(v.RequestId)
+	return nil
+}
+
+func readProcedureStatus(r *bsatn.Reader) (clientapi.ProcedureStatus, error) {
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return clientapi.ProcedureStatus{}, err
+	}
+
+	switch tag {
+	case procedureStatusReturned:
+		payload, err := r.ReadBytes()
+		if err != nil {
+			return clientapi.ProcedureStatus{}, err
+		}
+		return clientapi.NewProcedureStatus(clientapi.ProcedureStatusReturned{Value: payload}), nil
+	case procedureStatusInternalError:
+		message, err := r.ReadString()
+		if err != nil {
+			return clientapi.ProcedureStatus{}, err
+		}
+		return clientapi.NewProcedureStatus(clientapi.ProcedureStatusInternalError{Value: message}), nil
+	default:
+		return clientapi.ProcedureStatus{}, r.InvalidTag("ProcedureStatus", tag)
+	}
+}
+
+func writeProcedureStatus(w *bsatn.Writer, v clientapi.ProcedureStatus) error {
+	switch status := v.Variant().(type) {
+	case clientapi.ProcedureStatusReturned:
+		w.WriteSumTag(procedureStatusReturned)
+		return w.WriteBytes(status.Value)
+	case clientapi.ProcedureStatusInternalError:
+		w.WriteSumTag(procedureStatusInternalError)
+		return w.WriteString(status.Value)
+	default:
+		return fmt.Errorf("ProcedureStatus: %w", types.ErrEmptySum)
+	}
+}
+
+func readTimestamp(r *bsatn.Reader) (time.Time, error) {
+	micros, err := r.ReadI64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMicro(micros).UTC(), nil
+}
+
+func writeTimestamp(w *bsatn.Writer, v time.Time) {
+	w.WriteI64(v.UnixMicro())
+}
+
+func readTimeDuration(r *bsatn.Reader) (time.Duration, error) {
+	micros, err := r.ReadI64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(micros) * time.Microsecond, nil
+}
+
+func writeTimeDuration(w *bsatn.Writer, v time.Duration) {
+	w.WriteI64(v.Microseconds())
+}
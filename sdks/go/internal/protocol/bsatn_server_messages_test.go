@@ -0,0 +1,255 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+func le16(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+func le32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+func le64(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+func str(v string) []byte  { return append(le32(uint32(len(v))), v...) }
+
+func fixture(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func byteRange(start byte, n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = start + byte(i)
+	}
+	return out
+}
+
+var (
+	fixtureIdentity, _     = types.ParseIdentity("201f1e1d1c1b1a191817161514131211100f0e0d0c0b0a090807060504030201")
+	fixtureConnectionID, _ = types.ParseConnectionId("afaeadacabaaa9a8a7a6a5a4a3a2a1a0")
+)
+
+type serverMessageFixture struct {
+	raw       []byte
+	want      clientapi.ServerMessage
+	kind      MessageKind
+	requestID *uint32
+	queryID   *uint32
+}
+
+func serverMessageFixtures() []serverMessageFixture {
+	requestID := uint32(7)
+	oneOffErr := "nope"
+
+	return []serverMessageFixture{
+		{
+			raw: fixture([]byte{0}, byteRange(0x01, 32), byteRange(0xa0, 16), str("tok")),
+			want: clientapi.NewServerMessage(clientapi.ServerMessageInitialConnection{Value: clientapi.InitialConnection{
+				Identity:     fixtureIdentity,
+				ConnectionId: fixtureConnectionID,
+				Token:        "tok",
+			}}),
+			kind: MessageKindInitialConnection,
+		},
+		{
+			raw: fixture([]byte{1}, le32(5), le32(3),
+				le32(1), str("users"), []byte{0}, le16(4), le32(8), byteRange(1, 8)),
+			want: clientapi.NewServerMessage(clientapi.ServerMessageSubscribeApplied{Value: clientapi.SubscribeApplied{
+				RequestId:  5,
+				QuerySetId: clientapi.QuerySetId{Id: 3},
+				Rows: clientapi.QueryRows{Tables: []clientapi.SingleTableRows{{
+					Table: "users",
+					Rows: clientapi.BsatnRowList{
+						SizeHint: clientapi.NewRowSizeHint(clientapi.RowSizeHintFixedSize{Value: 4}),
+						RowsData: byteRange(1, 8),
+					},
+				}}},
+			}}),
+			kind:      MessageKindSubscribeApplied,
+			requestID: uint32Ptr(5),
+			queryID:   uint32Ptr(3),
+		},
+		{
+			raw: fixture([]byte{2}, le32(6), le32(3), []byte{bsatn.OptionNoneTag}),
+			want: clientapi.NewServerMessage(clientapi.ServerMessageUnsubscribeApplied{Value: clientapi.UnsubscribeApplied{
+				RequestId:  6,
+				QuerySetId: clientapi.QuerySetId{Id: 3},
+			}}),
+			kind:      MessageKindUnsubscribeApplied,
+			requestID: uint32Ptr(6),
+			queryID:   uint32Ptr(3),
+		},
+		{
+			raw: fixture([]byte{3}, []byte{bsatn.OptionSomeTag}, le32(7), le32(3), str("bad")),
+			want: clientapi.NewServerMessage(clientapi.ServerMessageSubscriptionError{Value: clientapi.SubscriptionError{
+				RequestId:  &requestID,
+				QuerySetId: clientapi.QuerySetId{Id: 3},
+				Error:      "bad",
+			}}),
+			kind:      MessageKindSubscriptionError,
+			requestID: uint32Ptr(7),
+			queryID:   uint32Ptr(3),
+		},
+		{
+			raw: fixture([]byte{4}, le32(1), le32(3), le32(1), str("users"), le32(1), []byte{0},
+				[]byte{1}, le32(2), le64(0), le64(2), le32(3), []byte("abc"),
+				[]byte{1}, le32(0), le32(0)),
+			want: clientapi.NewServerMessage(clientapi.ServerMessageTransactionUpdate{Value: clientapi.TransactionUpdate{QuerySets: []clientapi.QuerySetUpdate{{
+				QuerySetId: clientapi.QuerySetId{Id: 3},
+				Tables: []clientapi.TableUpdate{{
+					TableName: "users",
+					Rows: []clientapi.TableUpdateRows{clientapi.NewTableUpdateRows(clientapi.TableUpdateRowsPersistentTable{Value: clientapi.PersistentTableRows{
+						Inserts: clientapi.BsatnRowList{
+							SizeHint: clientapi.NewRowSizeHint(clientapi.RowSizeHintRowOffsets{Value: []uint64{0, 2}}),
+							RowsData: []byte("abc"),
+						},
+						Deletes: clientapi.BsatnRowList{
+							SizeHint: clientapi.NewRowSizeHint(clientapi.RowSizeHintRowOffsets{Value: []uint64{}}),
+							RowsData: []byte{},
+						},
+					}})},
+				}},
+			}}}}),
+			kind: MessageKindTransactionUpdate,
+		},
+		{
+			raw: fixture([]byte{5}, le32(8), []byte{1}, str("nope")),
+			want: clientapi.NewServerMessage(clientapi.ServerMessageOneOffQueryResult{Value: clientapi.OneOffQueryResult{
+				RequestId: 8,
+				Result:    clientapi.Result[clientapi.QueryRows, string]{Err: &oneOffErr},
+			}}),
+			kind:      MessageKindOneOffQueryResult,
+			requestID: uint32Ptr(8),
+		},
+		{
+			raw: fixture([]byte{6}, le32(9), le64(1_000_000), []byte{0}, le32(0), le32(0)),
+			want: clientapi.NewServerMessage(clientapi.ServerMessageReducerResult{Value: clientapi.ReducerResult{
+				RequestId: 9,
+				Timestamp: time.UnixMicro(1_000_000).UTC(),
+				Result: clientapi.NewReducerOutcome(clientapi.ReducerOutcomeOk{Value: clientapi.ReducerOk{
+					RetValue:          []byte{},
+					TransactionUpdate: clientapi.TransactionUpdate{QuerySets: []clientapi.QuerySetUpdate{}},
+				}}),
+			}}),
+			kind:      MessageKindReducerResult,
+			requestID: uint32Ptr(9),
+		},
+		{
+			raw: fixture([]byte{7}, []byte{0}, le32(2), []byte{1, 2}, le64(2_000_000), le64(1500), le32(10)),
+			want: clientapi.NewServerMessage(clientapi.ServerMessageProcedureResult{Value: clientapi.ProcedureResult{
+				Status:                     clientapi.NewProcedureStatus(clientapi.ProcedureStatusReturned{Value: []byte{1, 2}}),
+				Timestamp:                  time.UnixMicro(2_000_000).UTC(),
+				TotalHostExecutionDuration: 1500 * time.Microsecond,
+				RequestId:                  10,
+			}}),
+			kind:      MessageKindProcedureResult,
+			requestID: uint32Ptr(10),
+		},
+	}
+}
+
+// fixturePayload returns the payload of the variant held by a ServerMessage.
+func fixturePayload(message clientapi.ServerMessage) any {
+	return reflect.ValueOf(message.Variant()).Field(0).Interface()
+}
+
+func TestServerMessageFixturesCoverEveryTag(t *testing.T) {
+	covered := map[uint8]bool{}
+	for _, fx := range serverMessageFixtures() {
+		covered[fx.raw[0]] = true
+	}
+	for tag := serverMessageInitialConnection; tag <= serverMessageProcedureResult; tag++ {
+		if !covered[tag] {
+			t.Fatalf("missing byte fixture for server message tag %d", tag)
+		}
+	}
+}
+
+func TestServerMessageFixturesRoundTrip(t *testing.T) {
+	for _, fx := range serverMessageFixtures() {
+		t.Run(fx.want.VariantName(), func(t *testing.T) {
+			decoded, err := DecodeServerMessage(fx.raw)
+			if err != nil {
+				t.Fatalf("decode fixture: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, fx.want) {
+				t.Fatalf("decoded message mismatch:\n got %#v\nwant %#v", decoded, fx.want)
+			}
+
+			encoded, err := EncodeServerMessage(fx.want)
+			if err != nil {
+				t.Fatalf("encode expected message: %v", err)
+			}
+			if !bytes.Equal(encoded, fx.raw) {
+				t.Fatalf("encoded message mismatch:\n got %v\nwant %v", encoded, fx.raw)
+			}
+		})
+	}
+}
+
+func TestBSATNMessageDecoderRoutesByRequestAndQuery(t *testing.T) {
+	for _, fx := range serverMessageFixtures() {
+		t.Run(fx.want.VariantName(), func(t *testing.T) {
+			msg, err := BSATNMessageDecoder(fx.raw)
+			if err != nil {
+				t.Fatalf("decode fixture: %v", err)
+			}
+			if msg.Kind != fx.kind {
+				t.Fatalf("unexpected kind: got %s want %s", msg.Kind, fx.kind)
+			}
+			if !reflect.DeepEqual(msg.RequestID, fx.requestID) {
+				t.Fatalf("unexpected request id: got %v want %v", msg.RequestID, fx.requestID)
+			}
+			if !reflect.DeepEqual(msg.QueryID, fx.queryID) {
+				t.Fatalf("unexpected query id: got %v want %v", msg.QueryID, fx.queryID)
+			}
+			if !reflect.DeepEqual(msg.Payload, fixturePayload(fx.want)) {
+				t.Fatalf("payload should be the concrete clientapi value, got %T", msg.Payload)
+			}
+		})
+	}
+}
+
+func TestBSATNMessageDecoderInitialConnectionPayload(t *testing.T) {
+	fx := serverMessageFixtures()[0]
+	msg, err := BSATNMessageDecoder(fx.raw)
+	if err != nil {
+		t.Fatalf("decode initial connection: %v", err)
+	}
+	payload, err := DecodeInitialConnectionPayload(msg.Payload)
+	if err != nil {
+		t.Fatalf("decode initial connection payload: %v", err)
+	}
+	if payload.Token != "tok" || payload.ConnectionID.String() != "afaeadacabaaa9a8a7a6a5a4a3a2a1a0" {
+		t.Fatalf("unexpected initial connection payload: %+v", payload)
+	}
+}
+
+func TestDecodeServerMessageErrors(t *testing.T) {
+	if _, err := DecodeServerMessage([]byte{42}); !errors.Is(err, bsatn.ErrInvalidTag) {
+		t.Fatalf("expected invalid tag error, got: %v", err)
+	}
+	if _, err := DecodeServerMessage([]byte{6, 1, 0}); !errors.Is(err, bsatn.ErrUnexpectedEOF) {
+		t.Fatalf("expected truncated message error, got: %v", err)
+	}
+	raw := append(fixture([]byte{2}, le32(6), le32(3), []byte{bsatn.OptionNoneTag}), 0)
+	if _, err := DecodeServerMessage(raw); !errors.Is(err, bsatn.ErrTrailingBytes) {
+		t.Fatalf("expected trailing bytes error, got: %v", err)
+	}
+}
+
+func TestEncodeServerMessageRejectsEmptySums(t *testing.T) {
+	if _, err := EncodeServerMessage(clientapi.ServerMessage{}); !errors.Is(err, types.ErrEmptySum) {
+		t.Fatalf("expected empty message to fail, got: %v", err)
+	}
+	_, err := EncodeServerMessage(clientapi.NewServerMessage(clientapi.ServerMessageProcedureResult{}))
+	if !errors.Is(err, types.ErrEmptySum) {
+		t.Fatalf("expected empty procedure status to fail, got: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ const (
 type Compression string
 
 const (
-	CompressionNone Compression = "None"
-	CompressionGzip Compression = "Gzip"
+	CompressionNone   Compression = "None"
+	CompressionBrotli Compression = "Brotli"
+	CompressionGzip   Compression = "Gzip"
 )
@@ -3,19 +3,23 @@ package protocol
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
 )
 
 // InitialConnectionPayload is the payload shape for the "initial_connection" server message.
 type InitialConnectionPayload struct {
-	Identity     string `json:"identity"`
-	ConnectionID string `json:"connection_id"`
-	Token        string `json:"token"`
+	Identity     types.Identity     `json:"identity"`
+	ConnectionID types.ConnectionId `json:"connection_id"`
+	Token        string             `json:"token"`
 }
 
 // DecodeInitialConnectionPayload decodes a routed message payload into InitialConnectionPayload.
 //
 // Supported payload input forms:
 // - InitialConnectionPayload
+// - clientapi.InitialConnection
 // - map[string]any
 // - []byte containing JSON
 func DecodeInitialConnectionPayload(payload any) (InitialConnectionPayload, error) {
@@ -24,6 +28,12 @@ func DecodeInitialConnectionPayload(payload any) (InitialConnectionPayload, erro
 		return InitialConnectionPayload{}, fmt.Errorf("initial_connection payload is nil")
 	case InitialConnectionPayload:
 		return validateInitialConnectionPayload(p)
+	case clientapi.InitialConnection:
+		return validateInitialConnectionPayload(InitialConnectionPayload{
+			Identity:     p.Identity,
+			ConnectionID: p.ConnectionId,
+			Token:        p.Token,
+		})
 	case map[string]any:
 		var decoded InitialConnectionPayload
 		raw, err := json.Marshal(p)
@@ -46,10 +56,10 @@ func DecodeInitialConnectionPayload(payload any) (InitialConnectionPayload, erro
 }
 
 func validateInitialConnectionPayload(payload InitialConnectionPayload) (InitialConnectionPayload, error) {
-	if payload.Identity == "" {
+	if payload.Identity.IsZero() {
 		return InitialConnectionPayload{}, fmt.Errorf("initial_connection payload missing identity")
 	}
-	if payload.ConnectionID == "" {
+	if payload.ConnectionID.IsZero() {
 		return InitialConnectionPayload{}, fmt.Errorf("initial_connection payload missing connection_id")
 	}
 	if payload.Token == "" {
@@ -1,11 +1,22 @@
 package protocol
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+const (
+	testIdentityHex     = "c200000000000000000000000000000000000000000000000000000000000102"
+	testConnectionIDHex = "000102030405060708090a0b0c0d0e0f"
+)
 
 func TestDecodeInitialConnectionPayload(t *testing.T) {
+	identity, _ := types.ParseIdentity(testIdentityHex)
+	connectionID, _ := types.ParseConnectionId(testConnectionIDHex)
 	valid := InitialConnectionPayload{
-		Identity:     "id",
-		ConnectionID: "cid",
+		Identity:     identity,
+		ConnectionID: connectionID,
 		Token:        "token",
 	}
 
@@ -21,8 +32,8 @@ func TestDecodeInitialConnectionPayload(t *testing.T) {
 
 	t.Run("map payload", func(t *testing.T) {
 		got, err := DecodeInitialConnectionPayload(map[string]any{
-			"identity":      "id",
-			"connection_id": "cid",
+			"identity":      testIdentityHex,
+			"connection_id": testConnectionIDHex,
 			"token":         "token",
 		})
 		if err != nil {
@@ -34,7 +45,7 @@ func TestDecodeInitialConnectionPayload(t *testing.T) {
 	})
 
 	t.Run("bytes payload", func(t *testing.T) {
-		got, err := DecodeInitialConnectionPayload([]byte(`{"identity":"id","connection_id":"cid","token":"token"}`))
+		got, err := DecodeInitialConnectionPayload([]byte(`{"identity":"` + testIdentityHex + `","connection_id":"` + testConnectionIDHex + `","token":"token"}`))
 		if err != nil {
 			t.Fatalf("decode bytes payload: %v", err)
 		}
@@ -44,9 +55,12 @@ func TestDecodeInitialConnectionPayload(t *testing.T) {
 	})
 
 	t.Run("invalid payload", func(t *testing.T) {
-		if _, err := DecodeInitialConnectionPayload(map[string]any{"identity": "id"}); err == nil {
+		if _, err := DecodeInitialConnectionPayload(map[string]any{"identity": testIdentityHex}); err == nil {
 			t.Fatalf("expected validation error for missing fields")
 		}
+		if _, err := DecodeInitialConnectionPayload(map[string]any{"identity": "id", "connection_id": testConnectionIDHex, "token": "token"}); err == nil {
+			t.Fatalf("expected malformed identity to fail")
+		}
 	})
 }
 
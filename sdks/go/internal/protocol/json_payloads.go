@@ -0,0 +1,45 @@
+package protocol
+
+import (
+	"encoding/json"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+)
+
+// decodeJSONPayload decodes the JSON payload of a known message kind into its
+// concrete clientapi struct. Sum values nested in the payload decode into
+// their typed variants through types.Sum, so the result matches what
+// BSATNMessageDecoder produces for the same message. Sums missing from the
+// payload keep their zero value.
+func decodeJSONPayload(kind MessageKind, raw json.RawMessage) (any, error) {
+	switch kind {
+	case MessageKindInitialConnection:
+		return decodeJSONAs[clientapi.InitialConnection](raw)
+	case MessageKindSubscribeApplied:
+		return decodeJSONAs[clientapi.SubscribeApplied](raw)
+	case MessageKindUnsubscribeApplied:
+		return decodeJSONAs[clientapi.UnsubscribeApplied](raw)
+	case MessageKindSubscriptionError:
+		return decodeJSONAs[clientapi.SubscriptionError](raw)
+	case MessageKindTransactionUpdate:
+		return decodeJSONAs[clientapi.TransactionUpdate](raw)
+	case MessageKindOneOffQueryResult:
+		return decodeJSONAs[clientapi.OneOffQueryResult](raw)
+	case MessageKindReducerResult:
+		return decodeJSONAs[clientapi.ReducerResult](raw)
+	case MessageKindProcedureResult:
+		return decodeJSONAs[clientapi.ProcedureResult](raw)
+	default:
+		var decoded any
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			return nil, err
+		}
+		return decoded, nil
+	}
+}
+
+func decodeJSONAs[T any](raw json.RawMessage) (T, error) {
+	var out T
+	err := json.Unmarshal(raw, &out)
+	return out, err
+}
@@ -23,6 +23,9 @@ type taggedIncomingMessage struct {
 // It accepts two wire shapes:
 // - Legacy envelope: {"kind":"reducer_result","request_id":1,"payload":{...}}
 // - Tagged envelope: {"tag":"ReducerResult","value":{...}}
+//
+// Payloads of known kinds are decoded into their clientapi struct, for example
+// clientapi.ReducerResult; unknown kinds keep the generic JSON value.
 func JSONMessageDecoder(payload []byte) (RoutedMessage, error) {
 	var legacy legacyIncomingMessage
 	if err := json.Unmarshal(payload, &legacy); err == nil && legacy.Kind != "" {
@@ -36,8 +39,8 @@ func JSONMessageDecoder(payload []byte) (RoutedMessage, error) {
 			QueryID:   legacy.QueryID,
 		}
 		if len(legacy.Payload) > 0 {
-			var decoded any
-			if err := json.Unmarshal(legacy.Payload, &decoded); err != nil {
+			decoded, err := decodeJSONPayload(kind, legacy.Payload)
+			if err != nil {
 				return RoutedMessage{}, fmt.Errorf("decode legacy payload: %w", err)
 			}
 			msg.Payload = decoded
@@ -63,13 +66,17 @@ func JSONMessageDecoder(payload []byte) (RoutedMessage, error) {
 
 	msg := RoutedMessage{Kind: kind}
 	if len(tagged.Value) > 0 {
-		var decoded any
-		if err := json.Unmarshal(tagged.Value, &decoded); err != nil {
+		decoded, err := decodeJSONPayload(kind, tagged.Value)
+		if err != nil {
 			return RoutedMessage{}, fmt.Errorf("decode tagged value: %w", err)
 		}
-		msg.Payload = decoded
-		msg.RequestID = extractRequestID(decoded)
-		msg.QueryID = extractQueryID(decoded)
+		if ok {
+			msg = routedFromPayload(kind, decoded)
+		} else {
+			msg.Payload = decoded
+			msg.RequestID = extractRequestID(decoded)
+			msg.QueryID = extractQueryID(decoded)
+		}
 	}
 
 	if err := msg.Validate(); err != nil {
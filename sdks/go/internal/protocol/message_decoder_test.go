@@ -1,6 +1,12 @@
 package protocol
 
-import "testing"
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+)
 
 func TestJSONMessageDecoderLegacyEnvelope(t *testing.T) {
 	msg, err := JSONMessageDecoder([]byte(`{"kind":"reducer_result","request_id":7,"payload":{"ok":true}}`))
@@ -13,6 +19,9 @@ func TestJSONMessageDecoderLegacyEnvelope(t *testing.T) {
 	if msg.RequestID == nil || *msg.RequestID != 7 {
 		t.Fatalf("unexpected request id: %+v", msg.RequestID)
 	}
+	if _, ok := msg.AsReducerResult(); !ok {
+		t.Fatalf("expected clientapi.ReducerResult payload, got %T", msg.Payload)
+	}
 }
 
 func TestJSONMessageDecoderLegacyEnvelopePascalKind(t *testing.T) {
@@ -36,6 +45,10 @@ func TestJSONMessageDecoderTaggedEnvelope(t *testing.T) {
 	if msg.QueryID == nil || *msg.QueryID != 3 {
 		t.Fatalf("unexpected query id: %+v", msg.QueryID)
 	}
+	applied, ok := msg.AsSubscribeApplied()
+	if !ok || applied.RequestId != 9 {
+		t.Fatalf("unexpected subscribe applied payload: %#v", msg.Payload)
+	}
 }
 
 func TestJSONMessageDecoderRejectsUnknownShape(t *testing.T) {
@@ -43,3 +56,62 @@ func TestJSONMessageDecoderRejectsUnknownShape(t *testing.T) {
 		t.Fatalf("expected unknown shape decode to fail")
 	}
 }
+
+func TestJSONMessageDecoderMatchesBSATNPayloads(t *testing.T) {
+	for _, fx := range serverMessageFixtures() {
+		t.Run(fx.want.VariantName(), func(t *testing.T) {
+			raw, err := json.Marshal(fx.want)
+			if err != nil {
+				t.Fatalf("marshal fixture: %v", err)
+			}
+			msg, err := JSONMessageDecoder(raw)
+			if err != nil {
+				t.Fatalf("decode tagged message: %v", err)
+			}
+			if !reflect.DeepEqual(msg.Payload, fixturePayload(fx.want)) {
+				t.Fatalf("payload mismatch:\n got %#v\nwant %#v", msg.Payload, fixturePayload(fx.want))
+			}
+			if !reflect.DeepEqual(msg.RequestID, fx.requestID) || !reflect.DeepEqual(msg.QueryID, fx.queryID) {
+				t.Fatalf("unexpected ids: request=%v query=%v", msg.RequestID, msg.QueryID)
+			}
+		})
+	}
+}
+
+func TestJSONMessageDecoderTypedSums(t *testing.T) {
+	msg, err := JSONMessageDecoder([]byte(`{"tag":"ReducerResult","value":{"request_id":4,"timestamp":"2024-01-02T03:04:05Z","result":{"tag":"Ok","value":{"ret_value":"AQI=","transaction_update":{"query_sets":[{"query_set_id":{"id":2},"tables":[{"table_name":"users","rows":[{"tag":"PersistentTable","value":{"inserts":{"size_hint":{"tag":"FixedSize","value":2},"rows_data":"AQI="},"deletes":{"size_hint":{"tag":"RowOffsets","value":[0]},"rows_data":"AA=="}}}]}]}]}}}}}`))
+	if err != nil {
+		t.Fatalf("decode tagged message: %v", err)
+	}
+	result, ok := msg.AsReducerResult()
+	if !ok {
+		t.Fatalf("expected clientapi.ReducerResult payload, got %T", msg.Payload)
+	}
+	if msg.RequestID == nil || *msg.RequestID != 4 {
+		t.Fatalf("unexpected request id: %+v", msg.RequestID)
+	}
+	reducerOk, isOk := result.Result.Variant().(clientapi.ReducerOutcomeOk)
+	if !isOk {
+		t.Fatalf("expected ReducerOk outcome, got %v", result.Result)
+	}
+	tableRows := reducerOk.Value.TransactionUpdate.QuerySets[0].Tables[0].Rows[0]
+	rows, isRows := tableRows.Variant().(clientapi.TableUpdateRowsPersistentTable)
+	if !isRows {
+		t.Fatalf("expected PersistentTableRows, got %v", tableRows)
+	}
+	if hint := rows.Value.Inserts.SizeHint.Variant(); hint != (clientapi.RowSizeHintFixedSize{Value: 2}) {
+		t.Fatalf("expected typed fixed size hint, got %v", rows.Value.Inserts.SizeHint)
+	}
+	if hint, ok := rows.Value.Deletes.SizeHint.Variant().(clientapi.RowSizeHintRowOffsets); !ok || !reflect.DeepEqual(hint.Value, []uint64{0}) {
+		t.Fatalf("expected typed row offsets, got %v", rows.Value.Deletes.SizeHint)
+	}
+}
+
+func TestJSONMessageDecoderRejectsMistypedPayload(t *testing.T) {
+	if _, err := JSONMessageDecoder([]byte(`{"tag":"ReducerResult","value":{"request_id":"four"}}`)); err == nil {
+		t.Fatalf("expected mistyped payload to fail")
+	}
+	if _, err := JSONMessageDecoder([]byte(`{"tag":"ProcedureResult","value":{"status":{"tag":"Exploded"}}}`)); err == nil {
+		t.Fatalf("expected unknown sum tag to fail")
+	}
+}
@@ -0,0 +1,57 @@
+package protocol
+
+import "github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+
+// payloadAs returns the payload as T, accepting a non-nil *T too.
+func payloadAs[T any](payload any) (T, bool) {
+	switch v := payload.(type) {
+	case T:
+		return v, true
+	case *T:
+		if v != nil {
+			return *v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// AsInitialConnection returns the payload of an initial_connection message.
+func (m RoutedMessage) AsInitialConnection() (clientapi.InitialConnection, bool) {
+	return payloadAs[clientapi.InitialConnection](m.Payload)
+}
+
+// AsSubscribeApplied returns the payload of a subscribe_applied message.
+func (m RoutedMessage) AsSubscribeApplied() (clientapi.SubscribeApplied, bool) {
+	return payloadAs[clientapi.SubscribeApplied](m.Payload)
+}
+
+// AsUnsubscribeApplied returns the payload of an unsubscribe_applied message.
+func (m RoutedMessage) AsUnsubscribeApplied() (clientapi.UnsubscribeApplied, bool) {
+	return payloadAs[clientapi.UnsubscribeApplied](m.Payload)
+}
+
+// AsSubscriptionError returns the payload of a subscription_error message.
+func (m RoutedMessage) AsSubscriptionError() (clientapi.SubscriptionError, bool) {
+	return payloadAs[clientapi.SubscriptionError](m.Payload)
+}
+
+// AsTransactionUpdate returns the payload of a transaction_update message.
+func (m RoutedMessage) AsTransactionUpdate() (clientapi.TransactionUpdate, bool) {
+	return payloadAs[clientapi.TransactionUpdate](m.Payload)
+}
+
+// AsOneOffQueryResult returns the payload of a one_off_query_result message.
+func (m RoutedMessage) AsOneOffQueryResult() (clientapi.OneOffQueryResult, bool) {
+	return payloadAs[clientapi.OneOffQueryResult](m.Payload)
+}
+
+// AsReducerResult returns the payload of a reducer_result message.
+func (m RoutedMessage) AsReducerResult() (clientapi.ReducerResult, bool) {
+	return payloadAs[clientapi.ReducerResult](m.Payload)
+}
+
+// AsProcedureResult returns the payload of a procedure_result message.
+func (m RoutedMessage) AsProcedureResult() (clientapi.ProcedureResult, bool) {
+	return payloadAs[clientapi.ProcedureResult](m.Payload)
+}
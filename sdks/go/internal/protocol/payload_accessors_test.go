@@ -0,0 +1,32 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+)
+
+func TestRoutedMessageAccessors(t *testing.T) {
+	msg := RoutedMessage{Kind: MessageKindProcedureResult, Payload: clientapi.ProcedureResult{RequestId: 3}}
+	if got, ok := msg.AsProcedureResult(); !ok || got.RequestId != 3 {
+		t.Fatalf("unexpected procedure result: %+v ok=%v", got, ok)
+	}
+	if _, ok := msg.AsReducerResult(); ok {
+		t.Fatalf("procedure payload should not read as a reducer result")
+	}
+
+	msg.Payload = &clientapi.OneOffQueryResult{RequestId: 5}
+	if got, ok := msg.AsOneOffQueryResult(); !ok || got.RequestId != 5 {
+		t.Fatalf("pointer payload should be accepted: %+v ok=%v", got, ok)
+	}
+
+	msg.Payload = (*clientapi.OneOffQueryResult)(nil)
+	if _, ok := msg.AsOneOffQueryResult(); ok {
+		t.Fatalf("nil pointer payload should not be accepted")
+	}
+
+	msg.Payload = map[string]any{"request_id": 1.0}
+	if _, ok := msg.AsTransactionUpdate(); ok {
+		t.Fatalf("generic payload should not be accepted")
+	}
+}
@@ -0,0 +1,164 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+)
+
+var (
+	// ErrZeroRowSize reports a FixedSize hint of zero over non-empty row data.
+	ErrZeroRowSize = errors.New("row list: fixed row size is zero")
+	// ErrRowDataNotMultiple reports row data that is not a multiple of the fixed row size.
+	ErrRowDataNotMultiple = errors.New("row list: row data length is not a multiple of the row size")
+	// ErrRowOffsetOutOfRange reports an offset past the end of the row data.
+	ErrRowOffsetOutOfRange = errors.New("row list: row offset out of range")
+	// ErrRowOffsetsNotMonotonic reports an offset smaller than the one before it.
+	ErrRowOffsetsNotMonotonic = errors.New("row list: row offsets are not monotonic")
+	// ErrRowOffsetsIncomplete reports row data not covered by the offsets: a
+	// first offset other than zero, or no offsets over non-empty data.
+	ErrRowOffsetsIncomplete = errors.New("row list: row offsets do not cover the row data")
+	// ErrUnknownRowSizeHint reports a size hint that holds no variant.
+	ErrUnknownRowSizeHint = errors.New("row list: unknown row size hint")
+)
+
+// RowListError describes why a BsatnRowList could not be split into rows.
+// Row is the index of the offending row, or -1 when the error is not tied to one.
+type RowListError struct {
+	Row    int
+	Offset uint64
+	Len    int
+	Err    error
+}
+
+func (e *RowListError) Error() string {
+	if e.Row < 0 {
+		return fmt.Sprintf("%v (data length %d)", e.Err, e.Len)
+	}
+	return fmt.Sprintf("%v: row %d at offset %d (data length %d)", e.Err, e.Row, e.Offset, e.Len)
+}
+
+func (e *RowListError) Unwrap() error {
+	return e.Err
+}
+
+// RowIterator walks the rows of a BsatnRowList. Rows are sub-slices of
+// RowsData and must not be modified or retained past the lifetime of the
+// message buffer.
+type RowIterator struct {
+	data    []byte
+	size    int
+	offsets []uint64
+	count   int
+	next    int
+}
+
+// NewRowIterator validates the size hint of list against its row data and
+// returns an iterator over its rows. Validation happens up front, so Next
+// never fails.
+func NewRowIterator(list clientapi.BsatnRowList) (*RowIterator, error) {
+	it := &RowIterator{data: list.RowsData}
+	switch hint := list.SizeHint.Variant().(type) {
+	case clientapi.RowSizeHintFixedSize:
+		size := hint.Value
+		if size == 0 {
+			if len(it.data) != 0 {
+				return nil, &RowListError{Row: -1, Len: len(it.data), Err: ErrZeroRowSize}
+			}
+			return it, nil
+		}
+		if len(it.data)%int(size) != 0 {
+			return nil, &RowListError{Row: -1, Len: len(it.data), Err: fmt.Errorf("%w (row size %d)", ErrRowDataNotMultiple, size)}
+		}
+		it.size = int(size)
+		it.count = len(it.data) / it.size
+	case clientapi.RowSizeHintRowOffsets:
+		if err := validateRowOffsets(hint.Value, len(it.data)); err != nil {
+			return nil, err
+		}
+		it.offsets = hint.Value
+		it.count = len(hint.Value)
+	default:
+		return nil, &RowListError{Row: -1, Len: len(it.data), Err: ErrUnknownRowSizeHint}
+	}
+	return it, nil
+}
+
+func validateRowOffsets(offsets []uint64, dataLen int) error {
+	if len(offsets) == 0 {
+		if dataLen != 0 {
+			return &RowListError{Row: -1, Len: dataLen, Err: ErrRowOffsetsIncomplete}
+		}
+		return nil
+	}
+	if offsets[0] != 0 {
+		return &RowListError{Row: 0, Offset: offsets[0], Len: dataLen, Err: ErrRowOffsetsIncomplete}
+	}
+	for i, offset := range offsets {
+		if offset > uint64(dataLen) {
+			return &RowListError{Row: i, Offset: offset, Len: dataLen, Err: ErrRowOffsetOutOfRange}
+		}
+		if i > 0 && offset < offsets[i-1] {
+			return &RowListError{Row: i, Offset: offset, Len: dataLen, Err: ErrRowOffsetsNotMonotonic}
+		}
+	}
+	return nil
+}
+
+// Len returns the total number of rows in the list.
+func (it *RowIterator) Len() int {
+	return it.count
+}
+
+// Next returns the next row, or false once every row has been returned.
+func (it *RowIterator) Next() ([]byte, bool) {
+	if it.next >= it.count {
+		return nil, false
+	}
+	i := it.next
+	it.next++
+
+	if it.offsets == nil {
+		start := i * it.size
+		end := start + it.size
+		return it.data[start:end:end], true
+	}
+	start := it.offsets[i]
+	end := uint64(len(it.data))
+	if i+1 < len(it.offsets) {
+		end = it.offsets[i+1]
+	}
+	return it.data[start:end:end], true
+}
+
+// SplitRows returns every row of list. The rows alias list.RowsData.
+func SplitRows(list clientapi.BsatnRowList) ([][]byte, error) {
+	it, err := NewRowIterator(list)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]byte, 0, it.Len())
+	for row, ok := it.Next(); ok; row, ok = it.Next() {
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// NewRowList packs rows into a BsatnRowList with a RowOffsets size hint.
+// The row data is copied.
+func NewRowList(rows [][]byte) clientapi.BsatnRowList {
+	offsets := make([]uint64, len(rows))
+	var data []byte
+	for i, row := range rows {
+		offsets[i] = uint64(len(data))
+		data = append(data, row...)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return clientapi.BsatnRowList{
+		SizeHint: clientapi.NewRowSizeHint(clientapi.RowSizeHintRowOffsets{Value: offsets}),
+		RowsData: data,
+	}
+}
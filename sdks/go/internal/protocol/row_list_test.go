@@ -0,0 +1,142 @@
+package protocol
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+)
+
+func fixedSizeRows(size uint16, data []byte) clientapi.BsatnRowList {
+	return clientapi.BsatnRowList{
+		SizeHint: clientapi.NewRowSizeHint(clientapi.RowSizeHintFixedSize{Value: size}),
+		RowsData: data,
+	}
+}
+
+func offsetRows(offsets []uint64, data []byte) clientapi.BsatnRowList {
+	return clientapi.BsatnRowList{
+		SizeHint: clientapi.NewRowSizeHint(clientapi.RowSizeHintRowOffsets{Value: offsets}),
+		RowsData: data,
+	}
+}
+
+func TestSplitRowsFixedSize(t *testing.T) {
+	rows, err := SplitRows(fixedSizeRows(2, []byte{1, 2, 3, 4, 5, 6}))
+	if err != nil {
+		t.Fatalf("split rows: %v", err)
+	}
+	want := [][]byte{{1, 2}, {3, 4}, {5, 6}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+}
+
+func TestSplitRowsOffsets(t *testing.T) {
+	rows, err := SplitRows(offsetRows([]uint64{0, 1, 1, 4}, []byte("abcdefg")))
+	if err != nil {
+		t.Fatalf("split rows: %v", err)
+	}
+	want := [][]byte{[]byte("a"), {}, []byte("bcd"), []byte("efg")}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("unexpected rows: %q", rows)
+	}
+}
+
+func TestNewRowListRoundTrips(t *testing.T) {
+	want := [][]byte{[]byte("ab"), {}, []byte("c")}
+	list := NewRowList(want)
+	if !reflect.DeepEqual(list, offsetRows([]uint64{0, 2, 2}, []byte("abc"))) {
+		t.Fatalf("unexpected row list: %+v", list)
+	}
+	rows, err := SplitRows(list)
+	if err != nil {
+		t.Fatalf("split rows: %v", err)
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("unexpected rows: %q", rows)
+	}
+	if empty := NewRowList(nil); empty.RowsData == nil || len(empty.SizeHint.Variant().(clientapi.RowSizeHintRowOffsets).Value) != 0 {
+		t.Fatalf("unexpected empty row list: %+v", empty)
+	}
+}
+
+func TestSplitRowsEmpty(t *testing.T) {
+	for _, list := range []clientapi.BsatnRowList{
+		fixedSizeRows(0, nil),
+		fixedSizeRows(4, []byte{}),
+		offsetRows(nil, nil),
+		offsetRows([]uint64{}, []byte{}),
+	} {
+		rows, err := SplitRows(list)
+		if err != nil {
+			t.Fatalf("split empty rows (%v): %v", list.SizeHint, err)
+		}
+		if len(rows) != 0 {
+			t.Fatalf("expected no rows, got %d", len(rows))
+		}
+	}
+}
+
+func TestRowIteratorIsZeroCopy(t *testing.T) {
+	data := []byte{1, 2, 3, 4}
+	it, err := NewRowIterator(offsetRows([]uint64{0, 2}, data))
+	if err != nil {
+		t.Fatalf("new row iterator: %v", err)
+	}
+	if it.Len() != 2 {
+		t.Fatalf("unexpected row count: %d", it.Len())
+	}
+
+	first, ok := it.Next()
+	if !ok {
+		t.Fatalf("expected first row")
+	}
+	data[0] = 9
+	if first[0] != 9 {
+		t.Fatalf("rows should alias the row data")
+	}
+	if cap(first) != len(first) {
+		t.Fatalf("row capacity should be clipped, got cap %d len %d", cap(first), len(first))
+	}
+
+	if _, ok := it.Next(); !ok {
+		t.Fatalf("expected second row")
+	}
+	if _, ok := it.Next(); ok {
+		t.Fatalf("expected iterator to be exhausted")
+	}
+}
+
+func TestNewRowIteratorRejectsMalformedLists(t *testing.T) {
+	cases := []struct {
+		name string
+		list clientapi.BsatnRowList
+		want error
+		row  int
+	}{
+		{name: "zero size", list: fixedSizeRows(0, []byte{1}), want: ErrZeroRowSize, row: -1},
+		{name: "not multiple", list: fixedSizeRows(4, []byte{1, 2, 3, 4, 5}), want: ErrRowDataNotMultiple, row: -1},
+		{name: "out of range", list: offsetRows([]uint64{0, 5}, []byte{1, 2}), want: ErrRowOffsetOutOfRange, row: 1},
+		{name: "not monotonic", list: offsetRows([]uint64{0, 2, 1}, []byte{1, 2, 3}), want: ErrRowOffsetsNotMonotonic, row: 2},
+		{name: "leading gap", list: offsetRows([]uint64{1}, []byte{1, 2}), want: ErrRowOffsetsIncomplete, row: 0},
+		{name: "no offsets", list: offsetRows(nil, []byte{1}), want: ErrRowOffsetsIncomplete, row: -1},
+		{name: "empty hint", list: clientapi.BsatnRowList{RowsData: []byte{1}}, want: ErrUnknownRowSizeHint, row: -1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewRowIterator(tc.list)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got: %v", tc.want, err)
+			}
+			var listErr *RowListError
+			if !errors.As(err, &listErr) {
+				t.Fatalf("expected RowListError, got %T", err)
+			}
+			if listErr.Row != tc.row {
+				t.Fatalf("unexpected row index: got %d want %d", listErr.Row, tc.row)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Package satsjson implements SATS-JSON, the JSON form of SpacetimeDB values
+// used by the HTTP API and the text websocket protocol.
+//
+// SATS-JSON is not self-describing, so a value can only be read back with its
+// type at hand. Marshal and Unmarshal take that type from the Go value via
+// reflection, using the same mapping as the bsatn package:
+//
+//   - bool, sized integers and floats map to JSON numbers and booleans
+//   - string maps to a JSON string and []byte to a hex string
+//   - slices and arrays map to JSON arrays
+//   - structs map to products, written as objects keyed by field name
+//   - pointers map to Option, written as {"some": v} or {"none": []}
+//   - time.Time and time.Duration map to Timestamp and TimeDuration
+//   - big.Int maps to a JSON number
+//
+// Field names come from the `json` struct tag when present, as in generated
+// bindings, and from the Go field name otherwise. Fields tagged `bsatn:"-"`
+// are skipped, so that a struct describes the same product in both formats.
+// Types that implement Marshaler or Unmarshaler take over their own encoding,
+// which is how sum types and the SDK's builtin types are represented.
+//
+// The format helpers (EncodeProduct, DecodeProduct, EncodeVariant,
+// DecodeVariant and friends) are shared with the schema-driven encoder for
+// dynamic values in the types package. Readers accept every form the server
+// produces: products as arrays or objects, sums keyed by variant name or tag
+// number, and integers as numbers or strings.
+package satsjson
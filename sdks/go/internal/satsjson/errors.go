@@ -0,0 +1,74 @@
+package satsjson
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrSyntax is returned when the input is not the JSON shape the type expects.
+	ErrSyntax = errors.New("satsjson: unexpected json")
+	// ErrInvalidTag is returned when a sum value names an unknown variant.
+	ErrInvalidTag = errors.New("satsjson: invalid sum tag")
+	// ErrMissingField is returned when a product object lacks one of its fields.
+	ErrMissingField = errors.New("satsjson: missing product field")
+	// ErrIntegerOverflow is returned when a number does not fit the target type.
+	ErrIntegerOverflow = errors.New("satsjson: integer overflows target type")
+	// ErrNonFinite is returned when encoding NaN or an infinity, which JSON cannot represent.
+	ErrNonFinite = errors.New("satsjson: non-finite float")
+)
+
+// DecodeError records where in the value decoding failed, as a path of field
+// names, variant names and array indexes.
+type DecodeError struct {
+	Path []string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if len(e.Path) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (at %s)", e.Err, strings.Join(e.Path, "."))
+}
+
+func (e *DecodeError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// WithPath prefixes the location of err with segment, so that errors from
+// nested values report their full path.
+func WithPath(segment string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var de *DecodeError
+	if errors.As(err, &de) {
+		return &DecodeError{Path: append([]string{segment}, de.Path...), Err: de.Err}
+	}
+	return &DecodeError{Path: []string{segment}, Err: err}
+}
+
+// UnsupportedTypeError is returned when a Go type has no SATS-JSON mapping.
+type UnsupportedTypeError struct {
+	Type   reflect.Type
+	Reason string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Reason == "" {
+		return fmt.Sprintf("satsjson: unsupported type %s", e.Type)
+	}
+	return fmt.Sprintf("satsjson: unsupported type %s: %s", e.Type, e.Reason)
+}
@@ -0,0 +1,388 @@
+package satsjson
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Unit is the encoding of the empty product, and so of every variant that
+// carries no data.
+var Unit = []byte("[]")
+
+// Option variant names, in tag order.
+var optionNames = []string{"some", "none"}
+
+// EncodeProduct writes a product from its already encoded elements. Products
+// whose elements are all named become objects; any unnamed element makes the
+// whole product an array, in element order.
+func EncodeProduct(names []string, elements [][]byte) []byte {
+	if len(elements) == 0 {
+		return Unit
+	}
+	named := len(names) == len(elements)
+	for _, name := range names {
+		if name == "" {
+			named = false
+		}
+	}
+
+	var buf bytes.Buffer
+	if named {
+		buf.WriteByte('{')
+	} else {
+		buf.WriteByte('[')
+	}
+	for i, element := range elements {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if named {
+			buf.Write(EncodeString(names[i]))
+			buf.WriteByte(':')
+		}
+		buf.Write(element)
+	}
+	if named {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+	return buf.Bytes()
+}
+
+// DecodeProduct splits a product into its encoded elements, in the order of
+// names. Both the array and the object form are accepted.
+func DecodeProduct(data []byte, names []string) ([]json.RawMessage, error) {
+	switch firstByte(data) {
+	case '[':
+		var elements []json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		if len(elements) != len(names) {
+			return nil, fmt.Errorf("%w: product has %d elements, got %d", ErrSyntax, len(names), len(elements))
+		}
+		return elements, nil
+	case '{':
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		elements := make([]json.RawMessage, len(names))
+		for i, name := range names {
+			element, ok := fields[name]
+			if !ok || name == "" {
+				return nil, fmt.Errorf("%w %q", ErrMissingField, name)
+			}
+			elements[i] = element
+			delete(fields, name)
+		}
+		for name := range fields {
+			return nil, fmt.Errorf("%w: unknown product field %q", ErrSyntax, name)
+		}
+		return elements, nil
+	default:
+		return nil, fmt.Errorf("%w: expected product, got %s", ErrSyntax, preview(data))
+	}
+}
+
+// DecodeUnit checks that data is an empty product.
+func DecodeUnit(data []byte) error {
+	_, err := DecodeProduct(data, nil)
+	return err
+}
+
+// EncodeVariant writes a sum value. Named variants become a single-key object
+// such as {"some": 5}; unnamed ones become a [tag, payload] pair.
+func EncodeVariant(name string, tag uint8, payload []byte) []byte {
+	if name != "" {
+		return EncodeProduct([]string{name}, [][]byte{payload})
+	}
+	return EncodeProduct(nil, [][]byte{[]byte(strconv.Itoa(int(tag))), payload})
+}
+
+// DecodeVariant returns the tag and encoded payload of a sum value whose
+// variants are named by names. The variant may be given by name or by tag
+// number, either as the single key of an object or as a [tag, payload] pair.
+func DecodeVariant(data []byte, names []string) (uint8, json.RawMessage, error) {
+	switch firstByte(data) {
+	case '{':
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return 0, nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		if len(fields) != 1 {
+			return 0, nil, fmt.Errorf("%w: sum value must have exactly one key, got %d", ErrSyntax, len(fields))
+		}
+		for key, payload := range fields {
+			tag, err := variantTag(key, names)
+			return tag, payload, err
+		}
+	case '[':
+		var pair []json.RawMessage
+		if err := json.Unmarshal(data, &pair); err != nil {
+			return 0, nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		if len(pair) != 2 {
+			return 0, nil, fmt.Errorf("%w: sum value must be a [tag, payload] pair", ErrSyntax)
+		}
+		tag, err := ParseUint(pair[0], 8)
+		if err != nil {
+			return 0, nil, err
+		}
+		if int(tag) >= len(names) {
+			return 0, nil, fmt.Errorf("%w: %d", ErrInvalidTag, tag)
+		}
+		return uint8(tag), pair[1], nil
+	}
+	return 0, nil, fmt.Errorf("%w: expected sum, got %s", ErrSyntax, preview(data))
+}
+
+func variantTag(key string, names []string) (uint8, error) {
+	for i, name := range names {
+		if name != "" && name == key {
+			return uint8(i), nil
+		}
+	}
+	if tag, err := strconv.ParseUint(key, 10, 8); err == nil && int(tag) < len(names) {
+		return uint8(tag), nil
+	}
+	return 0, fmt.Errorf("%w %q", ErrInvalidTag, key)
+}
+
+// EncodeOption writes {"some": payload}, or {"none": []} if payload is nil.
+func EncodeOption(payload []byte) []byte {
+	if payload == nil {
+		return EncodeVariant(optionNames[1], 1, Unit)
+	}
+	return EncodeVariant(optionNames[0], 0, payload)
+}
+
+// DecodeOption returns the payload of an option and whether it is present.
+func DecodeOption(data []byte) (json.RawMessage, bool, error) {
+	tag, payload, err := DecodeVariant(data, optionNames)
+	if err != nil {
+		return nil, false, err
+	}
+	if tag == 1 {
+		return nil, false, WithPath("none", DecodeUnit(payload))
+	}
+	return payload, true, nil
+}
+
+// EncodeSpecial wraps inner in the single-field product SATS uses for its
+// builtin types, for example {"__identity__": "0x..."}.
+func EncodeSpecial(tag string, inner []byte) []byte {
+	return EncodeProduct([]string{tag}, [][]byte{inner})
+}
+
+// DecodeSpecial unwraps a builtin type written by EncodeSpecial and reports
+// whether data was wrapped. Data that is not a product, such as a bare hex
+// string, is returned unchanged so that the caller can accept it as a
+// shorthand.
+func DecodeSpecial(data []byte, tag string) (json.RawMessage, bool, error) {
+	switch firstByte(data) {
+	case '{', '[':
+		elements, err := DecodeProduct(data, []string{tag})
+		if err != nil {
+			return nil, false, err
+		}
+		return elements[0], true, nil
+	}
+	return data, false, nil
+}
+
+// EncodeBytes writes b as a hex string, the SATS-JSON form of Array<U8>.
+func EncodeBytes(b []byte) []byte {
+	out := make([]byte, 0, 2*len(b)+2)
+	out = append(out, '"')
+	out = hex.AppendEncode(out, b)
+	return append(out, '"')
+}
+
+// DecodeBytes reads a hex string, with or without a 0x prefix, or an array of
+// byte values.
+func DecodeBytes(data []byte) ([]byte, error) {
+	switch firstByte(data) {
+	case '"':
+		s, err := DecodeString(data)
+		if err != nil {
+			return nil, err
+		}
+		out, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		return out, nil
+	case '[':
+		var elements []json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		out := make([]byte, len(elements))
+		for i, element := range elements {
+			b, err := ParseUint(element, 8)
+			if err != nil {
+				return nil, WithPath(strconv.Itoa(i), err)
+			}
+			out[i] = byte(b)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w: expected bytes, got %s", ErrSyntax, preview(data))
+}
+
+// DecodeArray splits a JSON array into its encoded elements.
+func DecodeArray(data []byte) ([]json.RawMessage, error) {
+	if firstByte(data) != '[' {
+		return nil, fmt.Errorf("%w: expected array, got %s", ErrSyntax, preview(data))
+	}
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+	return elements, nil
+}
+
+// EncodeString writes s as a JSON string.
+func EncodeString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// DecodeString reads a JSON string.
+func DecodeString(data []byte) (string, error) {
+	if firstByte(data) != '"' {
+		return "", fmt.Errorf("%w: expected string, got %s", ErrSyntax, preview(data))
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+	return s, nil
+}
+
+// DecodeBool reads a JSON boolean.
+func DecodeBool(data []byte) (bool, error) {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: expected bool, got %s", ErrSyntax, preview(data))
+}
+
+// EncodeFloat writes f as a JSON number. bits is 32 or 64 and selects the
+// shortest representation that round-trips at that precision.
+func EncodeFloat(f float64, bits int) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("%w: %v", ErrNonFinite, f)
+	}
+	if bits == 32 {
+		return json.Marshal(float32(f))
+	}
+	return json.Marshal(f)
+}
+
+// DecodeFloat reads a JSON number as a float of the given bit size.
+func DecodeFloat(data []byte, bits int) (float64, error) {
+	text := string(bytes.TrimSpace(data))
+	if !isNumber(text) {
+		return 0, fmt.Errorf("%w: expected number, got %s", ErrSyntax, preview(data))
+	}
+	f, err := strconv.ParseFloat(text, bits)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+	return f, nil
+}
+
+// IntegerText returns the decimal or 0x-prefixed text of an integer given as
+// a JSON number or a JSON string. Wide integers are often quoted by clients
+// whose JSON numbers are doubles.
+func IntegerText(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if firstByte(data) == '"' {
+		return DecodeString(data)
+	}
+	text := string(data)
+	if !isNumber(text) || strings.ContainsAny(text, ".eE") {
+		return "", fmt.Errorf("%w: expected integer, got %s", ErrSyntax, preview(data))
+	}
+	return text, nil
+}
+
+// ParseInt reads a signed integer that must fit in bits.
+func ParseInt(data []byte, bits int) (int64, error) {
+	text, err := IntegerText(data)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(text, IntegerBase(text), bits)
+	if err != nil {
+		return 0, integerError(text, err)
+	}
+	return v, nil
+}
+
+// ParseUint reads an unsigned integer that must fit in bits.
+func ParseUint(data []byte, bits int) (uint64, error) {
+	text, err := IntegerText(data)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(text, IntegerBase(text), bits)
+	if err != nil {
+		return 0, integerError(text, err)
+	}
+	return v, nil
+}
+
+// IntegerBase returns the strconv base for text from IntegerText: 0, which
+// honors the 0x prefix, for hex text and 10 otherwise, so that decimal text
+// with leading zeros is not read as octal.
+func IntegerBase(text string) int {
+	if strings.HasPrefix(strings.TrimPrefix(text, "-"), "0x") {
+		return 0
+	}
+	return 10
+}
+
+func integerError(text string, err error) error {
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		return fmt.Errorf("%w: %s", ErrIntegerOverflow, text)
+	}
+	return fmt.Errorf("%w: invalid integer %q", ErrSyntax, text)
+}
+
+func isNumber(text string) bool {
+	return text != "" && json.Valid([]byte(text)) && (text[0] == '-' || (text[0] >= '0' && text[0] <= '9'))
+}
+
+func firstByte(data []byte) byte {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) == 0 {
+		return 0
+	}
+	return data[0]
+}
+
+func preview(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return "nothing"
+	}
+	if len(data) > 32 {
+		return string(data[:32]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,209 @@
+package satsjson
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Marshaler is implemented by types that write their own SATS-JSON encoding.
+type Marshaler interface {
+	MarshalSATSJSON() ([]byte, error)
+}
+
+// Unmarshaler is implemented by types that read their own SATS-JSON encoding.
+type Unmarshaler interface {
+	UnmarshalSATSJSON(data []byte) error
+}
+
+// Element names SATS uses for the builtin Timestamp and TimeDuration products.
+const (
+	TimestampTag    = "__timestamp_micros_since_unix_epoch__"
+	TimeDurationTag = "__time_duration_micros__"
+)
+
+var (
+	marshalerType   = reflect.TypeFor[Marshaler]()
+	unmarshalerType = reflect.TypeFor[Unmarshaler]()
+	timeType        = reflect.TypeFor[time.Time]()
+	durationType    = reflect.TypeFor[time.Duration]()
+	bigIntType      = reflect.TypeFor[big.Int]()
+	bigIntPtrType   = reflect.TypeFor[*big.Int]()
+)
+
+type structField struct {
+	index int
+	name  string
+	// plain marks a *big.Int with a bsatn width tag, which is an integer
+	// rather than an Option.
+	plain bool
+}
+
+var structFieldsCache sync.Map // map[reflect.Type][]structField
+
+// Marshal returns the SATS-JSON encoding of v.
+//
+// A top-level pointer is dereferenced rather than encoded as an Option.
+func Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil, &UnsupportedTypeError{Type: nil, Reason: "cannot encode nil"}
+	}
+	if rv.Kind() == reflect.Pointer && rv.Type() != bigIntPtrType {
+		if rv.IsNil() {
+			return nil, &UnsupportedTypeError{Type: rv.Type(), Reason: "cannot encode nil pointer"}
+		}
+		rv = rv.Elem()
+	}
+	return encodeValue(rv, false)
+}
+
+func encodeValue(v reflect.Value, plain bool) ([]byte, error) {
+	t := v.Type()
+
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface {
+		if t.Implements(marshalerType) {
+			return v.Interface().(Marshaler).MarshalSATSJSON()
+		}
+		if reflect.PointerTo(t).Implements(marshalerType) {
+			if v.CanAddr() {
+				return v.Addr().Interface().(Marshaler).MarshalSATSJSON()
+			}
+			p := reflect.New(t)
+			p.Elem().Set(v)
+			return p.Interface().(Marshaler).MarshalSATSJSON()
+		}
+	}
+
+	switch t {
+	case timeType:
+		micros := v.Interface().(time.Time).UnixMicro()
+		return EncodeSpecial(TimestampTag, []byte(strconv.FormatInt(micros, 10))), nil
+	case durationType:
+		micros := v.Interface().(time.Duration).Microseconds()
+		return EncodeSpecial(TimeDurationTag, []byte(strconv.FormatInt(micros, 10))), nil
+	case bigIntType:
+		bi := v.Interface().(big.Int)
+		return []byte(bi.String()), nil
+	case bigIntPtrType:
+		if plain {
+			if v.IsNil() {
+				return nil, &UnsupportedTypeError{Type: t, Reason: "cannot encode nil big.Int"}
+			}
+			return []byte(v.Interface().(*big.Int).String()), nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return []byte(strconv.FormatBool(v.Bool())), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []byte(strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []byte(strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32:
+		return EncodeFloat(v.Float(), 32)
+	case reflect.Float64:
+		return EncodeFloat(v.Float(), 64)
+	case reflect.String:
+		return EncodeString(v.String()), nil
+	case reflect.Slice, reflect.Array:
+		if isPlainByteType(t.Elem()) {
+			if t.Kind() == reflect.Slice {
+				return EncodeBytes(v.Bytes()), nil
+			}
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return EncodeBytes(b), nil
+		}
+		elements := make([][]byte, v.Len())
+		for i := range elements {
+			element, err := encodeValue(v.Index(i), false)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		// Arrays share the JSON array form of unnamed products.
+		return EncodeProduct(nil, elements), nil
+	case reflect.Struct:
+		fields, err := structFields(t)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(fields))
+		elements := make([][]byte, len(fields))
+		for i, field := range fields {
+			element, err := encodeValue(v.Field(field.index), field.plain)
+			if err != nil {
+				return nil, err
+			}
+			names[i] = field.name
+			elements[i] = element
+		}
+		return EncodeProduct(names, elements), nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return EncodeOption(nil), nil
+		}
+		payload, err := encodeValue(v.Elem(), plain)
+		if err != nil {
+			return nil, err
+		}
+		return EncodeOption(payload), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, &UnsupportedTypeError{Type: t, Reason: "cannot encode nil interface"}
+		}
+		if m, ok := v.Interface().(Marshaler); ok {
+			return m.MarshalSATSJSON()
+		}
+		return nil, &UnsupportedTypeError{Type: t, Reason: "interface values must implement Marshaler"}
+	default:
+		return nil, &UnsupportedTypeError{Type: t}
+	}
+}
+
+// isPlainByteType reports whether t is a byte that has no encoding of its
+// own, so that []t is written as a hex string.
+func isPlainByteType(t reflect.Type) bool {
+	return t.Kind() == reflect.Uint8 &&
+		!t.Implements(marshalerType) &&
+		!reflect.PointerTo(t).Implements(unmarshalerType)
+}
+
+func structFields(t reflect.Type) ([]structField, error) {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.([]structField), nil
+	}
+
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() || sf.Tag.Get("bsatn") == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			name = sf.Name
+		}
+		fields = append(fields, structField{index: i, name: name, plain: hasWidthTag(sf.Tag.Get("bsatn"))})
+	}
+
+	actual, _ := structFieldsCache.LoadOrStore(t, fields)
+	return actual.([]structField), nil
+}
+
+// hasWidthTag reports whether a `bsatn` tag selects a big.Int width.
+func hasWidthTag(tag string) bool {
+	for _, part := range strings.Split(tag, ",") {
+		switch strings.TrimSpace(part) {
+		case "u128", "i128", "u256", "i256":
+			return true
+		}
+	}
+	return false
+}
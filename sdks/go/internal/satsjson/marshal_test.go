@@ -0,0 +1,226 @@
+package satsjson
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type testShape struct {
+	Tag    uint8
+	Radius float32
+	Width  uint32
+}
+
+// MarshalSATSJSON encodes testShape as a two-variant sum: Circle(f32) | Rect(u32).
+func (s testShape) MarshalSATSJSON() ([]byte, error) {
+	switch s.Tag {
+	case 0:
+		radius, err := EncodeFloat(float64(s.Radius), 32)
+		if err != nil {
+			return nil, err
+		}
+		return EncodeVariant("Circle", 0, radius), nil
+	default:
+		return EncodeVariant("Rect", 1, []byte(strconv.FormatUint(uint64(s.Width), 10))), nil
+	}
+}
+
+func (s *testShape) UnmarshalSATSJSON(data []byte) error {
+	tag, payload, err := DecodeVariant(data, []string{"Circle", "Rect"})
+	if err != nil {
+		return err
+	}
+	*s = testShape{Tag: tag}
+	switch tag {
+	case 0:
+		var radius float64
+		radius, err = DecodeFloat(payload, 32)
+		s.Radius = float32(radius)
+	case 1:
+		var width uint64
+		width, err = ParseUint(payload, 32)
+		s.Width = uint32(width)
+	}
+	return err
+}
+
+type testInner struct {
+	Name  string `json:"name"`
+	Flags []bool `json:"flags"`
+}
+
+type testRow struct {
+	ID       uint64 `json:"id"`
+	Score    int32  `json:"score"`
+	Ratio    float64
+	Alive    bool
+	Name     string
+	Payload  []byte
+	Tags     []string
+	Pair     [2]int16
+	Hash     [4]byte
+	Inner    testInner
+	Maybe    *uint16
+	Missing  *string
+	Shape    testShape
+	Shapes   []testShape
+	At       time.Time
+	Elapsed  time.Duration
+	Balance  big.Int
+	Supply   *big.Int `bsatn:"u256"`
+	Skipped  string   `bsatn:"-"`
+	internal int
+}
+
+func TestMarshalRow(t *testing.T) {
+	maybe := uint16(7)
+	row := testRow{
+		ID:      1 << 60,
+		Score:   -5,
+		Ratio:   0.25,
+		Alive:   true,
+		Name:    "a<b>",
+		Payload: []byte{0xde, 0xad},
+		Tags:    []string{"x", "y"},
+		Pair:    [2]int16{-1, 2},
+		Hash:    [4]byte{1, 2, 3, 4},
+		Inner:   testInner{Name: "inner", Flags: []bool{true, false}},
+		Maybe:   &maybe,
+		Shape:   testShape{Tag: 1, Width: 3},
+		Shapes:  []testShape{{Tag: 0, Radius: 1.5}},
+		At:      time.UnixMicro(1_700_000_000_000_001).UTC(),
+		Elapsed: 1500 * time.Microsecond,
+		Supply:  big.NewInt(12),
+		Skipped: "not encoded",
+	}
+	row.Balance.SetString("340282366920938463463374607431768211455", 10)
+
+	got, err := Marshal(&row)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"id":1152921504606846976,"score":-5,"Ratio":0.25,"Alive":true,"Name":"a<b>",` +
+		`"Payload":"dead","Tags":["x","y"],"Pair":[-1,2],"Hash":"01020304",` +
+		`"Inner":{"name":"inner","flags":[true,false]},"Maybe":{"some":7},"Missing":{"none":[]},` +
+		`"Shape":{"Rect":3},"Shapes":[{"Circle":1.5}],` +
+		`"At":{"__timestamp_micros_since_unix_epoch__":1700000000000001},` +
+		`"Elapsed":{"__time_duration_micros__":1500},` +
+		`"Balance":340282366920938463463374607431768211455,"Supply":12}`
+	if string(got) != want {
+		t.Fatalf("unexpected encoding:\n got %s\nwant %s", got, want)
+	}
+
+	var decoded testRow
+	if err := Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	row.Skipped = ""
+	if !reflect.DeepEqual(decoded, row) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", decoded, row)
+	}
+}
+
+func TestUnmarshalAcceptsAlternateForms(t *testing.T) {
+	// Products as arrays, sums keyed by tag number, quoted integers, byte
+	// arrays as numbers and timestamps as RFC 3339 strings.
+	data := `[
+		"18446744073709551615", 3, 1, false, "n",
+		[1, 2], [], [0, 0], "0x0a0b0c0d",
+		["inner", []], {"0": 9}, {"1": []},
+		[1, 4], [],
+		"2024-01-02T03:04:05.000006Z", "2s",
+		"0xff", 1
+	]`
+	var row testRow
+	if err := Unmarshal([]byte(data), &row); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if row.ID != math.MaxUint64 || row.Ratio != 1 || row.Hash != [4]byte{10, 11, 12, 13} {
+		t.Fatalf("unexpected scalars: %+v", row)
+	}
+	if !reflect.DeepEqual(row.Payload, []byte{1, 2}) || row.Maybe == nil || *row.Maybe != 9 || row.Missing != nil {
+		t.Fatalf("unexpected bytes or options: %+v", row)
+	}
+	if row.Shape != (testShape{Tag: 1, Width: 4}) {
+		t.Fatalf("unexpected sum: %+v", row.Shape)
+	}
+	if !row.At.Equal(time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)) || row.Elapsed != 2*time.Second {
+		t.Fatalf("unexpected time values: %v %v", row.At, row.Elapsed)
+	}
+	if row.Balance.Int64() != 255 || row.Supply.Int64() != 1 {
+		t.Fatalf("unexpected big ints: %v %v", &row.Balance, row.Supply)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		into any
+		want error
+	}{
+		{"overflow", `300`, new(uint8), ErrIntegerOverflow},
+		{"negative unsigned", `-1`, new(uint32), ErrSyntax},
+		{"fraction", `1.5`, new(int64), ErrSyntax},
+		{"wrong kind", `"x"`, new(bool), ErrSyntax},
+		{"bad hex", `"zz"`, new([]byte), ErrSyntax},
+		{"missing field", `{"name":"x"}`, new(testInner), ErrMissingField},
+		{"unknown field", `{"name":"x","flags":[],"extra":1}`, new(testInner), ErrSyntax},
+		{"short product", `["x"]`, new(testInner), ErrSyntax},
+		{"bad option", `{"maybe":1}`, new(*int8), ErrInvalidTag},
+		{"two keys", `{"some":1,"none":[]}`, new(*int8), ErrSyntax},
+		{"none payload", `{"none":5}`, new(*int8), ErrSyntax},
+		{"array length", `[1]`, new([2]int16), ErrSyntax},
+		{"invalid json", `{`, new(testInner), ErrSyntax},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Unmarshal([]byte(tc.data), tc.into); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+
+	var unsupported *UnsupportedTypeError
+	if err := Unmarshal([]byte(`{}`), new(map[string]int)); !errors.As(err, &unsupported) {
+		t.Fatalf("expected unsupported type error, got %v", err)
+	}
+	if err := Unmarshal([]byte(`1`), testInner{}); !errors.As(err, &unsupported) {
+		t.Fatalf("expected non-pointer target to fail, got %v", err)
+	}
+}
+
+func TestUnmarshalErrorPath(t *testing.T) {
+	var row struct {
+		Items []testInner `json:"items"`
+	}
+	err := Unmarshal([]byte(`{"items":[{"name":"a","flags":[]},{"name":"b","flags":[1]}]}`), &row)
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("expected DecodeError, got %v", err)
+	}
+	if want := []string{"items", "1", "flags", "0"}; !reflect.DeepEqual(de.Path, want) {
+		t.Fatalf("unexpected path %v, want %v", de.Path, want)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	if _, err := Marshal(math.NaN()); !errors.Is(err, ErrNonFinite) {
+		t.Fatalf("expected NaN to fail, got %v", err)
+	}
+	var unsupported *UnsupportedTypeError
+	if _, err := Marshal(map[string]int{}); !errors.As(err, &unsupported) {
+		t.Fatalf("expected map to be unsupported, got %v", err)
+	}
+	if _, err := Marshal(nil); !errors.As(err, &unsupported) {
+		t.Fatalf("expected nil to be unsupported, got %v", err)
+	}
+	if _, err := Marshal(struct{ V any }{}); !errors.As(err, &unsupported) {
+		t.Fatalf("expected nil interface to be unsupported, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package satsjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Unmarshal decodes the SATS-JSON value in data into the value pointed to by v.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &UnsupportedTypeError{Type: reflect.TypeOf(v), Reason: "decode target must be a non-nil pointer"}
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("%w: invalid json", ErrSyntax)
+	}
+	if rv.Type() == bigIntPtrType {
+		return decodeBigInt(data, rv.Elem())
+	}
+	return decodeValue(data, rv.Elem(), false)
+}
+
+func decodeValue(data []byte, v reflect.Value, plain bool) error {
+	t := v.Type()
+
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(unmarshalerType) {
+		return v.Addr().Interface().(Unmarshaler).UnmarshalSATSJSON(data)
+	}
+
+	switch t {
+	case timeType:
+		micros, err := decodeMicros(data, TimestampTag, parseTimeMicros)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(time.UnixMicro(micros).UTC()))
+		return nil
+	case durationType:
+		micros, err := decodeMicros(data, TimeDurationTag, parseDurationMicros)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(time.Duration(micros) * time.Microsecond))
+		return nil
+	case bigIntType:
+		return decodeBigInt(data, v)
+	case bigIntPtrType:
+		if plain {
+			v.Set(reflect.New(bigIntType))
+			return decodeBigInt(data, v.Elem())
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := DecodeBool(data)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := ParseInt(data, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := ParseUint(data, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := DecodeFloat(data, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		s, err := DecodeString(data)
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Slice:
+		if isPlainByteType(t.Elem()) {
+			b, err := DecodeBytes(data)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		elements, err := DecodeArray(data)
+		if err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(t, len(elements), len(elements))
+		for i, element := range elements {
+			if err := decodeValue(element, slice.Index(i), false); err != nil {
+				return WithPath(strconv.Itoa(i), err)
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		if isPlainByteType(t.Elem()) {
+			b, err := DecodeBytes(data)
+			if err != nil {
+				return err
+			}
+			if len(b) != t.Len() {
+				return fmt.Errorf("%w: expected %d bytes, got %d", ErrSyntax, t.Len(), len(b))
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		elements, err := DecodeArray(data)
+		if err != nil {
+			return err
+		}
+		if len(elements) != t.Len() {
+			return fmt.Errorf("%w: expected %d elements, got %d", ErrSyntax, t.Len(), len(elements))
+		}
+		for i, element := range elements {
+			if err := decodeValue(element, v.Index(i), false); err != nil {
+				return WithPath(strconv.Itoa(i), err)
+			}
+		}
+	case reflect.Struct:
+		fields, err := structFields(t)
+		if err != nil {
+			return err
+		}
+		names := make([]string, len(fields))
+		for i, field := range fields {
+			names[i] = field.name
+		}
+		elements, err := DecodeProduct(data, names)
+		if err != nil {
+			return err
+		}
+		for i, field := range fields {
+			if err := decodeValue(elements[i], v.Field(field.index), field.plain); err != nil {
+				return WithPath(field.name, err)
+			}
+		}
+	case reflect.Pointer:
+		payload, present, err := DecodeOption(data)
+		if err != nil {
+			return err
+		}
+		if !present {
+			v.SetZero()
+			return nil
+		}
+		elem := reflect.New(t.Elem())
+		if err := decodeValue(payload, elem.Elem(), plain); err != nil {
+			return WithPath("some", err)
+		}
+		v.Set(elem)
+	default:
+		return &UnsupportedTypeError{Type: t}
+	}
+	return nil
+}
+
+// decodeMicros reads the i64 inside a Timestamp or TimeDuration product.
+// A bare string is parsed with parse, which accepts the human-readable form.
+func decodeMicros(data []byte, tag string, parse func(string) (int64, error)) (int64, error) {
+	inner, wrapped, err := DecodeSpecial(data, tag)
+	if err != nil {
+		return 0, err
+	}
+	if !wrapped && firstByte(inner) == '"' {
+		s, err := DecodeString(inner)
+		if err != nil {
+			return 0, err
+		}
+		return parse(s)
+	}
+	return ParseInt(inner, 64)
+}
+
+func parseTimeMicros(s string) (int64, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+	return t.UnixMicro(), nil
+}
+
+func parseDurationMicros(s string) (int64, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+	return d.Microseconds(), nil
+}
+
+func decodeBigInt(data []byte, v reflect.Value) error {
+	text, err := IntegerText(data)
+	if err != nil {
+		return err
+	}
+	bi, ok := new(big.Int).SetString(text, IntegerBase(text))
+	if !ok {
+		return fmt.Errorf("%w: invalid integer %q", ErrSyntax, text)
+	}
+	v.Set(reflect.ValueOf(*bi))
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package sdkerror holds the error type shared by the SDK packages. The
+// connection package re-exports it as connection.Error.
+package sdkerror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Code classifies SDK/runtime failures for retry and diagnostics policies.
+type Code string
+
+const (
+	InvalidArgument  Code = "invalid_argument"
+	ConnectionClosed Code = "connection_closed"
+	EncodeFailed     Code = "encode_failed"
+	SendFailed       Code = "send_failed"
+	UnexpectedKind   Code = "unexpected_message_kind"
+	Timeout          Code = "timeout"
+	QueueFull        Code = "send_queue_full"
+	CallFailed       Code = "call_failed"
+)
+
+// Error is the canonical error wrapper for SDK operations.
+type Error struct {
+	Code Code
+	Op   string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Op == "" {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+	return fmt.Sprintf("%s (%s): %v", e.Code, e.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// Wrap returns err wrapped in an Error, or nil if err is nil.
+func Wrap(code Code, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Op: op, Err: err}
+}
+
+// NewUnexpectedKind reports a result of the wrong message kind.
+func NewUnexpectedKind(op string, got, want string) error {
+	return &Error{
+		Code: UnexpectedKind,
+		Op:   op,
+		Err:  fmt.Errorf("unexpected result kind: got %q want %q", got, want),
+	}
+}
+
+// Expired reports an expired deadline as a Timeout error and leaves
+// cancellation as the bare context error.
+func Expired(op string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Wrap(Timeout, op, err)
+	}
+	return err
+}
+
+// IsCode reports whether err (or any wrapped error) is an SDK Error with the given code.
+func IsCode(err error, code Code) bool {
+	var sdkErr *Error
+	for errors.As(err, &sdkErr) {
+		if sdkErr.Code == code {
+			return true
+		}
+		err = sdkErr.Err
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package spacetimedb
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/cache"
+	"github.com/clockworklabs/spacetimedb/sdks/go/connection"
+)
+
+// ReconnectPolicy configures automatic reconnection after an established
+// connection is lost. Zero fields take the values from DefaultReconnectPolicy,
+// except Jitter and MaxElapsed where zero disables the feature.
+type ReconnectPolicy struct {
+	// InitialBackoff is the delay before the first reconnect attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each failed attempt. Values below 1 use the default.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction in either direction, in [0, 1].
+	Jitter float64
+	// MaxElapsed gives up once this much time has passed since the connection
+	// was lost. Zero retries until Disconnect is called.
+	MaxElapsed time.Duration
+}
+
+// DefaultReconnectPolicy returns the policy used by WithReconnect when fields are left zero.
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		MaxElapsed:     5 * time.Minute,
+	}
+}
+
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	defaults := DefaultReconnectPolicy()
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaults.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaults.MaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaults.Multiplier
+	}
+	p.Jitter = min(max(p.Jitter, 0), 1)
+	if p.MaxElapsed < 0 {
+		p.MaxElapsed = 0
+	}
+	return p
+}
+
+// Backoff returns the delay before the given 1-based attempt. random is a
+// sample from [0, 1) used to apply jitter.
+func (p ReconnectPolicy) Backoff(attempt int, random float64) time.Duration {
+	p = p.withDefaults()
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt && delay < float64(p.MaxBackoff); i++ {
+		delay *= p.Multiplier
+	}
+	delay = min(delay, float64(p.MaxBackoff))
+	delay *= 1 + p.Jitter*(2*random-1)
+	return time.Duration(delay)
+}
+
+// ReconnectAttempt describes a reconnect attempt that is about to start.
+type ReconnectAttempt struct {
+	// Attempt is 1 for the first attempt after the connection was lost.
+	Attempt int
+	// Delay is how long the SDK waits before dialing.
+	Delay time.Duration
+	// Err is the error that closed the connection or failed the previous attempt.
+	Err error
+}
+
+type ReconnectingCallback func(*DbConnection, ReconnectAttempt)
+type ReconnectedCallback func(*DbConnection)
+
+// reconnect redials the database after the connection was lost with cause.
+// It runs until a dial succeeds, the policy's MaxElapsed is exceeded or the
+// connection is closed with Disconnect.
+func (b *DbConnectionBuilder) reconnect(dbConn *DbConnection, cause error) {
+	policy := b.reconnectPolicy.withDefaults()
+	ctx := dbConn.reconnectCtx
+	lostAt := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		delay := policy.Backoff(attempt, rand.Float64())
+		if policy.MaxElapsed > 0 && time.Since(lostAt)+delay > policy.MaxElapsed {
+			b.notifyDisconnect(dbConn, fmt.Errorf("reconnect gave up after %d attempts: %w", attempt-1, cause))
+			return
+		}
+		if b.onReconnecting != nil {
+			b.onReconnecting(dbConn, ReconnectAttempt{Attempt: attempt, Delay: delay, Err: cause})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			b.notifyDisconnect(dbConn, cause)
+			return
+		case <-timer.C:
+		}
+
+		// Reuse the token the server issued so the reconnected client keeps its identity.
+		inner := dbConn.redial.Clone()
+		if info, ok := dbConn.ConnectionInfo(); ok && info.Token != "" {
+			inner.WithToken(info.Token)
+		}
+		conn, err := inner.Build(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				b.notifyDisconnect(dbConn, cause)
+				return
+			}
+			cause = err
+			continue
+		}
+		if dbConn.closing.Load() {
+			// Disconnect raced with the dial; the new connection's read loop
+			// reports the disconnect once it is closed.
+			_ = conn.Disconnect()
+			return
+		}
+		dbConn.subscriptions.resubscribe(dbConn, conn)
+		if b.onReconnected != nil {
+			b.onReconnected(dbConn)
+		}
+		return
+	}
+}
+
+func (b *DbConnectionBuilder) notifyDisconnect(dbConn *DbConnection, err error) {
+	// Closing on request is not an error worth keeping as LastError.
+	if dbConn.closing.Load() {
+		dbConn.setState(connection.StateClosed, nil)
+	} else {
+		dbConn.setState(connection.StateClosed, err)
+	}
+	dbConn.subscriptions.failAll(err)
+	if b.onDisconnect != nil {
+		b.onDisconnect(dbConn, err)
+	}
+}
+
+func (c *DbConnection) setState(state connection.State, err error) {
+	if c.state != nil {
+		c.state.Transition(state, err)
+	}
+}
+
+// willReconnect reports whether losing the connection now starts a reconnect.
+func (c *DbConnection) willReconnect() bool {
+	return c.reconnects && !c.closing.Load()
+}
+
+// current returns the live transport connection, which changes after a reconnect.
+func (c *DbConnection) current() *connection.Connection {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.conn
+}
+
+func (c *DbConnection) setConn(conn *connection.Connection) (first bool) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	first = c.conn == nil
+	c.conn = conn
+	return first
+}
+
+func newDbConnection(onStateChange StateChangeCallback) *DbConnection {
+	ctx, cancel := context.WithCancel(context.Background())
+	dbConn := &DbConnection{db: cache.NewStore(), reconnectCtx: ctx, stopReconnect: cancel}
+	dbConn.subscriptions.store = dbConn.db
+	var onChange func(connection.StateChange)
+	if onStateChange != nil {
+		onChange = func(change connection.StateChange) { onStateChange(dbConn, change) }
+	}
+	dbConn.state = connection.NewStateMachine(onChange)
+	return dbConn
+}
@@ -0,0 +1,362 @@
+package spacetimedb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/connection"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	policy := ReconnectPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 300 * time.Millisecond,
+		3: 900 * time.Millisecond,
+		4: time.Second,
+		9: time.Second,
+	} {
+		if got := policy.Backoff(attempt, 0.5); got != want {
+			t.Fatalf("attempt %d: got %v want %v", attempt, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	if got := policy.Backoff(1, 0); got != 50*time.Millisecond {
+		t.Fatalf("unexpected low jitter bound: %v", got)
+	}
+	if got := policy.Backoff(1, 0.999); got < 149*time.Millisecond || got > 150*time.Millisecond {
+		t.Fatalf("unexpected high jitter bound: %v", got)
+	}
+
+	defaults := DefaultReconnectPolicy()
+	if got := (ReconnectPolicy{}).Backoff(1, 0.5); got != defaults.InitialBackoff {
+		t.Fatalf("expected zero policy to use defaults, got %v", got)
+	}
+}
+
+func TestReconnectAfterServerDropsConnection(t *testing.T) {
+	server := newReconnectTestServer(t)
+	defer server.Close()
+
+	var reconnecting []ReconnectAttempt
+	var mu sync.Mutex
+	reconnected := make(chan struct{}, 1)
+	disconnected := make(chan error, 1)
+	var connects atomic.Int32
+
+	builder := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithReconnect(ReconnectPolicy{InitialBackoff: 5 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}).
+		OnConnect(func(*DbConnection) { connects.Add(1) }).
+		OnReconnecting(func(_ *DbConnection, attempt ReconnectAttempt) {
+			mu.Lock()
+			reconnecting = append(reconnecting, attempt)
+			mu.Unlock()
+		}).
+		OnReconnected(func(*DbConnection) { reconnected <- struct{}{} }).
+		OnDisconnect(func(_ *DbConnection, err error) { disconnected <- err })
+	conn, err := builder.Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	first := conn.Raw()
+
+	select {
+	case <-reconnected:
+	case err := <-disconnected:
+		t.Fatalf("unexpected disconnect: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for reconnect")
+	}
+
+	if conn.Raw() == first || !conn.IsActive() {
+		t.Fatalf("expected a new active connection after reconnect")
+	}
+	if got := server.authHeader(1); got != "Bearer minted-token" {
+		t.Fatalf("expected reconnect to reuse the issued token, got %q", got)
+	}
+	mu.Lock()
+	if len(reconnecting) != 1 || reconnecting[0].Attempt != 1 || reconnecting[0].Err == nil {
+		t.Fatalf("unexpected reconnecting events: %+v", reconnecting)
+	}
+	mu.Unlock()
+	if connects.Load() != 1 {
+		t.Fatalf("expected OnConnect to fire once, got %d", connects.Load())
+	}
+
+	if err := conn.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for disconnect")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if got := server.connections.Load(); got != 2 {
+		t.Fatalf("expected no reconnect after Disconnect, got %d connections", got)
+	}
+
+	// The issued token belongs to conn; a new Build from the builder starts
+	// without one.
+	other, err := builder.Build(context.Background())
+	if err != nil {
+		t.Fatalf("second build: %v", err)
+	}
+	defer other.Disconnect()
+	if got := server.authHeader(2); got != "" {
+		t.Fatalf("expected the builder to keep no token, got %q", got)
+	}
+}
+
+func TestStateTracksReconnect(t *testing.T) {
+	server := newReconnectTestServer(t)
+	defer server.Close()
+
+	changes := make(chan connection.StateChange, 16)
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithReconnect(ReconnectPolicy{InitialBackoff: 5 * time.Millisecond}).
+		OnStateChange(func(_ *DbConnection, change connection.StateChange) { changes <- change }).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	want := []connection.State{
+		connection.StateConnected,
+		connection.StateIdentified,
+		connection.StateReconnecting,
+		connection.StateConnected,
+		connection.StateIdentified,
+	}
+	for i, state := range want {
+		select {
+		case change := <-changes:
+			if change.To != state {
+				t.Fatalf("transition %d: got %s want %s", i, change.To, state)
+			}
+			if state == connection.StateReconnecting && change.Err == nil {
+				t.Fatalf("expected the reconnecting transition to carry the disconnect error")
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for transition to %s", state)
+		}
+	}
+	if conn.LastError() == nil {
+		t.Fatalf("expected LastError to keep the error that started the reconnect")
+	}
+
+	if err := conn.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+	if conn.State() != connection.StateClosed {
+		t.Fatalf("expected closed state after Disconnect, got %s", conn.State())
+	}
+}
+
+func TestShutdownClosesWithoutReconnecting(t *testing.T) {
+	server := newScriptedTestServer(t, func(_ int32, conn *websocket.Conn) {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithReconnect(ReconnectPolicy{InitialBackoff: 5 * time.Millisecond}).
+		OnReconnecting(func(*DbConnection, ReconnectAttempt) { t.Errorf("unexpected reconnect after Shutdown") }).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := conn.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if conn.State() != connection.StateClosed || conn.LastError() != nil {
+		t.Fatalf("expected a clean close, got %s / %v", conn.State(), conn.LastError())
+	}
+	time.Sleep(30 * time.Millisecond)
+	if got := server.connections.Load(); got != 1 {
+		t.Fatalf("expected no reconnect after Shutdown, got %d connections", got)
+	}
+}
+
+func TestReconnectGivesUpAfterMaxElapsed(t *testing.T) {
+	server := newReconnectTestServer(t)
+
+	var attempts atomic.Int32
+	disconnected := make(chan error, 1)
+	_, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithReconnect(ReconnectPolicy{
+			InitialBackoff: 10 * time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+			MaxElapsed:     50 * time.Millisecond,
+		}).
+		OnReconnecting(func(*DbConnection, ReconnectAttempt) { attempts.Add(1) }).
+		OnDisconnect(func(_ *DbConnection, err error) { disconnected <- err }).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	// Refuse every redial.
+	server.Close()
+
+	select {
+	case err := <-disconnected:
+		if err == nil {
+			t.Fatalf("expected a disconnect error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for reconnect to give up")
+	}
+	if got := attempts.Load(); got < 1 || got > 5 {
+		t.Fatalf("unexpected number of reconnect attempts: %d", got)
+	}
+}
+
+func TestDisconnectStopsPendingReconnect(t *testing.T) {
+	server := newReconnectTestServer(t)
+	defer server.Close()
+
+	reconnecting := make(chan struct{}, 1)
+	disconnected := make(chan error, 1)
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithReconnect(ReconnectPolicy{InitialBackoff: time.Hour}).
+		OnReconnecting(func(*DbConnection, ReconnectAttempt) { reconnecting <- struct{}{} }).
+		OnDisconnect(func(_ *DbConnection, err error) { disconnected <- err }).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	select {
+	case <-reconnecting:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for reconnect to start")
+	}
+	if err := conn.Disconnect(); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+	select {
+	case err := <-disconnected:
+		if err == nil || errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the original disconnect cause, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for disconnect")
+	}
+}
+
+type reconnectTestServer struct {
+	*httptest.Server
+	connections atomic.Int32
+
+	mu          sync.Mutex
+	authHeaders []string
+}
+
+// newReconnectTestServer starts a server that sends an initial connection
+// message and then drops the first connection. Later connections stay open.
+func newReconnectTestServer(t *testing.T) *reconnectTestServer {
+	t.Helper()
+	return newScriptedTestServer(t, func(n int32, conn *websocket.Conn) {
+		if n == 1 {
+			// Give the client time to process the initial connection message.
+			time.Sleep(20 * time.Millisecond)
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// newScriptedTestServer starts a server that sends an initial connection
+// message on every websocket and then runs script with the 1-based number
+// of the connection. The connection is closed when script returns.
+func newScriptedTestServer(t *testing.T, script func(n int32, conn *websocket.Conn)) *reconnectTestServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("local listen unavailable in this environment: %v", err)
+	}
+
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{protocol.WSSubprotocolV2},
+		CheckOrigin:  func(r *http.Request) bool { return true },
+	}
+	s := &reconnectTestServer{}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.authHeaders = append(s.authHeaders, r.Header.Get("Authorization"))
+		s.mu.Unlock()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		n := s.connections.Add(1)
+		writeServerMessage(t, conn, clientapi.ServerMessageInitialConnection{
+			Value: clientapi.InitialConnection{Identity: types.Identity{1}, ConnectionId: types.ConnectionId{2}, Token: "minted-token"},
+		})
+		script(n, conn)
+	}))
+	s.Listener = listener
+	s.Start()
+	return s
+}
+
+// writeServerMessage sends an uncompressed BSATN server message.
+func writeServerMessage(t *testing.T, conn *websocket.Conn, variant clientapi.ServerMessageVariant) {
+	encoded, err := protocol.EncodeServerMessage(clientapi.NewServerMessage(variant))
+	if err != nil {
+		t.Errorf("encode server message: %v", err)
+		return
+	}
+	_ = conn.WriteMessage(websocket.BinaryMessage, append([]byte{0}, encoded...))
+}
+
+func (s *reconnectTestServer) authHeader(i int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i >= len(s.authHeaders) {
+		return ""
+	}
+	return s.authHeaders[i]
+}
@@ -0,0 +1,538 @@
+package spacetimedb
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/cache"
+	"github.com/clockworklabs/spacetimedb/sdks/go/connection"
+	"github.com/clockworklabs/spacetimedb/sdks/go/events"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+// subscriptionTracker remembers the query sets subscribed through a
+// DbConnection so they can be re-sent after a reconnect.
+//
+// Subscribe returns a handle that stays valid across reconnects; the query
+// set id the server knows changes every time the subscription is re-sent.
+// Messages passed to subscription callbacks carry the handle as QueryID.
+//
+// The tracker also keeps store in step with the subscribed rows. Each
+// message is applied as one transaction before any callback runs.
+type subscriptionTracker struct {
+	mu         sync.Mutex
+	nextHandle uint32
+	byHandle   map[uint32]*trackedSubscription
+	byServerID map[uint32]*trackedSubscription
+	store      *cache.Store
+}
+
+type trackedSubscription struct {
+	handle       uint32
+	serverID     uint32
+	queries      []string
+	callback     SubscriptionCallback
+	applied      bool
+	unsubscribed bool
+	// rows holds the multiset of rows matched by the subscription, by table.
+	rows map[string]map[string]int
+}
+
+// subscribe sends a new subscription on conn and starts tracking it. The
+// handle and query set id are recorded before the subscription is sent, so
+// t.mu is not held while it waits for space in the send queue.
+func (t *subscriptionTracker) subscribe(ctx context.Context, dbConn *DbConnection, conn *connection.Connection, queries []string, callback SubscriptionCallback) (uint32, error) {
+	t.mu.Lock()
+	if t.byHandle == nil {
+		t.byHandle = map[uint32]*trackedSubscription{}
+		t.byServerID = map[uint32]*trackedSubscription{}
+	}
+	sub := &trackedSubscription{handle: t.nextHandle, serverID: conn.NextQueryID(), queries: slices.Clone(queries), callback: callback}
+	t.nextHandle++
+	t.byHandle[sub.handle] = sub
+	t.byServerID[sub.serverID] = sub
+	serverID := sub.serverID
+	t.mu.Unlock()
+
+	if err := conn.SubscribeQueryID(ctx, serverID, queries, t.route(dbConn, sub)); err != nil {
+		t.abandon(sub, serverID)
+		return sub.handle, err
+	}
+	return sub.handle, nil
+}
+
+// abandon forgets sub after sending it as serverID failed, unless it is
+// no longer tracked under serverID.
+func (t *subscriptionTracker) abandon(sub *trackedSubscription, serverID uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.byServerID[serverID] == sub {
+		t.forget(sub)
+	}
+}
+
+// unsubscribe stops tracking the subscription with the given handle and
+// asks the server to drop it. Unknown handles are passed through as query set ids.
+func (t *subscriptionTracker) unsubscribe(ctx context.Context, conn *connection.Connection, handle uint32) (uint32, error) {
+	t.mu.Lock()
+	queryID := handle
+	if sub, ok := t.byHandle[handle]; ok {
+		sub.unsubscribed = true
+		queryID = sub.serverID
+	}
+	t.mu.Unlock()
+	return conn.UnsubscribeContext(ctx, queryID)
+}
+
+// resubscribe re-sends every applied subscription on a new connection. As
+// in subscribe, the new query set ids are recorded before anything is sent.
+func (t *subscriptionTracker) resubscribe(dbConn *DbConnection, conn *connection.Connection) {
+	var (
+		failed  []func()
+		pending []*trackedSubscription
+	)
+	t.mu.Lock()
+	t.byServerID = map[uint32]*trackedSubscription{}
+	for handle, sub := range t.byHandle {
+		if sub.unsubscribed {
+			delete(t.byHandle, handle)
+			failed = append(failed, t.drop(sub, events.KindUnsubscribeApplied))
+			continue
+		}
+		sub.serverID = conn.NextQueryID()
+		t.byServerID[sub.serverID] = sub
+		pending = append(pending, sub)
+	}
+	t.mu.Unlock()
+	runAll(failed)
+
+	for _, sub := range pending {
+		t.mu.Lock()
+		serverID := sub.serverID
+		t.mu.Unlock()
+		if err := resend(dbConn, conn, serverID, sub.queries, t.route(dbConn, sub)); err != nil {
+			t.mu.Lock()
+			var notify func()
+			if t.byServerID[serverID] == sub {
+				t.forget(sub)
+				notify = chain(t.drop(sub, events.KindSubscriptionError), sub.fail(err))
+			}
+			t.mu.Unlock()
+			if notify != nil {
+				notify()
+			}
+		}
+	}
+}
+
+// resend sends a re-sent subscription. The user did not ask for it, so a
+// fail-fast queue that is full is waited on rather than failing it.
+func resend(dbConn *DbConnection, conn *connection.Connection, serverID uint32, queries []string, route SubscriptionCallback) error {
+	ctx := dbConn.reconnectCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for {
+		err := conn.SubscribeQueryID(ctx, serverID, queries, route)
+		if !connection.IsCode(err, connection.ErrorQueueFull) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(resendRetryDelay):
+		}
+	}
+}
+
+// resendRetryDelay is how long resend waits for a full send queue to drain.
+const resendRetryDelay = 5 * time.Millisecond
+
+// failAll fails and forgets every tracked subscription.
+func (t *subscriptionTracker) failAll(err error) {
+	t.mu.Lock()
+	failed := make([]func(), 0, len(t.byHandle))
+	for _, sub := range t.byHandle {
+		failed = append(failed, sub.fail(err))
+	}
+	t.byHandle = nil
+	t.byServerID = nil
+	t.mu.Unlock()
+	runAll(failed)
+}
+
+// route returns the connection-level callback for one attempt at sub.
+func (t *subscriptionTracker) route(dbConn *DbConnection, sub *trackedSubscription) SubscriptionCallback {
+	return func(message protocol.RoutedMessage, err error) {
+		t.mu.Lock()
+		notify := t.handle(dbConn, sub, message, err)
+		t.mu.Unlock()
+		if notify != nil {
+			notify()
+		}
+	}
+}
+
+func (t *subscriptionTracker) handle(dbConn *DbConnection, sub *trackedSubscription, message protocol.RoutedMessage, err error) func() {
+	if err != nil {
+		// A zero message kind means the connection was lost. Applied
+		// subscriptions survive it when a reconnect is coming.
+		if message.Kind == "" && sub.applied && !sub.unsubscribed && dbConn.willReconnect() {
+			return nil
+		}
+		if message.Kind == "" {
+			t.forget(sub)
+			// Rows of a subscription that will not be re-sent leave the
+			// cache, unless the connection closed for good with it.
+			if sub.unsubscribed {
+				return chain(t.drop(sub, events.KindUnsubscribeApplied), sub.notify(message, err))
+			}
+			if dbConn.willReconnect() {
+				return chain(t.drop(sub, events.KindSubscriptionError), sub.notify(message, err))
+			}
+		}
+		if message.Kind == protocol.MessageKindSubscriptionError {
+			return chain(t.drop(sub, events.KindSubscriptionError), sub.notify(message, err))
+		}
+		if message.Kind == protocol.MessageKindUnsubscribeApplied {
+			// The unsubscribe never reached the server.
+			sub.unsubscribed = false
+		}
+		return sub.notify(message, err)
+	}
+
+	switch message.Kind {
+	case protocol.MessageKindSubscribeApplied:
+		applied, _ := message.AsSubscribeApplied()
+		rows, err := subscribedRows(applied.Rows)
+		if err != nil {
+			return sub.notify(message, err)
+		}
+		if !sub.applied {
+			sub.applied = true
+			sub.rows = rows
+			dispatch := t.applyMutations(sub.event(events.KindSubscribeApplied), sub.rowMutations(true))
+			return chain(dispatch, sub.notify(message, nil))
+		}
+		// A re-sent subscription: report only what changed while disconnected.
+		tables, mutations := sub.reconcile(rows)
+		if len(tables) == 0 {
+			return nil
+		}
+		dispatch := t.applyMutations(sub.event(events.KindSubscribeApplied), mutations)
+		return chain(dispatch, sub.notify(protocol.RoutedMessage{
+			Kind: protocol.MessageKindTransactionUpdate,
+			Payload: clientapi.TransactionUpdate{QuerySets: []clientapi.QuerySetUpdate{{
+				QuerySetId: applied.QuerySetId,
+				Tables:     tables,
+			}}},
+		}, nil))
+	case protocol.MessageKindSubscriptionError:
+		t.forget(sub)
+		return chain(t.drop(sub, events.KindSubscriptionError), sub.notify(message, nil))
+	case protocol.MessageKindUnsubscribeApplied:
+		t.forget(sub)
+		return chain(t.drop(sub, events.KindUnsubscribeApplied), sub.notify(message, nil))
+	}
+	return sub.notify(message, nil)
+}
+
+// drop removes the rows of a subscription that ended from the store and
+// returns the call that runs the row callbacks.
+func (t *subscriptionTracker) drop(sub *trackedSubscription, kind events.Kind) func() {
+	if !sub.applied {
+		return nil
+	}
+	dispatch := t.applyMutations(sub.event(kind), sub.rowMutations(false))
+	sub.rows = nil
+	return dispatch
+}
+
+// applyMutations applies mutations to the store as one transaction and
+// returns the call that runs the row callbacks for it. The call must run
+// after t.mu is released, as callbacks may subscribe or unsubscribe.
+func (t *subscriptionTracker) applyMutations(event events.Event, mutations []types.TableMutation) func() {
+	if t.store == nil || len(mutations) == 0 {
+		return nil
+	}
+	changes := t.store.ApplyTransaction(types.Transaction{Tables: mutations})
+	if len(changes) == 0 {
+		return nil
+	}
+	store := t.store
+	return func() { store.Dispatch(event, changes) }
+}
+
+func (t *subscriptionTracker) forget(sub *trackedSubscription) {
+	if t.byHandle[sub.handle] == sub {
+		delete(t.byHandle, sub.handle)
+	}
+	if t.byServerID[sub.serverID] == sub {
+		delete(t.byServerID, sub.serverID)
+	}
+}
+
+// observeMessage is the connection's kind route for transaction updates.
+func (t *subscriptionTracker) observeMessage(message protocol.RoutedMessage) {
+	if update, ok := message.AsTransactionUpdate(); ok {
+		t.observe(events.Event{Kind: events.KindTransaction}, update)
+	}
+}
+
+// observeLateReducerResult is the connection's kind route for reducer results
+// that arrive after their call expired or was cancelled. The reducer name is
+// not known by then, so the event carries only the request id.
+func (t *subscriptionTracker) observeLateReducerResult(message protocol.RoutedMessage) {
+	t.observeReducerResult("", nil)(message, nil)
+}
+
+// observeReducerResult wraps a reducer callback so that the transaction
+// update carried by a successful result reaches the subscriptions.
+func (t *subscriptionTracker) observeReducerResult(reducer string, callback ReducerResultCallback) ReducerResultCallback {
+	return func(message protocol.RoutedMessage, err error) {
+		if result, ok := message.AsReducerResult(); ok && err == nil {
+			if outcome, ok := result.Result.Variant().(clientapi.ReducerOutcomeOk); ok {
+				t.observe(events.Event{Kind: events.KindReducer, Reducer: &events.ReducerEvent{
+					Name:      reducer,
+					RequestID: result.RequestId,
+					Timestamp: result.Timestamp,
+				}}, outcome.Value.TransactionUpdate)
+			}
+		}
+		if callback != nil {
+			callback(message, err)
+		}
+	}
+}
+
+// observe applies a transaction update to the tracked row sets and the store,
+// runs the row callbacks with event and then passes each query set's part of
+// the update to that subscription's callback.
+func (t *subscriptionTracker) observe(event events.Event, update clientapi.TransactionUpdate) {
+	var (
+		notify    []func()
+		mutations []types.TableMutation
+	)
+	t.mu.Lock()
+	for _, querySet := range update.QuerySets {
+		sub, ok := t.byServerID[querySet.QuerySetId.Id]
+		if !ok {
+			continue
+		}
+		applied, err := sub.apply(querySet.Tables)
+		if err != nil {
+			notify = append(notify, sub.notify(protocol.RoutedMessage{Kind: protocol.MessageKindTransactionUpdate}, err))
+			continue
+		}
+		mutations = append(mutations, applied...)
+		notify = append(notify, sub.notify(protocol.RoutedMessage{
+			Kind:    protocol.MessageKindTransactionUpdate,
+			Payload: clientapi.TransactionUpdate{QuerySets: []clientapi.QuerySetUpdate{querySet}},
+		}, nil))
+	}
+	dispatch := t.applyMutations(event, mutations)
+	t.mu.Unlock()
+	if dispatch != nil {
+		dispatch()
+	}
+	runAll(notify)
+}
+
+// notify returns a call of the subscription callback with message, which
+// is rewritten to carry the subscription handle as its query id.
+func (sub *trackedSubscription) notify(message protocol.RoutedMessage, err error) func() {
+	if sub.callback == nil {
+		return nil
+	}
+	handle := sub.handle
+	message.QueryID = &handle
+	callback := sub.callback
+	return func() { callback(message, err) }
+}
+
+// event describes a change to sub's rows for the row callbacks.
+func (sub *trackedSubscription) event(kind events.Kind) events.Event {
+	return events.Event{Kind: kind, QuerySet: sub.handle}
+}
+
+func (sub *trackedSubscription) fail(err error) func() {
+	return sub.notify(protocol.RoutedMessage{}, err)
+}
+
+// apply updates the row multiset with the persistent-table rows of an update
+// and returns them as store mutations. Event-table rows are not cached.
+func (sub *trackedSubscription) apply(tables []clientapi.TableUpdate) ([]types.TableMutation, error) {
+	if sub.rows == nil {
+		sub.rows = map[string]map[string]int{}
+	}
+	var mutations []types.TableMutation
+	for _, table := range tables {
+		for _, rows := range table.Rows {
+			persistent, ok := rows.Variant().(clientapi.TableUpdateRowsPersistentTable)
+			if !ok {
+				continue
+			}
+			deletes, err := protocol.SplitRows(persistent.Value.Deletes)
+			if err != nil {
+				return nil, err
+			}
+			inserts, err := protocol.SplitRows(persistent.Value.Inserts)
+			if err != nil {
+				return nil, err
+			}
+			counts := sub.rows[table.TableName]
+			if counts == nil {
+				counts = map[string]int{}
+				sub.rows[table.TableName] = counts
+			}
+			for _, row := range deletes {
+				if counts[string(row)]--; counts[string(row)] <= 0 {
+					delete(counts, string(row))
+				}
+			}
+			for _, row := range inserts {
+				counts[string(row)]++
+			}
+			mutations = append(mutations, sub.tableMutation(table.TableName, inserts, deletes))
+		}
+	}
+	return mutations, nil
+}
+
+// reconcile replaces the row multiset with fresh and returns the table
+// updates, and the matching store mutations, that turn the old rows into the
+// new ones.
+func (sub *trackedSubscription) reconcile(fresh map[string]map[string]int) ([]clientapi.TableUpdate, []types.TableMutation) {
+	names := make([]string, 0, len(fresh)+len(sub.rows))
+	for name := range sub.rows {
+		names = append(names, name)
+	}
+	for name := range fresh {
+		if _, ok := sub.rows[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var (
+		tables    []clientapi.TableUpdate
+		mutations []types.TableMutation
+	)
+	for _, name := range names {
+		inserts, deletes := diffRows(sub.rows[name], fresh[name])
+		if len(inserts) == 0 && len(deletes) == 0 {
+			continue
+		}
+		tables = append(tables, clientapi.TableUpdate{
+			TableName: name,
+			Rows: []clientapi.TableUpdateRows{clientapi.NewTableUpdateRows(clientapi.TableUpdateRowsPersistentTable{
+				Value: clientapi.PersistentTableRows{
+					Inserts: protocol.NewRowList(inserts),
+					Deletes: protocol.NewRowList(deletes),
+				},
+			})},
+		})
+		mutations = append(mutations, sub.tableMutation(name, inserts, deletes))
+	}
+	sub.rows = fresh
+	return tables, mutations
+}
+
+// tableMutation converts rows of the subscription to a store mutation. Rows
+// are keyed by their encoded bytes, unless the store reads a primary key out
+// of them, and counted under the subscription handle, which stays the same
+// across reconnects.
+func (sub *trackedSubscription) tableMutation(table string, inserts, deletes [][]byte) types.TableMutation {
+	mutation := types.TableMutation{Table: table, QuerySet: sub.handle}
+	for _, row := range deletes {
+		mutation.Deletes = append(mutation.Deletes, types.Row{Key: string(row), Data: row})
+	}
+	for _, row := range inserts {
+		mutation.Inserts = append(mutation.Inserts, types.Row{Key: string(row), Data: row})
+	}
+	return mutation
+}
+
+// rowMutations inserts, or deletes, every row the subscription holds, as
+// often as it holds it, by table in a stable order.
+func (sub *trackedSubscription) rowMutations(insert bool) []types.TableMutation {
+	names := slices.Sorted(maps.Keys(sub.rows))
+	mutations := make([]types.TableMutation, 0, len(names))
+	for _, name := range names {
+		counts := sub.rows[name]
+		var encoded [][]byte
+		for _, key := range slices.Sorted(maps.Keys(counts)) {
+			for range counts[key] {
+				encoded = append(encoded, []byte(key))
+			}
+		}
+		if insert {
+			mutations = append(mutations, sub.tableMutation(name, encoded, nil))
+		} else {
+			mutations = append(mutations, sub.tableMutation(name, nil, encoded))
+		}
+	}
+	return mutations
+}
+
+// diffRows returns the rows to insert and delete to turn before into after,
+// in a stable order.
+func diffRows(before, after map[string]int) (inserts, deletes [][]byte) {
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		for n := after[key] - before[key]; n > 0; n-- {
+			inserts = append(inserts, []byte(key))
+		}
+		for n := before[key] - after[key]; n > 0; n-- {
+			deletes = append(deletes, []byte(key))
+		}
+	}
+	return inserts, deletes
+}
+
+// subscribedRows collects the rows of a SubscribeApplied message as a multiset per table.
+func subscribedRows(rows clientapi.QueryRows) (map[string]map[string]int, error) {
+	out := make(map[string]map[string]int, len(rows.Tables))
+	for _, table := range rows.Tables {
+		split, err := protocol.SplitRows(table.Rows)
+		if err != nil {
+			return nil, err
+		}
+		counts := out[table.Table]
+		if counts == nil {
+			counts = make(map[string]int, len(split))
+			out[table.Table] = counts
+		}
+		for _, row := range split {
+			counts[string(row)]++
+		}
+	}
+	return out, nil
+}
+
+// chain returns a call that runs calls in order, skipping nil ones.
+func chain(calls ...func()) func() {
+	return func() { runAll(calls) }
+}
+
+func runAll(calls []func()) {
+	for _, call := range calls {
+		if call != nil {
+			call()
+		}
+	}
+}
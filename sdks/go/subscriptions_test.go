@@ -0,0 +1,783 @@
+package spacetimedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/cache"
+	"github.com/clockworklabs/spacetimedb/sdks/go/connection"
+	"github.com/clockworklabs/spacetimedb/sdks/go/events"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestDiffRows(t *testing.T) {
+	before := map[string]int{"a": 1, "b": 2, "c": 1}
+	after := map[string]int{"b": 1, "c": 1, "d": 2}
+	inserts, deletes := diffRows(before, after)
+	if want := [][]byte{[]byte("d"), []byte("d")}; !reflect.DeepEqual(inserts, want) {
+		t.Fatalf("unexpected inserts: %q", inserts)
+	}
+	if want := [][]byte{[]byte("a"), []byte("b")}; !reflect.DeepEqual(deletes, want) {
+		t.Fatalf("unexpected deletes: %q", deletes)
+	}
+}
+
+func TestReconcileReportsOnlyChangedTables(t *testing.T) {
+	sub := &trackedSubscription{rows: map[string]map[string]int{
+		"users": {"a": 1},
+		"teams": {"x": 1},
+	}}
+	tables, mutations := sub.reconcile(map[string]map[string]int{
+		"users": {"a": 1},
+		"items": {"i": 1},
+	})
+	if len(mutations) != len(tables) {
+		t.Fatalf("expected one store mutation per changed table, got %+v", mutations)
+	}
+	got := map[string][2][][]byte{}
+	for _, table := range tables {
+		inserts, deletes := tableRows(t, table)
+		got[table.TableName] = [2][][]byte{inserts, deletes}
+	}
+	want := map[string][2][][]byte{
+		"items": {{[]byte("i")}, {}},
+		"teams": {{}, {[]byte("x")}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected reconcile result: %q", got)
+	}
+}
+
+func TestResubscribeAfterReconnectReportsNetChanges(t *testing.T) {
+	server := newScriptedTestServer(t, func(n int32, conn *websocket.Conn) {
+		subscribe := readClientMessage(t, conn)
+		if subscribe.QueryID == nil {
+			t.Errorf("expected a subscribe message, got %+v", subscribe)
+			return
+		}
+		queryID := *subscribe.QueryID
+		if n == 1 {
+			writeServerMessage(t, conn, subscribeApplied(subscribe.RequestID, queryID, "a", "b"))
+			writeServerMessage(t, conn, clientapi.ServerMessageTransactionUpdate{Value: clientapi.TransactionUpdate{
+				QuerySets: []clientapi.QuerySetUpdate{querySetUpdate(queryID, []string{"c"}, nil)},
+			}})
+			time.Sleep(20 * time.Millisecond)
+			return
+		}
+		if got := subscribe.QueryStrings; !reflect.DeepEqual(got, []string{"select * from users"}) {
+			t.Errorf("unexpected resubscribe queries: %v", got)
+		}
+		writeServerMessage(t, conn, subscribeApplied(subscribe.RequestID, queryID, "b", "c", "d"))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	type event struct {
+		message protocol.RoutedMessage
+		err     error
+	}
+	events := make(chan event, 8)
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithReconnect(ReconnectPolicy{InitialBackoff: 5 * time.Millisecond}).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	handle, err := conn.Subscribe(context.Background(), []string{"select * from users"}, func(message protocol.RoutedMessage, err error) {
+		events <- event{message, err}
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	next := func() event {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.err != nil {
+				t.Fatalf("unexpected subscription error: %v", ev.err)
+			}
+			if ev.message.QueryID == nil || *ev.message.QueryID != handle {
+				t.Fatalf("expected messages to carry handle %d, got %v", handle, ev.message.QueryID)
+			}
+			return ev
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for subscription event")
+			return event{}
+		}
+	}
+
+	if ev := next(); ev.message.Kind != protocol.MessageKindSubscribeApplied {
+		t.Fatalf("expected subscribe_applied, got %s", ev.message.Kind)
+	}
+	ev := next()
+	update, ok := ev.message.AsTransactionUpdate()
+	if !ok {
+		t.Fatalf("expected transaction_update, got %s", ev.message.Kind)
+	}
+	if inserts, _ := tableRows(t, update.QuerySets[0].Tables[0]); !reflect.DeepEqual(inserts, [][]byte{[]byte("c")}) {
+		t.Fatalf("unexpected live update inserts: %q", inserts)
+	}
+
+	// After the reconnect only the difference between {a, b, c} and {b, c, d} is reported.
+	ev = next()
+	update, ok = ev.message.AsTransactionUpdate()
+	if !ok || len(update.QuerySets) != 1 || len(update.QuerySets[0].Tables) != 1 {
+		t.Fatalf("expected a single-table transaction_update, got %s %+v", ev.message.Kind, ev.message.Payload)
+	}
+	inserts, deletes := tableRows(t, update.QuerySets[0].Tables[0])
+	if !reflect.DeepEqual(inserts, [][]byte{[]byte("d")}) || !reflect.DeepEqual(deletes, [][]byte{[]byte("a")}) {
+		t.Fatalf("unexpected reconciled rows: inserts %q deletes %q", inserts, deletes)
+	}
+
+	if got := cachedKeys(conn, "users"); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+		t.Fatalf("unexpected cached rows after reconnect: %q", got)
+	}
+
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected extra subscription event: %s %v", ev.message.Kind, ev.err)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
+
+func TestUnconfirmedUnsubscribeDropsRowsOnReconnect(t *testing.T) {
+	server := newScriptedTestServer(t, func(n int32, conn *websocket.Conn) {
+		if n == 1 {
+			subscribe := readClientMessage(t, conn)
+			writeServerMessage(t, conn, subscribeApplied(subscribe.RequestID, *subscribe.QueryID, "a", "b"))
+			// Cut the connection before confirming the unsubscribe.
+			readClientMessage(t, conn)
+			return
+		}
+		for {
+			_, raw, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if message, err := protocol.BSATNClientMessageDecoder(raw); err == nil && message.Kind == protocol.ClientMessageSubscribe {
+				t.Errorf("expected the unsubscribed query not to be re-sent")
+			}
+		}
+	})
+	defer server.Close()
+
+	reconnected := make(chan struct{}, 1)
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithReconnect(ReconnectPolicy{InitialBackoff: 5 * time.Millisecond}).
+		OnReconnected(func(*DbConnection) { reconnected <- struct{}{} }).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	deleted := make(chan events.Kind, 2)
+	conn.Db().OnDelete("users", func(ev events.Event, _ types.Row) { deleted <- ev.Kind })
+	handle, _, err := conn.SubscribeWait(context.Background(), []string{"select * from users"}, nil)
+	if err != nil {
+		t.Fatalf("subscribe wait: %v", err)
+	}
+	if _, err := conn.Unsubscribe(context.Background(), handle); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	select {
+	case <-reconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for reconnect")
+	}
+
+	if got := cachedKeys(conn, "users"); len(got) != 0 {
+		t.Fatalf("expected the unsubscribed rows to leave the cache, got %q", got)
+	}
+	for range 2 {
+		select {
+		case kind := <-deleted:
+			if kind != events.KindUnsubscribeApplied {
+				t.Fatalf("expected unsubscribe_applied deletes, got %s", kind)
+			}
+		default:
+			t.Fatalf("expected a delete callback for each row")
+		}
+	}
+}
+
+func TestResubscribeOutgrowsSendQueue(t *testing.T) {
+	const subscriptions = 4
+	server := newScriptedTestServer(t, func(n int32, conn *websocket.Conn) {
+		for i := range subscriptions {
+			subscribe := readClientMessage(t, conn)
+			if subscribe.QueryID == nil {
+				t.Errorf("expected a subscribe message, got %+v", subscribe)
+				return
+			}
+			row := fmt.Sprintf("r%d", i)
+			if n > 1 {
+				row = fmt.Sprintf("s%d", i)
+			}
+			writeServerMessage(t, conn, subscribeApplied(subscribe.RequestID, *subscribe.QueryID, row))
+		}
+		if n == 1 {
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithSendQueue(1, connection.OverflowFailFast).
+		WithReconnect(ReconnectPolicy{InitialBackoff: 5 * time.Millisecond}).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	failures := make(chan error, subscriptions)
+	for i := range subscriptions {
+		if _, _, err := conn.SubscribeWait(context.Background(), []string{fmt.Sprintf("select * from users where id = %d", i)}, func(_ protocol.RoutedMessage, err error) {
+			if err != nil {
+				failures <- err
+			}
+		}); err != nil {
+			t.Fatalf("subscribe %d: %v", i, err)
+		}
+	}
+
+	want := []string{"s0", "s1", "s2", "s3"}
+	deadline := time.Now().Add(2 * time.Second)
+	for !reflect.DeepEqual(cachedKeys(conn, "users"), want) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := cachedKeys(conn, "users"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected every subscription to be re-sent, got %q", got)
+	}
+	select {
+	case err := <-failures:
+		t.Fatalf("unexpected subscription failure: %v", err)
+	default:
+	}
+}
+
+func TestDbCacheFollowsSubscriptionLifecycle(t *testing.T) {
+	server := newLifecycleTestServer(t)
+	defer server.Close()
+
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	messages := make(chan protocol.RoutedMessage, 4)
+	handle, _, err := conn.SubscribeWait(context.Background(), []string{"select * from users"}, func(message protocol.RoutedMessage, err error) {
+		if err == nil {
+			messages <- message
+		}
+	})
+	if err != nil {
+		t.Fatalf("subscribe wait: %v", err)
+	}
+	if got := cachedKeys(conn, "users"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("unexpected cached rows after subscribe: %q", got)
+	}
+
+	if _, err := conn.CallReducerWait(context.Background(), "rename", nil); err != nil {
+		t.Fatalf("call reducer: %v", err)
+	}
+	// The broadcast update may already have landed as well.
+	if got := cachedKeys(conn, "users"); slices.Contains(got, "a") || !slices.Contains(got, "c") {
+		t.Fatalf("unexpected cached rows after reducer result: %q", got)
+	}
+
+	nextKind := func(want protocol.MessageKind) {
+		t.Helper()
+		for {
+			select {
+			case message := <-messages:
+				if message.Kind == want {
+					return
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("timed out waiting for %s", want)
+			}
+		}
+	}
+	// The reducer's own update reaches the callback first; wait for the broadcast one.
+	nextKind(protocol.MessageKindTransactionUpdate)
+	nextKind(protocol.MessageKindTransactionUpdate)
+	if got := cachedKeys(conn, "users"); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+		t.Fatalf("unexpected cached rows after transaction update: %q", got)
+	}
+
+	if _, err := conn.Unsubscribe(context.Background(), handle); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	nextKind(protocol.MessageKindUnsubscribeApplied)
+	if got := cachedKeys(conn, "users"); len(got) != 0 {
+		t.Fatalf("expected unsubscribing to clear the cache, got %q", got)
+	}
+}
+
+func TestOverlappingSubscriptionsKeepSharedRows(t *testing.T) {
+	server := newScriptedTestServer(t, func(_ int32, conn *websocket.Conn) {
+		var queryIDs []uint32
+		for _, rows := range [][]string{{"a", "b"}, {"b", "c"}} {
+			subscribe := readClientMessage(t, conn)
+			if subscribe.QueryID == nil {
+				t.Errorf("expected a subscribe message, got %+v", subscribe)
+				return
+			}
+			queryIDs = append(queryIDs, *subscribe.QueryID)
+			writeServerMessage(t, conn, subscribeApplied(subscribe.RequestID, *subscribe.QueryID, rows...))
+		}
+		unsubscribe := readClientMessage(t, conn)
+		writeServerMessage(t, conn, clientapi.ServerMessageUnsubscribeApplied{Value: clientapi.UnsubscribeApplied{
+			RequestId:  unsubscribe.RequestID,
+			QuerySetId: clientapi.QuerySetId{Id: queryIDs[0]},
+		}})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	unsubscribed := make(chan struct{}, 1)
+	first, _, err := conn.SubscribeWait(context.Background(), []string{"select * from users where id < 2"}, func(message protocol.RoutedMessage, err error) {
+		if err == nil && message.Kind == protocol.MessageKindUnsubscribeApplied {
+			unsubscribed <- struct{}{}
+		}
+	})
+	if err != nil {
+		t.Fatalf("first subscribe: %v", err)
+	}
+	if _, _, err := conn.SubscribeWait(context.Background(), []string{"select * from users where id > 0"}, nil); err != nil {
+		t.Fatalf("second subscribe: %v", err)
+	}
+	if got := cachedKeys(conn, "users"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected cached rows: %q", got)
+	}
+
+	if _, err := conn.Unsubscribe(context.Background(), first); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	select {
+	case <-unsubscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for unsubscribe_applied")
+	}
+	if got := cachedKeys(conn, "users"); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Fatalf("expected the shared row to stay cached, got %q", got)
+	}
+}
+
+func TestRowCallbacksCarryTriggeringEvent(t *testing.T) {
+	server := newLifecycleTestServer(t)
+	defer server.Close()
+
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	record := func(ev events.Event, change string, row types.Row) {
+		mu.Lock()
+		defer mu.Unlock()
+		entry := fmt.Sprintf("%s(%d) %s %s", ev.Kind, ev.QuerySet, change, row.Data)
+		if ev.Reducer != nil {
+			entry = fmt.Sprintf("%s %s %s", ev.Reducer.Name, change, row.Data)
+		}
+		got = append(got, entry)
+	}
+	done := make(chan struct{})
+	conn.Db().OnInsert("users", func(ev events.Event, row types.Row) { record(ev, "insert", row) })
+	conn.Db().OnDelete("users", func(ev events.Event, row types.Row) {
+		record(ev, "delete", row)
+		if ev.Kind == events.KindUnsubscribeApplied && string(row.Data) == "d" {
+			close(done)
+		}
+	})
+
+	handle, _, err := conn.SubscribeWait(context.Background(), []string{"select * from users"}, nil)
+	if err != nil {
+		t.Fatalf("subscribe wait: %v", err)
+	}
+	if _, err := conn.CallReducerWait(context.Background(), "rename", nil); err != nil {
+		t.Fatalf("call reducer: %v", err)
+	}
+	// Wait for the broadcast insert of d before unsubscribing.
+	deadline := time.Now().Add(2 * time.Second)
+	for !slices.Contains(cachedKeys(conn, "users"), "d") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := conn.Unsubscribe(context.Background(), handle); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for unsubscribe row callbacks")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"subscribe_applied(0) insert a",
+		"subscribe_applied(0) insert b",
+		"rename delete a",
+		"rename insert c",
+		"transaction(0) insert d",
+		"unsubscribe_applied(0) delete b",
+		"unsubscribe_applied(0) delete c",
+		"unsubscribe_applied(0) delete d",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected row callbacks:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestPrimaryKeyChangesReachOnUpdate(t *testing.T) {
+	type user struct {
+		ID   uint32
+		Name string
+	}
+	encode := func(id uint32, name string) string {
+		data, err := bsatn.Marshal(user{ID: id, Name: name})
+		if err != nil {
+			t.Errorf("marshal user: %v", err)
+		}
+		return string(data)
+	}
+	server := newScriptedTestServer(t, func(_ int32, conn *websocket.Conn) {
+		subscribe := readClientMessage(t, conn)
+		if subscribe.QueryID == nil {
+			t.Errorf("expected a subscribe message, got %+v", subscribe)
+			return
+		}
+		queryID := *subscribe.QueryID
+		writeServerMessage(t, conn, subscribeApplied(subscribe.RequestID, queryID, encode(1, "alice"), encode(2, "bob")))
+		writeServerMessage(t, conn, clientapi.ServerMessageTransactionUpdate{Value: clientapi.TransactionUpdate{
+			QuerySets: []clientapi.QuerySetUpdate{querySetUpdate(queryID, []string{encode(1, "alicia")}, []string{encode(1, "alice")})},
+		}})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	err = conn.Db().DefineTable("users", cache.TableSchema{
+		RowType: types.ProductOf(
+			types.ProductTypeElement{Name: "id", Type: types.PrimitiveType(types.KindU32)},
+			types.ProductTypeElement{Name: "name", Type: types.PrimitiveType(types.KindString)},
+		),
+		PrimaryKey: "id",
+	})
+	if err != nil {
+		t.Fatalf("define table: %v", err)
+	}
+	updates := make(chan [2]string, 1)
+	conn.Db().OnUpdate("users", func(ev events.Event, old, new types.Row) {
+		if ev.Kind == events.KindTransaction {
+			updates <- [2]string{string(old.Data), string(new.Data)}
+		}
+	})
+	conn.Db().OnInsert("users", func(ev events.Event, row types.Row) {
+		if ev.Kind == events.KindTransaction {
+			t.Errorf("expected the renamed row to be an update, got an insert of %q", row.Data)
+		}
+	})
+
+	if _, _, err := conn.SubscribeWait(context.Background(), []string{"select * from users"}, nil); err != nil {
+		t.Fatalf("subscribe wait: %v", err)
+	}
+	select {
+	case update := <-updates:
+		if update != [2]string{encode(1, "alice"), encode(1, "alicia")} {
+			t.Fatalf("unexpected update %q", update)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for the update callback")
+	}
+	id := types.AlgebraicValue{Type: types.PrimitiveType(types.KindU32), Value: uint32(1)}
+	if data, ok := conn.Db().FindByPrimaryKey("users", id); !ok || string(data) != encode(1, "alicia") {
+		t.Fatalf("expected the cache to hold the new row, got %q, %v", data, ok)
+	}
+}
+
+// newLifecycleTestServer answers one subscription with rows a and b, one
+// reducer call with a transaction that deletes a and inserts c, broadcasts an
+// insert of d and then confirms one unsubscribe.
+func newLifecycleTestServer(t *testing.T) *reconnectTestServer {
+	t.Helper()
+	return newScriptedTestServer(t, func(_ int32, conn *websocket.Conn) {
+		subscribe := readClientMessage(t, conn)
+		if subscribe.QueryID == nil {
+			t.Errorf("expected a subscribe message, got %+v", subscribe)
+			return
+		}
+		queryID := *subscribe.QueryID
+		writeServerMessage(t, conn, subscribeApplied(subscribe.RequestID, queryID, "a", "b"))
+
+		call := readClientMessage(t, conn)
+		writeServerMessage(t, conn, clientapi.ServerMessageReducerResult{Value: clientapi.ReducerResult{
+			RequestId: call.RequestID,
+			Timestamp: time.Unix(1, 0),
+			Result: clientapi.NewReducerOutcome(clientapi.ReducerOutcomeOk{Value: clientapi.ReducerOk{
+				TransactionUpdate: clientapi.TransactionUpdate{
+					QuerySets: []clientapi.QuerySetUpdate{querySetUpdate(queryID, []string{"c"}, []string{"a"})},
+				},
+			}}),
+		}})
+		writeServerMessage(t, conn, clientapi.ServerMessageTransactionUpdate{Value: clientapi.TransactionUpdate{
+			QuerySets: []clientapi.QuerySetUpdate{querySetUpdate(queryID, []string{"d"}, nil)},
+		}})
+
+		unsubscribe := readClientMessage(t, conn)
+		writeServerMessage(t, conn, clientapi.ServerMessageUnsubscribeApplied{Value: clientapi.UnsubscribeApplied{
+			RequestId:  unsubscribe.RequestID,
+			QuerySetId: clientapi.QuerySetId{Id: queryID},
+		}})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// cachedKeys returns the sorted row keys the connection caches for table.
+func TestLateReducerResultStillReachesCache(t *testing.T) {
+	release := make(chan struct{})
+	server := newScriptedTestServer(t, func(_ int32, conn *websocket.Conn) {
+		subscribe := readClientMessage(t, conn)
+		writeServerMessage(t, conn, subscribeApplied(subscribe.RequestID, *subscribe.QueryID, "a"))
+		call := readClientMessage(t, conn)
+		<-release
+		writeServerMessage(t, conn, clientapi.ServerMessageReducerResult{Value: clientapi.ReducerResult{
+			RequestId: call.RequestID,
+			Timestamp: time.Unix(1, 0),
+			Result: clientapi.NewReducerOutcome(clientapi.ReducerOutcomeOk{Value: clientapi.ReducerOk{
+				TransactionUpdate: clientapi.TransactionUpdate{
+					QuerySets: []clientapi.QuerySetUpdate{querySetUpdate(*subscribe.QueryID, []string{"b"}, nil)},
+				},
+			}}),
+		}})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	if _, _, err := conn.SubscribeWait(context.Background(), []string{"select * from users"}, nil); err != nil {
+		t.Fatalf("subscribe wait: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan error, 1)
+	if _, err := conn.CallReducer(ctx, "slow", nil, func(_ protocol.RoutedMessage, err error) { results <- err }); err != nil {
+		t.Fatalf("call reducer: %v", err)
+	}
+	cancel()
+	if err := <-results; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled call to fail, got %v", err)
+	}
+	close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !slices.Contains(cachedKeys(conn, "users"), "b") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := cachedKeys(conn, "users"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("expected the late result's rows to be cached, got %q", got)
+	}
+}
+
+func cachedKeys(conn *DbConnection, table string) []string {
+	return slices.Sorted(maps.Keys(conn.Db().TableSnapshot(table)))
+}
+
+func TestPendingSubscriptionFailsOnDisconnect(t *testing.T) {
+	server := newScriptedTestServer(t, func(n int32, conn *websocket.Conn) {
+		if n == 1 {
+			readClientMessage(t, conn)
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	errs := make(chan error, 2)
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		WithReconnect(ReconnectPolicy{InitialBackoff: 5 * time.Millisecond}).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	if _, err := conn.Subscribe(context.Background(), []string{"select * from users"}, func(_ protocol.RoutedMessage, err error) {
+		errs <- err
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Fatalf("expected the unapplied subscription to fail")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for subscription failure")
+	}
+}
+
+// readClientMessage reads one client message. It runs on server goroutines,
+// so failures are reported with t.Errorf and a zero message.
+func readClientMessage(t *testing.T, conn *websocket.Conn) protocol.ClientMessage {
+	_, raw, err := conn.ReadMessage()
+	if err != nil {
+		t.Errorf("read client message: %v", err)
+		return protocol.ClientMessage{}
+	}
+	message, err := protocol.BSATNClientMessageDecoder(raw)
+	if err != nil {
+		t.Errorf("decode client message: %v", err)
+	}
+	return message
+}
+
+func subscribeApplied(requestID, queryID uint32, rows ...string) clientapi.ServerMessageSubscribeApplied {
+	return clientapi.ServerMessageSubscribeApplied{Value: clientapi.SubscribeApplied{
+		RequestId:  requestID,
+		QuerySetId: clientapi.QuerySetId{Id: queryID},
+		Rows: clientapi.QueryRows{Tables: []clientapi.SingleTableRows{{
+			Table: "users",
+			Rows:  protocol.NewRowList(byteRows(rows)),
+		}}},
+	}}
+}
+
+func querySetUpdate(queryID uint32, inserts, deletes []string) clientapi.QuerySetUpdate {
+	return clientapi.QuerySetUpdate{
+		QuerySetId: clientapi.QuerySetId{Id: queryID},
+		Tables: []clientapi.TableUpdate{{
+			TableName: "users",
+			Rows: []clientapi.TableUpdateRows{clientapi.NewTableUpdateRows(clientapi.TableUpdateRowsPersistentTable{
+				Value: clientapi.PersistentTableRows{
+					Inserts: protocol.NewRowList(byteRows(inserts)),
+					Deletes: protocol.NewRowList(byteRows(deletes)),
+				},
+			})},
+		}},
+	}
+}
+
+func byteRows(rows []string) [][]byte {
+	out := make([][]byte, len(rows))
+	for i, row := range rows {
+		out[i] = []byte(row)
+	}
+	return out
+}
+
+// tableRows returns the persistent inserts and deletes of a table update.
+func tableRows(t *testing.T, table clientapi.TableUpdate) (inserts, deletes [][]byte) {
+	t.Helper()
+	persistent, ok := table.Rows[0].Variant().(clientapi.TableUpdateRowsPersistentTable)
+	if !ok {
+		t.Fatalf("expected persistent table rows, got %v", table.Rows[0])
+	}
+	inserts, err := protocol.SplitRows(persistent.Value.Inserts)
+	if err != nil {
+		t.Fatalf("split inserts: %v", err)
+	}
+	deletes, err = protocol.SplitRows(persistent.Value.Deletes)
+	if err != nil {
+		t.Fatalf("split deletes: %v", err)
+	}
+	return inserts, deletes
+}
@@ -15,6 +15,10 @@ cargo run --manifest-path "%STDB_PATH%\crates\cli\Cargo.toml" -- generate -l go
   --module-def ^
   -o "%OUT_DIR%"
 
+rem The generated Client wrapper imports the connection package, which reaches
+rem clientapi through internal/protocol. The wire types do not need it.
+if exist "%OUT_DIR%\client.go" del /q "%OUT_DIR%\client.go"
+
 for %%f in ("%OUT_DIR%\*.go") do (
   powershell -NoProfile -Command "(Get-Content '%%~ff') -replace '^package module_bindings$','package clientapi' | Set-Content '%%~ff'"
 )
@@ -17,6 +17,10 @@ cargo run --manifest-path "$STDB_PATH/crates/cli/Cargo.toml" -- generate -l go \
   --module-def \
   -o "$OUT_DIR"
 
+# The generated Client wrapper imports the connection package, which reaches
+# clientapi through internal/protocol. The wire types do not need it.
+rm -f "$OUT_DIR/client.go"
+
 while IFS= read -r -d '' file; do
   sed -i 's/^package module_bindings$/package clientapi/' "$file"
 done < <(find "$OUT_DIR" -type f -name "*.go" -print0)
@@ -0,0 +1,391 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AlgebraicTypeKind identifies the shape of an AlgebraicType.
+type AlgebraicTypeKind uint8
+
+const (
+	KindRef AlgebraicTypeKind = iota
+	KindSum
+	KindProduct
+	KindArray
+	KindString
+	KindBool
+	KindI8
+	KindU8
+	KindI16
+	KindU16
+	KindI32
+	KindU32
+	KindI64
+	KindU64
+	KindI128
+	KindU128
+	KindI256
+	KindU256
+	KindF32
+	KindF64
+)
+
+// kindNames are the SATS variant names of each kind, in tag order.
+var kindNames = [...]string{
+	"Ref", "Sum", "Product", "Array", "String", "Bool",
+	"I8", "U8", "I16", "U16", "I32", "U32", "I64", "U64",
+	"I128", "U128", "I256", "U256", "F32", "F64",
+}
+
+func (k AlgebraicTypeKind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("AlgebraicTypeKind(%d)", uint8(k))
+}
+
+// Special product element names used by SATS for builtin types.
+const (
+	identityTag     = "__identity__"
+	connectionIdTag = "__connection_id__"
+	timestampTag    = "__timestamp_micros_since_unix_epoch__"
+	timeDurationTag = "__time_duration_micros__"
+	uuidTag         = "__uuid__"
+)
+
+// AlgebraicType is the runtime representation of a SATS type.
+//
+// Only the field matching Kind is set: Ref for KindRef, Product for
+// KindProduct, Sum for KindSum and Elem for KindArray.
+type AlgebraicType struct {
+	Kind    AlgebraicTypeKind
+	Ref     uint32
+	Product *ProductType
+	Sum     *SumType
+	Elem    *AlgebraicType
+}
+
+// ProductType is an ordered list of optionally named elements.
+type ProductType struct {
+	Elements []ProductTypeElement
+}
+
+// ProductTypeElement is one field of a ProductType. Name is empty for
+// unnamed elements.
+type ProductTypeElement struct {
+	Name string
+	Type AlgebraicType
+}
+
+// SumType is an ordered list of variants; a variant's index is its tag.
+type SumType struct {
+	Variants []SumTypeVariant
+}
+
+// SumTypeVariant is one variant of a SumType.
+type SumTypeVariant struct {
+	Name string
+	Type AlgebraicType
+}
+
+// Typespace holds the types that KindRef types point into.
+type Typespace struct {
+	Types []AlgebraicType
+}
+
+// PrimitiveType returns the type of the given non-composite kind.
+func PrimitiveType(kind AlgebraicTypeKind) AlgebraicType {
+	return AlgebraicType{Kind: kind}
+}
+
+// RefType returns a reference to the type at index ref of a Typespace.
+func RefType(ref uint32) AlgebraicType {
+	return AlgebraicType{Kind: KindRef, Ref: ref}
+}
+
+// ArrayType returns the type of arrays of elem.
+func ArrayType(elem AlgebraicType) AlgebraicType {
+	return AlgebraicType{Kind: KindArray, Elem: &elem}
+}
+
+// ProductOf returns a product type with the given elements.
+func ProductOf(elements ...ProductTypeElement) AlgebraicType {
+	return AlgebraicType{Kind: KindProduct, Product: &ProductType{Elements: elements}}
+}
+
+// SumOf returns a sum type with the given variants.
+func SumOf(variants ...SumTypeVariant) AlgebraicType {
+	return AlgebraicType{Kind: KindSum, Sum: &SumType{Variants: variants}}
+}
+
+// UnitType returns the empty product.
+func UnitType() AlgebraicType {
+	return ProductOf()
+}
+
+// OptionType returns the SATS option type: a sum of some(inner) and none.
+func OptionType(inner AlgebraicType) AlgebraicType {
+	return SumOf(SumTypeVariant{Name: "some", Type: inner}, SumTypeVariant{Name: "none", Type: UnitType()})
+}
+
+// IdentityType returns the SATS representation of Identity.
+func IdentityType() AlgebraicType {
+	return ProductOf(ProductTypeElement{Name: identityTag, Type: PrimitiveType(KindU256)})
+}
+
+// ConnectionIdType returns the SATS representation of ConnectionId.
+func ConnectionIdType() AlgebraicType {
+	return ProductOf(ProductTypeElement{Name: connectionIdTag, Type: PrimitiveType(KindU128)})
+}
+
+// TimestampType returns the SATS representation of Timestamp.
+func TimestampType() AlgebraicType {
+	return ProductOf(ProductTypeElement{Name: timestampTag, Type: PrimitiveType(KindI64)})
+}
+
+// TimeDurationType returns the SATS representation of TimeDuration.
+func TimeDurationType() AlgebraicType {
+	return ProductOf(ProductTypeElement{Name: timeDurationTag, Type: PrimitiveType(KindI64)})
+}
+
+// UuidType returns the SATS representation of Uuid.
+func UuidType() AlgebraicType {
+	return ProductOf(ProductTypeElement{Name: uuidTag, Type: PrimitiveType(KindU128)})
+}
+
+// IsOption reports whether t is an option type.
+func (t AlgebraicType) IsOption() bool {
+	if t.Kind != KindSum || t.Sum == nil || len(t.Sum.Variants) != 2 {
+		return false
+	}
+	none := t.Sum.Variants[1]
+	return t.Sum.Variants[0].Name == "some" && none.Name == "none" && none.Type.IsUnit()
+}
+
+// IsUnit reports whether t is the empty product.
+func (t AlgebraicType) IsUnit() bool {
+	return t.Kind == KindProduct && (t.Product == nil || len(t.Product.Elements) == 0)
+}
+
+// specialKind returns the builtin element name if t is one of the special
+// single-element products, such as Identity, or "" otherwise.
+func (t AlgebraicType) specialKind() string {
+	if t.Kind != KindProduct || t.Product == nil || len(t.Product.Elements) != 1 {
+		return ""
+	}
+	element := t.Product.Elements[0]
+	switch {
+	case element.Name == identityTag && element.Type.Kind == KindU256,
+		element.Name == connectionIdTag && element.Type.Kind == KindU128,
+		element.Name == timestampTag && element.Type.Kind == KindI64,
+		element.Name == timeDurationTag && element.Type.Kind == KindI64,
+		element.Name == uuidTag && element.Type.Kind == KindU128:
+		return element.Name
+	}
+	return ""
+}
+
+// Resolve follows refs until it reaches a type that is not a KindRef.
+func (ts *Typespace) Resolve(t AlgebraicType) (AlgebraicType, error) {
+	for hops := 0; t.Kind == KindRef; hops++ {
+		if ts == nil || int(t.Ref) >= len(ts.Types) {
+			return AlgebraicType{}, fmt.Errorf("typespace: unknown type ref %d", t.Ref)
+		}
+		if hops > len(ts.Types) {
+			return AlgebraicType{}, fmt.Errorf("typespace: ref %d does not resolve to a type", t.Ref)
+		}
+		t = ts.Types[t.Ref]
+	}
+	return t, nil
+}
+
+func (t AlgebraicType) String() string {
+	switch t.Kind {
+	case KindRef:
+		return fmt.Sprintf("&%d", t.Ref)
+	case KindArray:
+		if t.Elem == nil {
+			return "Array<?>"
+		}
+		return "Array<" + t.Elem.String() + ">"
+	case KindProduct:
+		if special := t.specialKind(); special != "" {
+			return special
+		}
+		out := "("
+		if t.Product != nil {
+			for i, e := range t.Product.Elements {
+				if i > 0 {
+					out += ", "
+				}
+				if e.Name != "" {
+					out += e.Name + ": "
+				}
+				out += e.Type.String()
+			}
+		}
+		return out + ")"
+	case KindSum:
+		if t.IsOption() {
+			return "Option<" + t.Sum.Variants[0].Type.String() + ">"
+		}
+		out := "("
+		if t.Sum != nil {
+			for i, v := range t.Sum.Variants {
+				if i > 0 {
+					out += " | "
+				}
+				if v.Name != "" {
+					out += v.Name + ": "
+				}
+				out += v.Type.String()
+			}
+		}
+		return out + ")"
+	default:
+		return t.Kind.String()
+	}
+}
+
+// The JSON form of AlgebraicType is the one used by module definitions, for
+// example {"Product":{"elements":[{"name":{"some":"id"},"algebraic_type":{"U32":[]}}]}}.
+
+type jsonOptionalName struct {
+	Some *string          `json:"some,omitempty"`
+	None *json.RawMessage `json:"none,omitempty"`
+}
+
+type jsonNamedType struct {
+	Name          jsonOptionalName `json:"name"`
+	AlgebraicType AlgebraicType    `json:"algebraic_type"`
+}
+
+func nameToJSON(name string) jsonOptionalName {
+	if name == "" {
+		empty := json.RawMessage("[]")
+		return jsonOptionalName{None: &empty}
+	}
+	return jsonOptionalName{Some: &name}
+}
+
+func (t AlgebraicType) MarshalJSON() ([]byte, error) {
+	var body any = []any{}
+	switch t.Kind {
+	case KindRef:
+		body = t.Ref
+	case KindArray:
+		if t.Elem == nil {
+			return nil, fmt.Errorf("algebraic type: array without element type")
+		}
+		body = t.Elem
+	case KindProduct:
+		elements := []jsonNamedType{}
+		if t.Product != nil {
+			for _, e := range t.Product.Elements {
+				elements = append(elements, jsonNamedType{Name: nameToJSON(e.Name), AlgebraicType: e.Type})
+			}
+		}
+		body = map[string]any{"elements": elements}
+	case KindSum:
+		variants := []jsonNamedType{}
+		if t.Sum != nil {
+			for _, v := range t.Sum.Variants {
+				variants = append(variants, jsonNamedType{Name: nameToJSON(v.Name), AlgebraicType: v.Type})
+			}
+		}
+		body = map[string]any{"variants": variants}
+	default:
+		if int(t.Kind) >= len(kindNames) {
+			return nil, fmt.Errorf("algebraic type: unknown kind %d", t.Kind)
+		}
+	}
+	return json.Marshal(map[string]any{t.Kind.String(): body})
+}
+
+func (t *AlgebraicType) UnmarshalJSON(data []byte) error {
+	var outer map[string]json.RawMessage
+	if err := json.Unmarshal(data, &outer); err != nil {
+		return fmt.Errorf("algebraic type: %w", err)
+	}
+	if len(outer) != 1 {
+		return fmt.Errorf("algebraic type: expected a single variant, got %d", len(outer))
+	}
+	for name, body := range outer {
+		kind, ok := kindFromName(name)
+		if !ok {
+			return fmt.Errorf("algebraic type: unknown variant %q", name)
+		}
+		*t = AlgebraicType{Kind: kind}
+		switch kind {
+		case KindRef:
+			return json.Unmarshal(body, &t.Ref)
+		case KindArray:
+			var elem AlgebraicType
+			if err := json.Unmarshal(body, &elem); err != nil {
+				return err
+			}
+			t.Elem = &elem
+		case KindProduct:
+			var product struct {
+				Elements []jsonNamedType `json:"elements"`
+			}
+			if err := json.Unmarshal(body, &product); err != nil {
+				return err
+			}
+			t.Product = &ProductType{}
+			for _, e := range product.Elements {
+				t.Product.Elements = append(t.Product.Elements, ProductTypeElement{Name: nameFromJSON(e.Name), Type: e.AlgebraicType})
+			}
+		case KindSum:
+			var sum struct {
+				Variants []jsonNamedType `json:"variants"`
+			}
+			if err := json.Unmarshal(body, &sum); err != nil {
+				return err
+			}
+			t.Sum = &SumType{}
+			for _, v := range sum.Variants {
+				t.Sum.Variants = append(t.Sum.Variants, SumTypeVariant{Name: nameFromJSON(v.Name), Type: v.AlgebraicType})
+			}
+		}
+	}
+	return nil
+}
+
+func nameFromJSON(name jsonOptionalName) string {
+	if name.Some == nil {
+		return ""
+	}
+	return *name.Some
+}
+
+func kindFromName(name string) (AlgebraicTypeKind, bool) {
+	for i, candidate := range kindNames {
+		if candidate == name {
+			return AlgebraicTypeKind(i), true
+		}
+	}
+	return 0, false
+}
+
+func (ts Typespace) MarshalJSON() ([]byte, error) {
+	types := ts.Types
+	if types == nil {
+		types = []AlgebraicType{}
+	}
+	return json.Marshal(struct {
+		Types []AlgebraicType `json:"types"`
+	}{Types: types})
+}
+
+func (ts *Typespace) UnmarshalJSON(data []byte) error {
+	var wire struct {
+		Types []AlgebraicType `json:"types"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	ts.Types = wire.Types
+	return nil
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAlgebraicTypeJSONRoundTrip(t *testing.T) {
+	ts := Typespace{Types: []AlgebraicType{
+		ProductOf(
+			ProductTypeElement{Name: "id", Type: PrimitiveType(KindU32)},
+			ProductTypeElement{Name: "owner", Type: IdentityType()},
+			ProductTypeElement{Name: "tags", Type: ArrayType(PrimitiveType(KindString))},
+			ProductTypeElement{Name: "parent", Type: OptionType(RefType(0))},
+			ProductTypeElement{Type: PrimitiveType(KindF64)},
+		),
+		SumOf(
+			SumTypeVariant{Name: "Idle", Type: UnitType()},
+			SumTypeVariant{Name: "Moving", Type: RefType(0)},
+		),
+	}}
+
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatalf("marshal typespace: %v", err)
+	}
+	var decoded Typespace
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal typespace: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, ts) {
+		t.Fatalf("typespace round trip mismatch:\n got %#v\nwant %#v", decoded, ts)
+	}
+}
+
+func TestAlgebraicTypeJSONModuleDefForm(t *testing.T) {
+	raw := `{"Product":{"elements":[
+		{"name":{"some":"id"},"algebraic_type":{"U32":[]}},
+		{"name":{"none":[]},"algebraic_type":{"Array":{"U8":[]}}},
+		{"name":{"some":"next"},"algebraic_type":{"Ref":3}}
+	]}}`
+	var ty AlgebraicType
+	if err := json.Unmarshal([]byte(raw), &ty); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := ProductOf(
+		ProductTypeElement{Name: "id", Type: PrimitiveType(KindU32)},
+		ProductTypeElement{Type: ArrayType(PrimitiveType(KindU8))},
+		ProductTypeElement{Name: "next", Type: RefType(3)},
+	)
+	if !reflect.DeepEqual(ty, want) {
+		t.Fatalf("unexpected type: %s", ty)
+	}
+
+	encoded, err := json.Marshal(PrimitiveType(KindBool))
+	if err != nil {
+		t.Fatalf("marshal bool: %v", err)
+	}
+	if string(encoded) != `{"Bool":[]}` {
+		t.Fatalf("unexpected primitive encoding: %s", encoded)
+	}
+}
+
+func TestAlgebraicTypeJSONErrors(t *testing.T) {
+	for _, raw := range []string{`[]`, `{}`, `{"U32":[],"U64":[]}`, `{"Nope":[]}`, `{"Ref":"x"}`} {
+		var ty AlgebraicType
+		if err := json.Unmarshal([]byte(raw), &ty); err == nil {
+			t.Fatalf("expected %s to fail", raw)
+		}
+	}
+	if _, err := json.Marshal(AlgebraicType{Kind: KindArray}); err == nil {
+		t.Fatalf("expected array without element type to fail")
+	}
+}
+
+func TestTypespaceResolve(t *testing.T) {
+	ts := &Typespace{Types: []AlgebraicType{RefType(1), PrimitiveType(KindString), RefType(2)}}
+
+	resolved, err := ts.Resolve(RefType(0))
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if resolved.Kind != KindString {
+		t.Fatalf("unexpected resolved type: %s", resolved)
+	}
+
+	if _, err := ts.Resolve(RefType(9)); err == nil || !strings.Contains(err.Error(), "unknown type ref 9") {
+		t.Fatalf("expected unknown ref error, got %v", err)
+	}
+	if _, err := ts.Resolve(RefType(2)); err == nil {
+		t.Fatalf("expected self-referential ref to fail")
+	}
+	var nilSpace *Typespace
+	if _, err := nilSpace.Resolve(PrimitiveType(KindU8)); err != nil {
+		t.Fatalf("nil typespace should resolve non-refs: %v", err)
+	}
+}
+
+func TestAlgebraicTypeString(t *testing.T) {
+	ty := ProductOf(
+		ProductTypeElement{Name: "id", Type: PrimitiveType(KindU64)},
+		ProductTypeElement{Name: "name", Type: OptionType(PrimitiveType(KindString))},
+		ProductTypeElement{Name: "at", Type: TimestampType()},
+		ProductTypeElement{Name: "data", Type: ArrayType(RefType(4))},
+	)
+	want := "(id: U64, name: Option<String>, at: __timestamp_micros_since_unix_epoch__, data: Array<&4>)"
+	if got := ty.String(); got != want {
+		t.Fatalf("unexpected string:\n got %s\nwant %s", got, want)
+	}
+	if !OptionType(UnitType()).IsOption() || SumOf(SumTypeVariant{Name: "some"}).IsOption() {
+		t.Fatalf("unexpected IsOption result")
+	}
+}
@@ -0,0 +1,404 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+)
+
+// maxDecodeDepth bounds nesting so a recursive type over hostile input
+// cannot exhaust the stack.
+const maxDecodeDepth = 256
+
+// AlgebraicValue is a decoded SATS value together with its resolved type.
+//
+// The dynamic type of Value depends on Type:
+//   - Bool, integers up to 64 bits, F32, F64 and String: the matching Go type
+//   - I128, U128, I256, U256: the types of the same name in this package
+//   - Array of U8: []byte
+//   - other arrays: []AlgebraicValue
+//   - Identity, ConnectionId, Timestamp, TimeDuration and Uuid products:
+//     the types of the same name in this package
+//   - other products: ProductValue
+//   - sums, including options: SumValue
+type AlgebraicValue struct {
+	Type  AlgebraicType
+	Value any
+}
+
+// ProductValue holds the elements of a product, in type order.
+type ProductValue struct {
+	Elements []AlgebraicValue
+}
+
+// SumValue holds the chosen variant of a sum.
+type SumValue struct {
+	Tag   uint8
+	Value AlgebraicValue
+}
+
+// Field returns the element of a product value with the given name.
+func (v AlgebraicValue) Field(name string) (AlgebraicValue, bool) {
+	product, ok := v.Value.(ProductValue)
+	if !ok || v.Type.Product == nil {
+		return AlgebraicValue{}, false
+	}
+	for i, element := range v.Type.Product.Elements {
+		if element.Name == name && i < len(product.Elements) {
+			return product.Elements[i], true
+		}
+	}
+	return AlgebraicValue{}, false
+}
+
+// Variant returns the variant name and payload of a sum value.
+func (v AlgebraicValue) Variant() (string, AlgebraicValue, bool) {
+	sum, ok := v.Value.(SumValue)
+	if !ok || v.Type.Sum == nil || int(sum.Tag) >= len(v.Type.Sum.Variants) {
+		return "", AlgebraicValue{}, false
+	}
+	return v.Type.Sum.Variants[sum.Tag].Name, sum.Value, true
+}
+
+// Option returns the payload of an option value and whether it is present.
+func (v AlgebraicValue) Option() (AlgebraicValue, bool) {
+	if !v.Type.IsOption() {
+		return AlgebraicValue{}, false
+	}
+	sum, ok := v.Value.(SumValue)
+	if !ok || sum.Tag != bsatn.OptionSomeTag {
+		return AlgebraicValue{}, false
+	}
+	return sum.Value, true
+}
+
+// DecodeValue decodes one BSATN value of type t from data, which must contain
+// exactly that value. Refs in t are resolved through ts, which may be nil if
+// t contains none.
+func (ts *Typespace) DecodeValue(t AlgebraicType, data []byte) (AlgebraicValue, error) {
+	r := bsatn.NewReader(data)
+	v, err := ts.decode(r, t, 0)
+	if err != nil {
+		return AlgebraicValue{}, fmt.Errorf("decode %s: %w", t, err)
+	}
+	if !r.Done() {
+		return AlgebraicValue{}, fmt.Errorf("decode %s: %w (%d bytes)", t, bsatn.ErrTrailingBytes, r.Remaining())
+	}
+	return v, nil
+}
+
+// EncodeValue encodes v as BSATN according to v.Type.
+func (ts *Typespace) EncodeValue(v AlgebraicValue) ([]byte, error) {
+	w := &bsatn.Writer{}
+	if err := ts.encode(w, v, 0); err != nil {
+		return nil, fmt.Errorf("encode %s: %w", v.Type, err)
+	}
+	return w.Bytes(), nil
+}
+
+func (ts *Typespace) decode(r *bsatn.Reader, t AlgebraicType, depth int) (AlgebraicValue, error) {
+	if depth > maxDecodeDepth {
+		return AlgebraicValue{}, fmt.Errorf("value nested deeper than %d levels", maxDecodeDepth)
+	}
+	t, err := ts.Resolve(t)
+	if err != nil {
+		return AlgebraicValue{}, err
+	}
+
+	out := AlgebraicValue{Type: t}
+	switch t.Kind {
+	case KindBool:
+		out.Value, err = r.ReadBool()
+	case KindI8:
+		out.Value, err = r.ReadI8()
+	case KindU8:
+		out.Value, err = r.ReadU8()
+	case KindI16:
+		out.Value, err = r.ReadI16()
+	case KindU16:
+		out.Value, err = r.ReadU16()
+	case KindI32:
+		out.Value, err = r.ReadI32()
+	case KindU32:
+		out.Value, err = r.ReadU32()
+	case KindI64:
+		out.Value, err = r.ReadI64()
+	case KindU64:
+		out.Value, err = r.ReadU64()
+	case KindI128:
+		out.Value, err = decodeUnmarshaler[I128](r)
+	case KindU128:
+		out.Value, err = decodeUnmarshaler[U128](r)
+	case KindI256:
+		out.Value, err = decodeUnmarshaler[I256](r)
+	case KindU256:
+		out.Value, err = decodeUnmarshaler[U256](r)
+	case KindF32:
+		out.Value, err = r.ReadF32()
+	case KindF64:
+		out.Value, err = r.ReadF64()
+	case KindString:
+		out.Value, err = r.ReadString()
+	case KindArray:
+		out.Value, err = ts.decodeArray(r, t, depth)
+	case KindProduct:
+		out.Value, err = ts.decodeProduct(r, t, depth)
+	case KindSum:
+		out.Value, err = ts.decodeSum(r, t, depth)
+	default:
+		err = fmt.Errorf("unknown type kind %s", t.Kind)
+	}
+	if err != nil {
+		return AlgebraicValue{}, err
+	}
+	return out, nil
+}
+
+func (ts *Typespace) decodeArray(r *bsatn.Reader, t AlgebraicType, depth int) (any, error) {
+	if t.Elem == nil {
+		return nil, fmt.Errorf("array type without element type")
+	}
+	elem, err := ts.Resolve(*t.Elem)
+	if err != nil {
+		return nil, err
+	}
+	if elem.Kind == KindU8 {
+		raw, err := r.ReadBytes()
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), raw...), nil
+	}
+
+	n, err := r.ReadElementCount()
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]AlgebraicValue, 0, min(n, r.Remaining()))
+	for i := 0; i < n; i++ {
+		v, err := ts.decode(r, elem, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		elements = append(elements, v)
+	}
+	return elements, nil
+}
+
+func (ts *Typespace) decodeProduct(r *bsatn.Reader, t AlgebraicType, depth int) (any, error) {
+	switch t.specialKind() {
+	case identityTag:
+		return decodeUnmarshaler[Identity](r)
+	case connectionIdTag:
+		return decodeUnmarshaler[ConnectionId](r)
+	case timestampTag:
+		return decodeUnmarshaler[Timestamp](r)
+	case timeDurationTag:
+		return decodeUnmarshaler[TimeDuration](r)
+	case uuidTag:
+		return decodeUnmarshaler[Uuid](r)
+	}
+
+	var fields []ProductTypeElement
+	if t.Product != nil {
+		fields = t.Product.Elements
+	}
+	elements := make([]AlgebraicValue, 0, len(fields))
+	for i, element := range fields {
+		v, err := ts.decode(r, element.Type, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", elementLabel(element.Name, i), err)
+		}
+		elements = append(elements, v)
+	}
+	return ProductValue{Elements: elements}, nil
+}
+
+func (ts *Typespace) decodeSum(r *bsatn.Reader, t AlgebraicType, depth int) (any, error) {
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return nil, err
+	}
+	if t.Sum == nil || int(tag) >= len(t.Sum.Variants) {
+		return nil, r.InvalidTag(t.String(), tag)
+	}
+	variant := t.Sum.Variants[tag]
+	v, err := ts.decode(r, variant.Type, depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("variant %s: %w", elementLabel(variant.Name, int(tag)), err)
+	}
+	return SumValue{Tag: tag, Value: v}, nil
+}
+
+func (ts *Typespace) encode(w *bsatn.Writer, v AlgebraicValue, depth int) error {
+	if depth > maxDecodeDepth {
+		return fmt.Errorf("value nested deeper than %d levels", maxDecodeDepth)
+	}
+	t, err := ts.Resolve(v.Type)
+	if err != nil {
+		return err
+	}
+
+	switch t.Kind {
+	case KindBool:
+		return encodeScalar(v.Value, w.WriteBool)
+	case KindI8:
+		return encodeScalar(v.Value, w.WriteI8)
+	case KindU8:
+		return encodeScalar(v.Value, w.WriteU8)
+	case KindI16:
+		return encodeScalar(v.Value, w.WriteI16)
+	case KindU16:
+		return encodeScalar(v.Value, w.WriteU16)
+	case KindI32:
+		return encodeScalar(v.Value, w.WriteI32)
+	case KindU32:
+		return encodeScalar(v.Value, w.WriteU32)
+	case KindI64:
+		return encodeScalar(v.Value, w.WriteI64)
+	case KindU64:
+		return encodeScalar(v.Value, w.WriteU64)
+	case KindF32:
+		return encodeScalar(v.Value, w.WriteF32)
+	case KindF64:
+		return encodeScalar(v.Value, w.WriteF64)
+	case KindI128:
+		return encodeMarshaler[I128](w, v.Value)
+	case KindU128:
+		return encodeMarshaler[U128](w, v.Value)
+	case KindI256:
+		return encodeMarshaler[I256](w, v.Value)
+	case KindU256:
+		return encodeMarshaler[U256](w, v.Value)
+	case KindString:
+		s, ok := v.Value.(string)
+		if !ok {
+			return valueTypeError(t, v.Value)
+		}
+		return w.WriteString(s)
+	case KindArray:
+		return ts.encodeArray(w, t, v.Value, depth)
+	case KindProduct:
+		return ts.encodeProduct(w, t, v.Value, depth)
+	case KindSum:
+		sum, ok := v.Value.(SumValue)
+		if !ok {
+			return valueTypeError(t, v.Value)
+		}
+		if t.Sum == nil || int(sum.Tag) >= len(t.Sum.Variants) {
+			return fmt.Errorf("%w: tag %d for %s", bsatn.ErrInvalidTag, sum.Tag, t)
+		}
+		w.WriteSumTag(sum.Tag)
+		payload := sum.Value
+		payload.Type = t.Sum.Variants[sum.Tag].Type
+		return ts.encode(w, payload, depth+1)
+	default:
+		return fmt.Errorf("unknown type kind %s", t.Kind)
+	}
+}
+
+func (ts *Typespace) encodeArray(w *bsatn.Writer, t AlgebraicType, value any, depth int) error {
+	if t.Elem == nil {
+		return fmt.Errorf("array type without element type")
+	}
+	if raw, ok := value.([]byte); ok {
+		elem, err := ts.Resolve(*t.Elem)
+		if err != nil {
+			return err
+		}
+		if elem.Kind != KindU8 {
+			return valueTypeError(t, value)
+		}
+		return w.WriteBytes(raw)
+	}
+	elements, ok := value.([]AlgebraicValue)
+	if !ok {
+		return valueTypeError(t, value)
+	}
+	if err := w.WriteArrayLen(len(elements)); err != nil {
+		return err
+	}
+	for i, element := range elements {
+		element.Type = *t.Elem
+		if err := ts.encode(w, element, depth+1); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (ts *Typespace) encodeProduct(w *bsatn.Writer, t AlgebraicType, value any, depth int) error {
+	switch t.specialKind() {
+	case identityTag:
+		return encodeMarshaler[Identity](w, value)
+	case connectionIdTag:
+		return encodeMarshaler[ConnectionId](w, value)
+	case timestampTag:
+		return encodeMarshaler[Timestamp](w, value)
+	case timeDurationTag:
+		return encodeMarshaler[TimeDuration](w, value)
+	case uuidTag:
+		return encodeMarshaler[Uuid](w, value)
+	}
+
+	product, ok := value.(ProductValue)
+	if !ok {
+		return valueTypeError(t, value)
+	}
+	var elements []ProductTypeElement
+	if t.Product != nil {
+		elements = t.Product.Elements
+	}
+	if len(product.Elements) != len(elements) {
+		return fmt.Errorf("product %s has %d elements, got %d", t, len(elements), len(product.Elements))
+	}
+	for i, element := range elements {
+		v := product.Elements[i]
+		v.Type = element.Type
+		if err := ts.encode(w, v, depth+1); err != nil {
+			return fmt.Errorf("field %s: %w", elementLabel(element.Name, i), err)
+		}
+	}
+	return nil
+}
+
+func decodeUnmarshaler[T any, PT interface {
+	*T
+	bsatn.Unmarshaler
+}](r *bsatn.Reader) (any, error) {
+	var v T
+	if err := PT(&v).UnmarshalBSATN(r); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func encodeScalar[T any](value any, write func(T)) error {
+	v, ok := value.(T)
+	if !ok {
+		var zero T
+		return fmt.Errorf("expected %T value, got %T", zero, value)
+	}
+	write(v)
+	return nil
+}
+
+func encodeMarshaler[T bsatn.Marshaler](w *bsatn.Writer, value any) error {
+	v, ok := value.(T)
+	if !ok {
+		var zero T
+		return fmt.Errorf("expected %T value, got %T", zero, value)
+	}
+	return v.MarshalBSATN(w)
+}
+
+func valueTypeError(t AlgebraicType, value any) error {
+	return fmt.Errorf("cannot encode %T as %s", value, t)
+}
+
+func elementLabel(name string, index int) string {
+	if name == "" {
+		return fmt.Sprint(index)
+	}
+	return name
+}
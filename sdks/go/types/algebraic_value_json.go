@@ -0,0 +1,380 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+// MarshalValueJSON encodes v as SATS-JSON according to v.Type.
+//
+// Products with named elements become objects and other products arrays,
+// sums become {"variant": payload}, U8 arrays become hex strings, 256-bit
+// integers become 0x-prefixed hex strings and the builtin products keep their
+// wrapper, such as {"__identity__": "0x..."}. The result reads back exactly
+// with UnmarshalValueJSON.
+func (ts *Typespace) MarshalValueJSON(v AlgebraicValue) ([]byte, error) {
+	out, err := ts.marshalJSON(v, 0)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", v.Type, err)
+	}
+	return out, nil
+}
+
+// UnmarshalValueJSON decodes a SATS-JSON value of type t. Refs in t are
+// resolved through ts, which may be nil if t contains none.
+func (ts *Typespace) UnmarshalValueJSON(t AlgebraicType, data []byte) (AlgebraicValue, error) {
+	if !json.Valid(data) {
+		return AlgebraicValue{}, fmt.Errorf("decode %s: %w: invalid json", t, satsjson.ErrSyntax)
+	}
+	v, err := ts.unmarshalJSON(t, data, 0)
+	if err != nil {
+		return AlgebraicValue{}, fmt.Errorf("decode %s: %w", t, err)
+	}
+	return v, nil
+}
+
+func (ts *Typespace) marshalJSON(v AlgebraicValue, depth int) ([]byte, error) {
+	if depth > maxDecodeDepth {
+		return nil, fmt.Errorf("value nested deeper than %d levels", maxDecodeDepth)
+	}
+	t, err := ts.Resolve(v.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.Kind {
+	case KindBool:
+		b, ok := v.Value.(bool)
+		if !ok {
+			return nil, valueTypeError(t, v.Value)
+		}
+		return []byte(strconv.FormatBool(b)), nil
+	case KindI8:
+		return marshalIntJSON[int8](t, v.Value)
+	case KindI16:
+		return marshalIntJSON[int16](t, v.Value)
+	case KindI32:
+		return marshalIntJSON[int32](t, v.Value)
+	case KindI64:
+		return marshalIntJSON[int64](t, v.Value)
+	case KindU8:
+		return marshalUintJSON[uint8](t, v.Value)
+	case KindU16:
+		return marshalUintJSON[uint16](t, v.Value)
+	case KindU32:
+		return marshalUintJSON[uint32](t, v.Value)
+	case KindU64:
+		return marshalUintJSON[uint64](t, v.Value)
+	case KindF32:
+		f, ok := v.Value.(float32)
+		if !ok {
+			return nil, valueTypeError(t, v.Value)
+		}
+		return satsjson.EncodeFloat(float64(f), 32)
+	case KindF64:
+		f, ok := v.Value.(float64)
+		if !ok {
+			return nil, valueTypeError(t, v.Value)
+		}
+		return satsjson.EncodeFloat(f, 64)
+	case KindI128:
+		return marshalBuiltinJSON[I128](t, v.Value)
+	case KindU128:
+		return marshalBuiltinJSON[U128](t, v.Value)
+	case KindI256:
+		return marshalBuiltinJSON[I256](t, v.Value)
+	case KindU256:
+		return marshalBuiltinJSON[U256](t, v.Value)
+	case KindString:
+		s, ok := v.Value.(string)
+		if !ok {
+			return nil, valueTypeError(t, v.Value)
+		}
+		return satsjson.EncodeString(s), nil
+	case KindArray:
+		return ts.marshalArrayJSON(t, v.Value, depth)
+	case KindProduct:
+		return ts.marshalProductJSON(t, v.Value, depth)
+	case KindSum:
+		sum, ok := v.Value.(SumValue)
+		if !ok {
+			return nil, valueTypeError(t, v.Value)
+		}
+		if t.Sum == nil || int(sum.Tag) >= len(t.Sum.Variants) {
+			return nil, fmt.Errorf("%w: tag %d for %s", satsjson.ErrInvalidTag, sum.Tag, t)
+		}
+		variant := t.Sum.Variants[sum.Tag]
+		payload := sum.Value
+		payload.Type = variant.Type
+		encoded, err := ts.marshalJSON(payload, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("variant %s: %w", elementLabel(variant.Name, int(sum.Tag)), err)
+		}
+		return satsjson.EncodeVariant(variant.Name, sum.Tag, encoded), nil
+	default:
+		return nil, fmt.Errorf("unknown type kind %s", t.Kind)
+	}
+}
+
+func (ts *Typespace) marshalArrayJSON(t AlgebraicType, value any, depth int) ([]byte, error) {
+	if t.Elem == nil {
+		return nil, fmt.Errorf("array type without element type")
+	}
+	if raw, ok := value.([]byte); ok {
+		elem, err := ts.Resolve(*t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		if elem.Kind != KindU8 {
+			return nil, valueTypeError(t, value)
+		}
+		return satsjson.EncodeBytes(raw), nil
+	}
+	elements, ok := value.([]AlgebraicValue)
+	if !ok {
+		return nil, valueTypeError(t, value)
+	}
+	encoded := make([][]byte, len(elements))
+	for i, element := range elements {
+		element.Type = *t.Elem
+		out, err := ts.marshalJSON(element, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		encoded[i] = out
+	}
+	return satsjson.EncodeProduct(nil, encoded), nil
+}
+
+func (ts *Typespace) marshalProductJSON(t AlgebraicType, value any, depth int) ([]byte, error) {
+	switch t.specialKind() {
+	case identityTag:
+		return marshalBuiltinJSON[Identity](t, value)
+	case connectionIdTag:
+		return marshalBuiltinJSON[ConnectionId](t, value)
+	case timestampTag:
+		return marshalBuiltinJSON[Timestamp](t, value)
+	case timeDurationTag:
+		return marshalBuiltinJSON[TimeDuration](t, value)
+	case uuidTag:
+		return marshalBuiltinJSON[Uuid](t, value)
+	}
+
+	product, ok := value.(ProductValue)
+	if !ok {
+		return nil, valueTypeError(t, value)
+	}
+	var elements []ProductTypeElement
+	if t.Product != nil {
+		elements = t.Product.Elements
+	}
+	if len(product.Elements) != len(elements) {
+		return nil, fmt.Errorf("product %s has %d elements, got %d", t, len(elements), len(product.Elements))
+	}
+	names := make([]string, len(elements))
+	encoded := make([][]byte, len(elements))
+	for i, element := range elements {
+		v := product.Elements[i]
+		v.Type = element.Type
+		out, err := ts.marshalJSON(v, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", elementLabel(element.Name, i), err)
+		}
+		names[i] = element.Name
+		encoded[i] = out
+	}
+	return satsjson.EncodeProduct(names, encoded), nil
+}
+
+func (ts *Typespace) unmarshalJSON(t AlgebraicType, data []byte, depth int) (AlgebraicValue, error) {
+	if depth > maxDecodeDepth {
+		return AlgebraicValue{}, fmt.Errorf("value nested deeper than %d levels", maxDecodeDepth)
+	}
+	t, err := ts.Resolve(t)
+	if err != nil {
+		return AlgebraicValue{}, err
+	}
+
+	out := AlgebraicValue{Type: t}
+	switch t.Kind {
+	case KindBool:
+		out.Value, err = satsjson.DecodeBool(data)
+	case KindI8:
+		out.Value, err = unmarshalIntJSON[int8](data, 8)
+	case KindI16:
+		out.Value, err = unmarshalIntJSON[int16](data, 16)
+	case KindI32:
+		out.Value, err = unmarshalIntJSON[int32](data, 32)
+	case KindI64:
+		out.Value, err = unmarshalIntJSON[int64](data, 64)
+	case KindU8:
+		out.Value, err = unmarshalUintJSON[uint8](data, 8)
+	case KindU16:
+		out.Value, err = unmarshalUintJSON[uint16](data, 16)
+	case KindU32:
+		out.Value, err = unmarshalUintJSON[uint32](data, 32)
+	case KindU64:
+		out.Value, err = unmarshalUintJSON[uint64](data, 64)
+	case KindF32:
+		var f float64
+		f, err = satsjson.DecodeFloat(data, 32)
+		out.Value = float32(f)
+	case KindF64:
+		out.Value, err = satsjson.DecodeFloat(data, 64)
+	case KindI128:
+		out.Value, err = unmarshalBuiltinJSON[I128](data)
+	case KindU128:
+		out.Value, err = unmarshalBuiltinJSON[U128](data)
+	case KindI256:
+		out.Value, err = unmarshalBuiltinJSON[I256](data)
+	case KindU256:
+		out.Value, err = unmarshalBuiltinJSON[U256](data)
+	case KindString:
+		out.Value, err = satsjson.DecodeString(data)
+	case KindArray:
+		out.Value, err = ts.unmarshalArrayJSON(t, data, depth)
+	case KindProduct:
+		out.Value, err = ts.unmarshalProductJSON(t, data, depth)
+	case KindSum:
+		out.Value, err = ts.unmarshalSumJSON(t, data, depth)
+	default:
+		err = fmt.Errorf("unknown type kind %s", t.Kind)
+	}
+	if err != nil {
+		return AlgebraicValue{}, err
+	}
+	return out, nil
+}
+
+func (ts *Typespace) unmarshalArrayJSON(t AlgebraicType, data []byte, depth int) (any, error) {
+	if t.Elem == nil {
+		return nil, fmt.Errorf("array type without element type")
+	}
+	elem, err := ts.Resolve(*t.Elem)
+	if err != nil {
+		return nil, err
+	}
+	if elem.Kind == KindU8 {
+		return satsjson.DecodeBytes(data)
+	}
+
+	raw, err := satsjson.DecodeArray(data)
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]AlgebraicValue, 0, len(raw))
+	for i, element := range raw {
+		v, err := ts.unmarshalJSON(elem, element, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		elements = append(elements, v)
+	}
+	return elements, nil
+}
+
+func (ts *Typespace) unmarshalProductJSON(t AlgebraicType, data []byte, depth int) (any, error) {
+	switch t.specialKind() {
+	case identityTag:
+		return unmarshalBuiltinJSON[Identity](data)
+	case connectionIdTag:
+		return unmarshalBuiltinJSON[ConnectionId](data)
+	case timestampTag:
+		return unmarshalBuiltinJSON[Timestamp](data)
+	case timeDurationTag:
+		return unmarshalBuiltinJSON[TimeDuration](data)
+	case uuidTag:
+		return unmarshalBuiltinJSON[Uuid](data)
+	}
+
+	var elements []ProductTypeElement
+	if t.Product != nil {
+		elements = t.Product.Elements
+	}
+	names := make([]string, len(elements))
+	for i, element := range elements {
+		names[i] = element.Name
+	}
+	raw, err := satsjson.DecodeProduct(data, names)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]AlgebraicValue, 0, len(elements))
+	for i, element := range elements {
+		v, err := ts.unmarshalJSON(element.Type, raw[i], depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", elementLabel(element.Name, i), err)
+		}
+		values = append(values, v)
+	}
+	return ProductValue{Elements: values}, nil
+}
+
+func (ts *Typespace) unmarshalSumJSON(t AlgebraicType, data []byte, depth int) (any, error) {
+	var variants []SumTypeVariant
+	if t.Sum != nil {
+		variants = t.Sum.Variants
+	}
+	names := make([]string, len(variants))
+	for i, variant := range variants {
+		names[i] = variant.Name
+	}
+	tag, payload, err := satsjson.DecodeVariant(data, names)
+	if err != nil {
+		return nil, err
+	}
+	variant := variants[tag]
+	v, err := ts.unmarshalJSON(variant.Type, payload, depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("variant %s: %w", elementLabel(variant.Name, int(tag)), err)
+	}
+	return SumValue{Tag: tag, Value: v}, nil
+}
+
+func marshalIntJSON[T int8 | int16 | int32 | int64](t AlgebraicType, value any) ([]byte, error) {
+	v, ok := value.(T)
+	if !ok {
+		return nil, valueTypeError(t, value)
+	}
+	return []byte(strconv.FormatInt(int64(v), 10)), nil
+}
+
+func marshalUintJSON[T uint8 | uint16 | uint32 | uint64](t AlgebraicType, value any) ([]byte, error) {
+	v, ok := value.(T)
+	if !ok {
+		return nil, valueTypeError(t, value)
+	}
+	return []byte(strconv.FormatUint(uint64(v), 10)), nil
+}
+
+func marshalBuiltinJSON[T satsjson.Marshaler](t AlgebraicType, value any) ([]byte, error) {
+	v, ok := value.(T)
+	if !ok {
+		return nil, valueTypeError(t, value)
+	}
+	return v.MarshalSATSJSON()
+}
+
+func unmarshalIntJSON[T int8 | int16 | int32 | int64](data []byte, bits int) (any, error) {
+	v, err := satsjson.ParseInt(data, bits)
+	return T(v), err
+}
+
+func unmarshalUintJSON[T uint8 | uint16 | uint32 | uint64](data []byte, bits int) (any, error) {
+	v, err := satsjson.ParseUint(data, bits)
+	return T(v), err
+}
+
+func unmarshalBuiltinJSON[T any, PT interface {
+	*T
+	satsjson.Unmarshaler
+}](data []byte) (any, error) {
+	var v T
+	if err := PT(&v).UnmarshalSATSJSON(data); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
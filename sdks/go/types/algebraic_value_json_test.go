@@ -0,0 +1,171 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+type jsonPos struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+}
+
+type jsonPlayer struct {
+	ID       uint64    `json:"id"`
+	Name     string    `json:"name"`
+	Owner    Identity  `json:"owner"`
+	JoinedAt Timestamp `json:"joined_at"`
+	Score    *int32    `json:"score"`
+	Avatar   []byte    `json:"avatar"`
+	Pos      jsonPos   `json:"pos"`
+	Big      U128      `json:"big"`
+	Tags     []string  `json:"tags"`
+}
+
+func TestValueJSONMatchesStructEncoding(t *testing.T) {
+	owner, _ := ParseIdentity(testIdentityHex)
+	score := int32(-7)
+	row := jsonPlayer{
+		ID:       42,
+		Name:     "alice",
+		Owner:    owner,
+		JoinedAt: TimestampFromMicros(1_700_000_000_000_000),
+		Score:    &score,
+		Avatar:   []byte{1, 2, 3},
+		Pos:      jsonPos{X: 1.5, Y: -2},
+		Big:      MaxU128,
+		Tags:     []string{"a"},
+	}
+	data, err := bsatn.Marshal(row)
+	if err != nil {
+		t.Fatalf("marshal bsatn: %v", err)
+	}
+	ts := playerTypespace()
+	value, err := ts.DecodeValue(RefType(0), data)
+	if err != nil {
+		t.Fatalf("decode bsatn: %v", err)
+	}
+
+	dynamic, err := ts.MarshalValueJSON(value)
+	if err != nil {
+		t.Fatalf("marshal value json: %v", err)
+	}
+	want := `{"id":42,"name":"alice",` +
+		`"owner":{"__identity__":"0x` + testIdentityHex + `"},` +
+		`"joined_at":{"__timestamp_micros_since_unix_epoch__":1700000000000000},` +
+		`"score":{"some":-7},"avatar":"010203","pos":{"x":1.5,"y":-2},` +
+		`"big":340282366920938463463374607431768211455,"tags":["a"]}`
+	if string(dynamic) != want {
+		t.Fatalf("unexpected json:\n got %s\nwant %s", dynamic, want)
+	}
+
+	typed, err := satsjson.Marshal(row)
+	if err != nil {
+		t.Fatalf("marshal struct json: %v", err)
+	}
+	if string(typed) != string(dynamic) {
+		t.Fatalf("struct and dynamic encodings differ:\n struct  %s\n dynamic %s", typed, dynamic)
+	}
+
+	decoded, err := ts.UnmarshalValueJSON(RefType(0), dynamic)
+	if err != nil {
+		t.Fatalf("unmarshal value json: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, value) {
+		t.Fatalf("json round trip mismatch:\n got %#v\nwant %#v", decoded, value)
+	}
+
+	var back jsonPlayer
+	if err := satsjson.Unmarshal(dynamic, &back); err != nil {
+		t.Fatalf("unmarshal struct json: %v", err)
+	}
+	if !reflect.DeepEqual(back, row) {
+		t.Fatalf("struct round trip mismatch:\n got %+v\nwant %+v", back, row)
+	}
+}
+
+func TestValueJSONSumsAndWideIntegers(t *testing.T) {
+	ts := &Typespace{Types: []AlgebraicType{
+		SumOf(
+			SumTypeVariant{Name: "Idle", Type: UnitType()},
+			SumTypeVariant{Name: "Moving", Type: ProductOf(ProductTypeElement{Type: PrimitiveType(KindI256)})},
+			SumTypeVariant{Type: PrimitiveType(KindI128)},
+		),
+	}}
+	cases := []struct {
+		value AlgebraicValue
+		json  string
+	}{
+		{
+			AlgebraicValue{Type: RefType(0), Value: SumValue{Tag: 0, Value: AlgebraicValue{Value: ProductValue{}}}},
+			`{"Idle":[]}`,
+		},
+		{
+			AlgebraicValue{Type: RefType(0), Value: SumValue{Tag: 1, Value: AlgebraicValue{Value: ProductValue{
+				Elements: []AlgebraicValue{{Value: I256From64(-255)}},
+			}}}},
+			`{"Moving":["-0xff"]}`,
+		},
+		{
+			AlgebraicValue{Type: RefType(0), Value: SumValue{Tag: 2, Value: AlgebraicValue{Value: MinI128}}},
+			`[2,-170141183460469231731687303715884105728]`,
+		},
+	}
+	for _, tc := range cases {
+		got, err := ts.MarshalValueJSON(tc.value)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if string(got) != tc.json {
+			t.Fatalf("unexpected json: got %s want %s", got, tc.json)
+		}
+		decoded, err := ts.UnmarshalValueJSON(RefType(0), got)
+		if err != nil {
+			t.Fatalf("unmarshal %s: %v", got, err)
+		}
+		again, err := ts.MarshalValueJSON(decoded)
+		if err != nil || string(again) != tc.json {
+			t.Fatalf("round trip of %s gave %s (%v)", tc.json, again, err)
+		}
+	}
+
+	// The server may also key sums by tag number and quote wide integers.
+	v, err := ts.UnmarshalValueJSON(RefType(0), []byte(`{"2":"-5"}`))
+	if err != nil {
+		t.Fatalf("unmarshal numeric tag: %v", err)
+	}
+	if _, payload, ok := v.Variant(); !ok || payload.Value != I128From64(-5) {
+		t.Fatalf("unexpected variant: %#v", v.Value)
+	}
+}
+
+func TestValueJSONErrors(t *testing.T) {
+	option := OptionType(PrimitiveType(KindU8))
+	cases := []struct {
+		name string
+		ty   AlgebraicType
+		data string
+		want error
+	}{
+		{"invalid json", PrimitiveType(KindU8), `{`, satsjson.ErrSyntax},
+		{"overflow", PrimitiveType(KindI8), `128`, satsjson.ErrIntegerOverflow},
+		{"bad variant", option, `{"maybe":1}`, satsjson.ErrInvalidTag},
+		{"missing field", ProductOf(ProductTypeElement{Name: "a", Type: PrimitiveType(KindBool)}), `{}`, satsjson.ErrMissingField},
+		{"bad element", ArrayType(PrimitiveType(KindString)), `["a", 1]`, satsjson.ErrSyntax},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := (*Typespace)(nil).UnmarshalValueJSON(tc.ty, []byte(tc.data)); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+
+	if _, err := (*Typespace)(nil).MarshalValueJSON(AlgebraicValue{Type: PrimitiveType(KindF64), Value: 1}); err == nil {
+		t.Fatalf("expected mismatched value to fail")
+	}
+}
@@ -0,0 +1,243 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+)
+
+type playerRow struct {
+	ID       uint64
+	Name     string
+	Owner    Identity
+	JoinedAt Timestamp
+	Score    *int32
+	Avatar   []byte
+	Pos      struct{ X, Y float32 }
+	Big      U128
+	Tags     []string
+}
+
+func playerTypespace() *Typespace {
+	return &Typespace{Types: []AlgebraicType{
+		ProductOf(
+			ProductTypeElement{Name: "id", Type: PrimitiveType(KindU64)},
+			ProductTypeElement{Name: "name", Type: PrimitiveType(KindString)},
+			ProductTypeElement{Name: "owner", Type: IdentityType()},
+			ProductTypeElement{Name: "joined_at", Type: TimestampType()},
+			ProductTypeElement{Name: "score", Type: OptionType(PrimitiveType(KindI32))},
+			ProductTypeElement{Name: "avatar", Type: ArrayType(PrimitiveType(KindU8))},
+			ProductTypeElement{Name: "pos", Type: RefType(1)},
+			ProductTypeElement{Name: "big", Type: PrimitiveType(KindU128)},
+			ProductTypeElement{Name: "tags", Type: ArrayType(PrimitiveType(KindString))},
+		),
+		ProductOf(
+			ProductTypeElement{Name: "x", Type: PrimitiveType(KindF32)},
+			ProductTypeElement{Name: "y", Type: PrimitiveType(KindF32)},
+		),
+	}}
+}
+
+func TestDecodeValueProductRow(t *testing.T) {
+	owner, _ := ParseIdentity(testIdentityHex)
+	score := int32(-7)
+	row := playerRow{
+		ID:       42,
+		Name:     "alice",
+		Owner:    owner,
+		JoinedAt: TimestampFromMicros(1_700_000_000_000_000),
+		Score:    &score,
+		Avatar:   []byte{1, 2, 3},
+		Big:      U128FromParts(1, 2),
+		Tags:     []string{"a", "b"},
+	}
+	row.Pos.X, row.Pos.Y = 1.5, -2
+	data, err := bsatn.Marshal(row)
+	if err != nil {
+		t.Fatalf("marshal row: %v", err)
+	}
+
+	ts := playerTypespace()
+	v, err := ts.DecodeValue(RefType(0), data)
+	if err != nil {
+		t.Fatalf("decode row: %v", err)
+	}
+	if v.Type.Kind != KindProduct {
+		t.Fatalf("decoded value should carry the resolved type, got %s", v.Type)
+	}
+
+	checks := map[string]any{
+		"id":        uint64(42),
+		"name":      "alice",
+		"owner":     owner,
+		"joined_at": row.JoinedAt,
+		"avatar":    []byte{1, 2, 3},
+		"big":       row.Big,
+	}
+	for name, want := range checks {
+		field, ok := v.Field(name)
+		if !ok {
+			t.Fatalf("missing field %s", name)
+		}
+		if !reflect.DeepEqual(field.Value, want) {
+			t.Fatalf("field %s = %#v, want %#v", name, field.Value, want)
+		}
+	}
+
+	scoreField, _ := v.Field("score")
+	inner, ok := scoreField.Option()
+	if !ok || inner.Value != int32(-7) {
+		t.Fatalf("unexpected score option: %#v", scoreField.Value)
+	}
+
+	pos, _ := v.Field("pos")
+	y, ok := pos.Field("y")
+	if !ok || y.Value != float32(-2) {
+		t.Fatalf("unexpected nested product: %#v", pos.Value)
+	}
+
+	tags, _ := v.Field("tags")
+	elements, ok := tags.Value.([]AlgebraicValue)
+	if !ok || len(elements) != 2 || elements[1].Value != "b" {
+		t.Fatalf("unexpected tags: %#v", tags.Value)
+	}
+
+	if _, ok := v.Field("missing"); ok {
+		t.Fatalf("unexpected field lookup success")
+	}
+
+	encoded, err := ts.EncodeValue(v)
+	if err != nil {
+		t.Fatalf("encode row: %v", err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Fatalf("re-encoded row differs:\n got %v\nwant %v", encoded, data)
+	}
+}
+
+func TestDecodeValueRecursiveSum(t *testing.T) {
+	// List = Nil | Cons(head: U8, tail: List)
+	ts := &Typespace{Types: []AlgebraicType{
+		SumOf(
+			SumTypeVariant{Name: "Nil", Type: UnitType()},
+			SumTypeVariant{Name: "Cons", Type: ProductOf(
+				ProductTypeElement{Name: "head", Type: PrimitiveType(KindU8)},
+				ProductTypeElement{Name: "tail", Type: RefType(0)},
+			)},
+		),
+	}}
+	data := []byte{1, 10, 1, 20, 0}
+
+	v, err := ts.DecodeValue(RefType(0), data)
+	if err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	var heads []uint8
+	for {
+		name, payload, ok := v.Variant()
+		if !ok {
+			t.Fatalf("expected sum value, got %#v", v.Value)
+		}
+		if name == "Nil" {
+			break
+		}
+		head, _ := payload.Field("head")
+		heads = append(heads, head.Value.(uint8))
+		v, _ = payload.Field("tail")
+	}
+	if !reflect.DeepEqual(heads, []uint8{10, 20}) {
+		t.Fatalf("unexpected list contents: %v", heads)
+	}
+
+	root, _ := ts.DecodeValue(RefType(0), data)
+	encoded, err := ts.EncodeValue(root)
+	if err != nil {
+		t.Fatalf("encode list: %v", err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Fatalf("unexpected list encoding: %v", encoded)
+	}
+}
+
+func TestDecodeValueSpecialTypes(t *testing.T) {
+	id, _ := ParseConnectionId("000102030405060708090a0b0c0d0e0f")
+	uuid, _ := ParseUuid("123e4567-e89b-12d3-a456-426614174000")
+	cases := []struct {
+		ty   AlgebraicType
+		want any
+	}{
+		{ConnectionIdType(), id},
+		{UuidType(), uuid},
+		{TimeDurationType(), TimeDurationFromMicros(-5)},
+		{PrimitiveType(KindI256), I256From64(-3)},
+	}
+	for _, tc := range cases {
+		data, err := bsatn.Marshal(tc.want)
+		if err != nil {
+			t.Fatalf("marshal %s: %v", tc.ty, err)
+		}
+		v, err := (*Typespace)(nil).DecodeValue(tc.ty, data)
+		if err != nil {
+			t.Fatalf("decode %s: %v", tc.ty, err)
+		}
+		if v.Value != tc.want {
+			t.Fatalf("decode %s = %#v, want %#v", tc.ty, v.Value, tc.want)
+		}
+	}
+}
+
+func TestDecodeValueErrors(t *testing.T) {
+	ts := playerTypespace()
+	option := OptionType(PrimitiveType(KindU8))
+
+	cases := []struct {
+		name string
+		ty   AlgebraicType
+		data []byte
+		want error
+	}{
+		{"trailing bytes", PrimitiveType(KindU8), []byte{1, 2}, bsatn.ErrTrailingBytes},
+		{"short input", PrimitiveType(KindU32), []byte{1, 2}, bsatn.ErrUnexpectedEOF},
+		{"bad tag", option, []byte{2}, bsatn.ErrInvalidTag},
+		{"bad bool", PrimitiveType(KindBool), []byte{7}, bsatn.ErrInvalidBool},
+		{"short nested", RefType(1), []byte{0, 0, 0, 0, 1}, bsatn.ErrUnexpectedEOF},
+		{"hostile zero-sized array", ArrayType(UnitType()), []byte{0xff, 0xff, 0xff, 0xff}, bsatn.ErrArrayTooLong},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ts.DecodeValue(tc.ty, tc.data); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+
+	if _, err := ts.DecodeValue(RefType(5), []byte{0}); err == nil {
+		t.Fatalf("expected unknown ref to fail")
+	}
+	units, err := ts.DecodeValue(ArrayType(UnitType()), []byte{3, 0, 0, 0})
+	if err != nil {
+		t.Fatalf("decode unit array: %v", err)
+	}
+	if elements, _ := units.Value.([]AlgebraicValue); len(elements) != 3 {
+		t.Fatalf("expected three units, got %#v", units.Value)
+	}
+}
+
+func TestEncodeValueRejectsMismatchedValues(t *testing.T) {
+	cases := []AlgebraicValue{
+		{Type: PrimitiveType(KindU32), Value: int32(1)},
+		{Type: PrimitiveType(KindString), Value: []byte("x")},
+		{Type: ArrayType(PrimitiveType(KindU16)), Value: []byte{1}},
+		{Type: IdentityType(), Value: ConnectionId{}},
+		{Type: OptionType(PrimitiveType(KindU8)), Value: SumValue{Tag: 3}},
+		{Type: ProductOf(ProductTypeElement{Name: "a", Type: PrimitiveType(KindU8)}), Value: ProductValue{}},
+	}
+	for _, v := range cases {
+		if _, err := (*Typespace)(nil).EncodeValue(v); err == nil {
+			t.Fatalf("expected encoding %#v as %s to fail", v.Value, v.Type)
+		}
+	}
+}
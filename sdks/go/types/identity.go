@@ -0,0 +1,232 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+// Identity is a 256-bit SpacetimeDB identity.
+//
+// The bytes are stored big-endian, in the order they are displayed as hex.
+// On the wire an Identity is a little-endian u256. Identity is comparable and
+// can be used directly as a map key.
+type Identity [32]byte
+
+// ConnectionId is a 128-bit SpacetimeDB connection id, stored big-endian like
+// Identity. On the wire it is a little-endian u128.
+type ConnectionId [16]byte
+
+// ParseIdentity parses a 64-character hex string, with or without a 0x prefix.
+func ParseIdentity(s string) (Identity, error) {
+	var id Identity
+	err := parseHexWord(id[:], "identity", s)
+	return id, err
+}
+
+// IdentityFromBytes returns the Identity with the given big-endian bytes.
+func IdentityFromBytes(b []byte) (Identity, error) {
+	var id Identity
+	if len(b) != len(id) {
+		return id, fmt.Errorf("identity: expected %d bytes, got %d", len(id), len(b))
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// String returns the identity as 64 lowercase hex characters.
+func (id Identity) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Bytes returns a copy of the big-endian identity bytes.
+func (id Identity) Bytes() []byte {
+	return bytes.Clone(id[:])
+}
+
+// IsZero reports whether id is the all-zero identity.
+func (id Identity) IsZero() bool {
+	return id == Identity{}
+}
+
+// Compare returns -1, 0 or +1 depending on whether id sorts before, equal to
+// or after other, ordering identities as unsigned integers.
+func (id Identity) Compare(other Identity) int {
+	return bytes.Compare(id[:], other[:])
+}
+
+func (id Identity) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *Identity) UnmarshalText(text []byte) error {
+	parsed, err := ParseIdentity(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+func (id Identity) MarshalBSATN(w *bsatn.Writer) error {
+	writeReversed(w, id[:])
+	return nil
+}
+
+func (id *Identity) UnmarshalBSATN(r *bsatn.Reader) error {
+	return readReversed(r, id[:])
+}
+
+// MarshalSATSJSON writes id the way the server does, as the u256 inside its
+// product: {"__identity__": "0x..."}.
+func (id Identity) MarshalSATSJSON() ([]byte, error) {
+	return satsjson.EncodeSpecial(identityTag, satsjson.EncodeString("0x"+id.String())), nil
+}
+
+// UnmarshalSATSJSON reads the product written by MarshalSATSJSON or a bare
+// hex string.
+func (id *Identity) UnmarshalSATSJSON(data []byte) error {
+	return unmarshalSpecialWord(id[:], data, identityTag, "identity", func(s string) error {
+		return parseHexWord(id[:], "identity", s)
+	})
+}
+
+// ParseConnectionId parses a 32-character hex string, with or without a 0x prefix.
+func ParseConnectionId(s string) (ConnectionId, error) {
+	var id ConnectionId
+	err := parseHexWord(id[:], "connection id", s)
+	return id, err
+}
+
+// ConnectionIdFromBytes returns the ConnectionId with the given big-endian bytes.
+func ConnectionIdFromBytes(b []byte) (ConnectionId, error) {
+	var id ConnectionId
+	if len(b) != len(id) {
+		return id, fmt.Errorf("connection id: expected %d bytes, got %d", len(id), len(b))
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// String returns the connection id as 32 lowercase hex characters.
+func (id ConnectionId) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Bytes returns a copy of the big-endian connection id bytes.
+func (id ConnectionId) Bytes() []byte {
+	return bytes.Clone(id[:])
+}
+
+// IsZero reports whether id is the all-zero connection id.
+func (id ConnectionId) IsZero() bool {
+	return id == ConnectionId{}
+}
+
+// Compare orders connection ids as unsigned integers, like Identity.Compare.
+func (id ConnectionId) Compare(other ConnectionId) int {
+	return bytes.Compare(id[:], other[:])
+}
+
+func (id ConnectionId) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *ConnectionId) UnmarshalText(text []byte) error {
+	parsed, err := ParseConnectionId(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+func (id ConnectionId) MarshalBSATN(w *bsatn.Writer) error {
+	writeReversed(w, id[:])
+	return nil
+}
+
+func (id *ConnectionId) UnmarshalBSATN(r *bsatn.Reader) error {
+	return readReversed(r, id[:])
+}
+
+// MarshalSATSJSON writes id the way the server does, as the u128 inside its
+// product: {"__connection_id__": 1234}.
+func (id ConnectionId) MarshalSATSJSON() ([]byte, error) {
+	var words [2]uint64
+	wordsFromBytes(words[:], id[:])
+	return satsjson.EncodeSpecial(connectionIdTag, wordsToJSON(words[:], false)), nil
+}
+
+// UnmarshalSATSJSON reads the product written by MarshalSATSJSON or a bare
+// hex string.
+func (id *ConnectionId) UnmarshalSATSJSON(data []byte) error {
+	return unmarshalSpecialWord(id[:], data, connectionIdTag, "connection id", func(s string) error {
+		return parseHexWord(id[:], "connection id", s)
+	})
+}
+
+// Compare calls a.Compare(b). It lets the scalar types in this package be
+// passed directly to slices.SortFunc and similar helpers.
+func Compare[T interface{ Compare(T) int }](a, b T) int {
+	return a.Compare(b)
+}
+
+// unmarshalSpecialWord reads a builtin type whose SATS-JSON form is an
+// unsigned integer wrapped in a single-field product, storing it big-endian in
+// dst. A bare string is handed to parseBare instead.
+func unmarshalSpecialWord(dst, data []byte, tag, name string, parseBare func(string) error) error {
+	inner, wrapped, err := satsjson.DecodeSpecial(data, tag)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if !wrapped {
+		s, err := satsjson.DecodeString(inner)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return parseBare(s)
+	}
+	words := make([]uint64, len(dst)/8)
+	if err := wordsFromJSON(words, inner, false, name); err != nil {
+		return err
+	}
+	wordsToBytes(dst, words)
+	return nil
+}
+
+func parseHexWord(dst []byte, name, s string) error {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s) != hex.EncodedLen(len(dst)) {
+		return fmt.Errorf("%s: expected %d hex characters, got %d", name, hex.EncodedLen(len(dst)), len(s))
+	}
+	if _, err := hex.Decode(dst, []byte(s)); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// writeReversed writes big-endian bytes as a little-endian integer.
+func writeReversed(w *bsatn.Writer, be []byte) {
+	le := make([]byte, len(be))
+	for i, b := range be {
+		le[len(be)-1-i] = b
+	}
+	w.WriteRaw(le)
+}
+
+// readReversed reads a little-endian integer into big-endian bytes.
+func readReversed(r *bsatn.Reader, be []byte) error {
+	le, err := r.ReadRaw(len(be))
+	if err != nil {
+		return err
+	}
+	for i, b := range le {
+		be[len(be)-1-i] = b
+	}
+	return nil
+}
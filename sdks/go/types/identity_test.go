@@ -0,0 +1,166 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+const testIdentityHex = "c200000000000000000000000000000000000000000000000000000000000102"
+
+func TestParseIdentity(t *testing.T) {
+	id, err := ParseIdentity("0x" + testIdentityHex)
+	if err != nil {
+		t.Fatalf("parse identity: %v", err)
+	}
+	if id[0] != 0xc2 || id[31] != 0x02 {
+		t.Fatalf("identity should be stored big-endian: %v", id)
+	}
+	if id.String() != testIdentityHex {
+		t.Fatalf("unexpected identity string: %s", id)
+	}
+
+	for _, bad := range []string{"", "abc", testIdentityHex + "00", "zz" + testIdentityHex[2:]} {
+		if _, err := ParseIdentity(bad); err == nil {
+			t.Fatalf("expected %q to fail parsing", bad)
+		}
+	}
+}
+
+func TestIdentityBSATNIsLittleEndian(t *testing.T) {
+	id, _ := ParseIdentity(testIdentityHex)
+	encoded, err := bsatn.Marshal(id)
+	if err != nil {
+		t.Fatalf("marshal identity: %v", err)
+	}
+	if len(encoded) != 32 || encoded[0] != 0x02 || encoded[31] != 0xc2 {
+		t.Fatalf("unexpected identity encoding: %v", encoded)
+	}
+
+	var decoded Identity
+	if err := bsatn.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unmarshal identity: %v", err)
+	}
+	if decoded != id {
+		t.Fatalf("identity round trip mismatch: %s", decoded)
+	}
+
+	if err := bsatn.Unmarshal(encoded[:10], &decoded); err == nil {
+		t.Fatalf("expected truncated identity to fail")
+	}
+}
+
+func TestConnectionIdRoundTrip(t *testing.T) {
+	id, err := ParseConnectionId("000102030405060708090a0b0c0d0e0f")
+	if err != nil {
+		t.Fatalf("parse connection id: %v", err)
+	}
+	encoded, err := bsatn.Marshal(id)
+	if err != nil {
+		t.Fatalf("marshal connection id: %v", err)
+	}
+	if encoded[0] != 0x0f || encoded[15] != 0x00 {
+		t.Fatalf("unexpected connection id encoding: %v", encoded)
+	}
+
+	raw, err := json.Marshal(map[string]ConnectionId{"id": id})
+	if err != nil {
+		t.Fatalf("marshal json: %v", err)
+	}
+	if string(raw) != `{"id":"000102030405060708090a0b0c0d0e0f"}` {
+		t.Fatalf("unexpected json: %s", raw)
+	}
+	var decoded map[string]ConnectionId
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unmarshal json: %v", err)
+	}
+	if decoded["id"] != id {
+		t.Fatalf("json round trip mismatch: %s", decoded["id"])
+	}
+
+	if _, err := ConnectionIdFromBytes(make([]byte, 15)); err == nil {
+		t.Fatalf("expected short connection id bytes to fail")
+	}
+}
+
+func TestIdentityComparisonAndMapKeys(t *testing.T) {
+	low, _ := IdentityFromBytes(append(make([]byte, 31), 1))
+	high, _ := IdentityFromBytes(append([]byte{1}, make([]byte, 31)...))
+
+	if low.Compare(high) >= 0 || high.Compare(low) <= 0 || low.Compare(low) != 0 {
+		t.Fatalf("identities should compare as unsigned integers")
+	}
+
+	ids := []Identity{high, low}
+	slices.SortFunc(ids, Compare[Identity])
+	if ids[0] != low {
+		t.Fatalf("unexpected sort order: %v", ids)
+	}
+
+	seen := map[Identity]int{low: 1}
+	copyOfLow, _ := ParseIdentity(low.String())
+	if seen[copyOfLow] != 1 {
+		t.Fatalf("parsed identity should hit the same map key")
+	}
+
+	if !(Identity{}).IsZero() || low.IsZero() {
+		t.Fatalf("unexpected IsZero result")
+	}
+	b := low.Bytes()
+	b[31] = 9
+	if low[31] != 1 || !bytes.Equal(low.Bytes(), append(make([]byte, 31), 1)) {
+		t.Fatalf("Bytes should return a copy")
+	}
+}
+
+func TestIdentitySATSJSON(t *testing.T) {
+	id, _ := ParseIdentity(testIdentityHex)
+	encoded, err := satsjson.Marshal(id)
+	if err != nil {
+		t.Fatalf("marshal identity: %v", err)
+	}
+	if want := `{"__identity__":"0x` + testIdentityHex + `"}`; string(encoded) != want {
+		t.Fatalf("unexpected identity json: %s", encoded)
+	}
+
+	for _, form := range []string{
+		string(encoded),
+		`"` + testIdentityHex + `"`,
+		`{"__identity__":"0x` + testIdentityHex + `"}`,
+	} {
+		var decoded Identity
+		if err := satsjson.Unmarshal([]byte(form), &decoded); err != nil || decoded != id {
+			t.Fatalf("decode %s = %s (%v)", form, decoded, err)
+		}
+	}
+
+	var small Identity
+	if err := satsjson.Unmarshal([]byte(`{"__identity__":"0x102"}`), &small); err != nil || small[30] != 1 || small[31] != 2 {
+		t.Fatalf("short hex identity decoded to %s (%v)", small, err)
+	}
+	if err := satsjson.Unmarshal([]byte(`{"__identity__":-1}`), &small); err == nil {
+		t.Fatalf("expected negative identity to fail")
+	}
+}
+
+func TestConnectionIdSATSJSON(t *testing.T) {
+	id, _ := ParseConnectionId("000000000000000000000000000001ff")
+	encoded, err := satsjson.Marshal(id)
+	if err != nil {
+		t.Fatalf("marshal connection id: %v", err)
+	}
+	if string(encoded) != `{"__connection_id__":511}` {
+		t.Fatalf("unexpected connection id json: %s", encoded)
+	}
+	var decoded ConnectionId
+	if err := satsjson.Unmarshal(encoded, &decoded); err != nil || decoded != id {
+		t.Fatalf("decode connection id = %s (%v)", decoded, err)
+	}
+	if err := satsjson.Unmarshal([]byte(`"`+id.String()+`"`), &decoded); err != nil || decoded != id {
+		t.Fatalf("decode bare connection id = %s (%v)", decoded, err)
+	}
+}
@@ -0,0 +1,290 @@
+package types
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+)
+
+var (
+	// MaxU128 is the largest U128.
+	MaxU128 = U128{w: [2]uint64{math.MaxUint64, math.MaxUint64}}
+	// MinI128 is the smallest I128.
+	MinI128 = I128{w: [2]uint64{0, 1 << 63}}
+	// MaxI128 is the largest I128.
+	MaxI128 = I128{w: [2]uint64{math.MaxUint64, math.MaxInt64}}
+)
+
+// U128 is an unsigned 128-bit integer value.
+type U128 struct {
+	w [2]uint64
+}
+
+// U128From64 returns v as a U128.
+func U128From64(v uint64) U128 {
+	return U128{w: [2]uint64{v}}
+}
+
+// U128FromParts returns the U128 whose high and low 64 bits are hi and lo.
+func U128FromParts(hi, lo uint64) U128 {
+	return U128{w: [2]uint64{lo, hi}}
+}
+
+// U128FromBig converts v, failing if it is out of range.
+func U128FromBig(v *big.Int) (U128, error) {
+	var out U128
+	err := wordsFromBig(out.w[:], v, false, "u128")
+	return out, err
+}
+
+// ParseU128 parses a decimal integer, or a hex, octal or binary one with a
+// 0x, 0o or 0b prefix.
+func ParseU128(s string) (U128, error) {
+	var out U128
+	err := parseWords(out.w[:], s, false, "u128")
+	return out, err
+}
+
+// Parts returns the high and low 64 bits of x.
+func (x U128) Parts() (hi, lo uint64) {
+	return x.w[1], x.w[0]
+}
+
+func (x U128) IsZero() bool {
+	return x == U128{}
+}
+
+// Compare returns -1, 0 or +1 depending on whether x is less than, equal to
+// or greater than y.
+func (x U128) Compare(y U128) int {
+	return cmpWords(x.w[:], y.w[:])
+}
+
+// Add returns x+y, wrapping on overflow.
+func (x U128) Add(y U128) U128 {
+	var z U128
+	addWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Sub returns x-y, wrapping on overflow.
+func (x U128) Sub(y U128) U128 {
+	var z U128
+	subWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Mul returns x*y, wrapping on overflow.
+func (x U128) Mul(y U128) U128 {
+	var z U128
+	mulWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Quo returns x/y. It panics if y is zero.
+func (x U128) Quo(y U128) U128 {
+	var q, r U128
+	divWords(q.w[:], r.w[:], x.w[:], y.w[:])
+	return q
+}
+
+// Rem returns x%y. It panics if y is zero.
+func (x U128) Rem(y U128) U128 {
+	var q, r U128
+	divWords(q.w[:], r.w[:], x.w[:], y.w[:])
+	return r
+}
+
+// Big returns x as a newly allocated big.Int.
+func (x U128) Big() *big.Int {
+	return wordsToBig(x.w[:], false)
+}
+
+// String returns x in base 10.
+func (x U128) String() string {
+	return x.Big().String()
+}
+
+func (x U128) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+func (x *U128) UnmarshalText(text []byte) error {
+	parsed, err := ParseU128(string(text))
+	if err != nil {
+		return err
+	}
+	*x = parsed
+	return nil
+}
+
+func (x U128) MarshalBSATN(w *bsatn.Writer) error {
+	w.WriteU128(x.w[0], x.w[1])
+	return nil
+}
+
+func (x *U128) UnmarshalBSATN(r *bsatn.Reader) error {
+	lo, hi, err := r.ReadU128()
+	if err != nil {
+		return err
+	}
+	x.w = [2]uint64{lo, hi}
+	return nil
+}
+
+func (x U128) MarshalSATSJSON() ([]byte, error) {
+	return wordsToJSON(x.w[:], false), nil
+}
+
+func (x *U128) UnmarshalSATSJSON(data []byte) error {
+	return wordsFromJSON(x.w[:], data, false, "u128")
+}
+
+// I128 is a signed 128-bit integer value.
+type I128 struct {
+	w [2]uint64
+}
+
+// I128From64 returns v as an I128.
+func I128From64(v int64) I128 {
+	var out I128
+	for i := range out.w {
+		out.w[i] = uint64(v >> 63)
+	}
+	out.w[0] = uint64(v)
+	return out
+}
+
+// I128FromParts returns the I128 whose high and low 64 bits are hi and lo.
+func I128FromParts(hi int64, lo uint64) I128 {
+	return I128{w: [2]uint64{lo, uint64(hi)}}
+}
+
+// I128FromBig converts v, failing if it is out of range.
+func I128FromBig(v *big.Int) (I128, error) {
+	var out I128
+	err := wordsFromBig(out.w[:], v, true, "i128")
+	return out, err
+}
+
+// ParseI128 parses a decimal integer, or a hex, octal or binary one with a
+// 0x, 0o or 0b prefix.
+func ParseI128(s string) (I128, error) {
+	var out I128
+	err := parseWords(out.w[:], s, true, "i128")
+	return out, err
+}
+
+// Parts returns the high and low 64 bits of x.
+func (x I128) Parts() (hi int64, lo uint64) {
+	return int64(x.w[1]), x.w[0]
+}
+
+func (x I128) IsZero() bool {
+	return x == I128{}
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of x.
+func (x I128) Sign() int {
+	switch {
+	case isNegativeWords(x.w[:]):
+		return -1
+	case x.IsZero():
+		return 0
+	}
+	return 1
+}
+
+// Compare returns -1, 0 or +1 depending on whether x is less than, equal to
+// or greater than y.
+func (x I128) Compare(y I128) int {
+	return cmpSignedWords(x.w[:], y.w[:])
+}
+
+// Add returns x+y, wrapping on overflow.
+func (x I128) Add(y I128) I128 {
+	var z I128
+	addWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Sub returns x-y, wrapping on overflow.
+func (x I128) Sub(y I128) I128 {
+	var z I128
+	subWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Mul returns x*y, wrapping on overflow.
+func (x I128) Mul(y I128) I128 {
+	var z I128
+	mulWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Quo returns x/y truncated towards zero. It panics if y is zero.
+func (x I128) Quo(y I128) I128 {
+	var q, r I128
+	divSignedWords(q.w[:], r.w[:], x.w[:], y.w[:])
+	return q
+}
+
+// Rem returns x%y. It panics if y is zero.
+func (x I128) Rem(y I128) I128 {
+	var q, r I128
+	divSignedWords(q.w[:], r.w[:], x.w[:], y.w[:])
+	return r
+}
+
+// Neg returns -x, wrapping for the minimum value.
+func (x I128) Neg() I128 {
+	var z I128
+	negWords(z.w[:], x.w[:])
+	return z
+}
+
+// Big returns x as a newly allocated big.Int.
+func (x I128) Big() *big.Int {
+	return wordsToBig(x.w[:], true)
+}
+
+// String returns x in base 10.
+func (x I128) String() string {
+	return x.Big().String()
+}
+
+func (x I128) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+func (x *I128) UnmarshalText(text []byte) error {
+	parsed, err := ParseI128(string(text))
+	if err != nil {
+		return err
+	}
+	*x = parsed
+	return nil
+}
+
+func (x I128) MarshalBSATN(w *bsatn.Writer) error {
+	w.WriteI128(x.w[0], int64(x.w[1]))
+	return nil
+}
+
+func (x *I128) UnmarshalBSATN(r *bsatn.Reader) error {
+	lo, hi, err := r.ReadI128()
+	if err != nil {
+		return err
+	}
+	x.w = [2]uint64{lo, uint64(hi)}
+	return nil
+}
+
+func (x I128) MarshalSATSJSON() ([]byte, error) {
+	return wordsToJSON(x.w[:], true), nil
+}
+
+func (x *I128) UnmarshalSATSJSON(data []byte) error {
+	return wordsFromJSON(x.w[:], data, true, "i128")
+}
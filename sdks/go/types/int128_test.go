@@ -0,0 +1,192 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+)
+
+// wrapBig reduces v into the range of a bits-wide integer, the way fixed-width
+// arithmetic wraps.
+func wrapBig(v *big.Int, bits int, signed bool) *big.Int {
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	out := new(big.Int).Mod(v, mod)
+	if signed && out.Bit(bits-1) == 1 {
+		out.Sub(out, mod)
+	}
+	return out
+}
+
+func randomBig(rng *rand.Rand, bits int, signed bool) *big.Int {
+	words := make([]big.Word, bits/64)
+	for i := range words {
+		switch rng.IntN(4) {
+		case 0:
+			words[i] = 0
+		case 1:
+			words[i] = ^big.Word(0)
+		default:
+			words[i] = big.Word(rng.Uint64())
+		}
+	}
+	return wrapBig(new(big.Int).SetBits(words), bits, signed)
+}
+
+type wideOps[T any] struct {
+	bits    int
+	signed  bool
+	fromBig func(*big.Int) (T, error)
+	toBig   func(T) *big.Int
+	add     func(T, T) T
+	sub     func(T, T) T
+	mul     func(T, T) T
+	quo     func(T, T) T
+	rem     func(T, T) T
+	cmp     func(T, T) int
+}
+
+func checkWideArithmetic[T any](t *testing.T, ops wideOps[T]) {
+	t.Helper()
+	rng := rand.New(rand.NewPCG(1, uint64(ops.bits)))
+	for i := 0; i < 500; i++ {
+		a, b := randomBig(rng, ops.bits, ops.signed), randomBig(rng, ops.bits, ops.signed)
+		x, err := ops.fromBig(a)
+		if err != nil {
+			t.Fatalf("from big %s: %v", a, err)
+		}
+		y, err := ops.fromBig(b)
+		if err != nil {
+			t.Fatalf("from big %s: %v", b, err)
+		}
+		if got := ops.toBig(x); got.Cmp(a) != 0 {
+			t.Fatalf("big round trip: got %s want %s", got, a)
+		}
+
+		check := func(op string, got T, want *big.Int) {
+			t.Helper()
+			want = wrapBig(want, ops.bits, ops.signed)
+			if ops.toBig(got).Cmp(want) != 0 {
+				t.Fatalf("%s %s %s: got %s want %s", a, op, b, ops.toBig(got), want)
+			}
+		}
+		check("+", ops.add(x, y), new(big.Int).Add(a, b))
+		check("-", ops.sub(x, y), new(big.Int).Sub(a, b))
+		check("*", ops.mul(x, y), new(big.Int).Mul(a, b))
+		if b.Sign() != 0 {
+			check("/", ops.quo(x, y), new(big.Int).Quo(a, b))
+			check("%", ops.rem(x, y), new(big.Int).Rem(a, b))
+		}
+		if got := ops.cmp(x, y); got != a.Cmp(b) {
+			t.Fatalf("compare %s %s: got %d", a, b, got)
+		}
+	}
+}
+
+func TestU128Arithmetic(t *testing.T) {
+	checkWideArithmetic(t, wideOps[U128]{
+		bits: 128, fromBig: U128FromBig, toBig: U128.Big,
+		add: U128.Add, sub: U128.Sub, mul: U128.Mul, quo: U128.Quo, rem: U128.Rem, cmp: U128.Compare,
+	})
+}
+
+func TestI128Arithmetic(t *testing.T) {
+	checkWideArithmetic(t, wideOps[I128]{
+		bits: 128, signed: true, fromBig: I128FromBig, toBig: I128.Big,
+		add: I128.Add, sub: I128.Sub, mul: I128.Mul, quo: I128.Quo, rem: I128.Rem, cmp: I128.Compare,
+	})
+}
+
+func TestI128Limits(t *testing.T) {
+	if MaxI128.Add(I128From64(1)) != MinI128 {
+		t.Fatalf("expected MaxI128+1 to wrap to MinI128")
+	}
+	if MinI128.Neg() != MinI128 {
+		t.Fatalf("expected -MinI128 to wrap")
+	}
+	if MinI128.Quo(I128From64(-1)) != MinI128 {
+		t.Fatalf("expected MinI128/-1 to wrap")
+	}
+	if I128From64(-5).Sign() != -1 || I128From64(0).Sign() != 0 || I128From64(5).Sign() != 1 {
+		t.Fatalf("unexpected Sign results")
+	}
+	if MinI128.String() != "-170141183460469231731687303715884105728" {
+		t.Fatalf("unexpected MinI128: %s", MinI128)
+	}
+	if MaxU128.String() != "340282366920938463463374607431768211455" {
+		t.Fatalf("unexpected MaxU128: %s", MaxU128)
+	}
+	hi, lo := I128From64(-2).Parts()
+	if hi != -1 || lo != ^uint64(1) {
+		t.Fatalf("unexpected parts: %d %x", hi, lo)
+	}
+	if I128FromParts(hi, lo) != I128From64(-2) {
+		t.Fatalf("FromParts should invert Parts")
+	}
+}
+
+func TestParseU128(t *testing.T) {
+	v, err := ParseU128("0xffffffffffffffff0000000000000001")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if v != U128FromParts(^uint64(0), 1) {
+		t.Fatalf("unexpected value: %s", v)
+	}
+	for _, bad := range []string{"", "-1", "abc", "340282366920938463463374607431768211456"} {
+		if _, err := ParseU128(bad); err == nil {
+			t.Fatalf("expected %q to fail", bad)
+		}
+	}
+	if _, err := I128FromBig(new(big.Int).Lsh(big.NewInt(1), 127)); err == nil {
+		t.Fatalf("expected 2^127 to overflow I128")
+	}
+	if _, err := U128FromBig(nil); err == nil {
+		t.Fatalf("expected nil big.Int to fail")
+	}
+}
+
+func TestDivideByZeroPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected division by zero to panic")
+		}
+	}()
+	U128From64(1).Quo(U128{})
+}
+
+func TestInt128Encoding(t *testing.T) {
+	type row struct {
+		Balance U128
+		Delta   I128
+	}
+	in := row{Balance: U128FromParts(2, 1), Delta: I128From64(-1)}
+
+	encoded, err := bsatn.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := append([]byte{1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0}, bytes.Repeat([]byte{0xff}, 16)...)
+	if !bytes.Equal(encoded, want) {
+		t.Fatalf("unexpected encoding: %v", encoded)
+	}
+	var out row
+	if err := bsatn.Unmarshal(encoded, &out); err != nil || out != in {
+		t.Fatalf("bsatn round trip mismatch: %+v %v", out, err)
+	}
+
+	raw, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal json: %v", err)
+	}
+	if string(raw) != `{"Balance":"36893488147419103233","Delta":"-1"}` {
+		t.Fatalf("unexpected json: %s", raw)
+	}
+	out = row{}
+	if err := json.Unmarshal(raw, &out); err != nil || out != in {
+		t.Fatalf("json round trip mismatch: %+v %v", out, err)
+	}
+}
@@ -0,0 +1,290 @@
+package types
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+)
+
+var (
+	// MaxU256 is the largest U256.
+	MaxU256 = U256{w: [4]uint64{math.MaxUint64, math.MaxUint64, math.MaxUint64, math.MaxUint64}}
+	// MinI256 is the smallest I256.
+	MinI256 = I256{w: [4]uint64{0, 0, 0, 1 << 63}}
+	// MaxI256 is the largest I256.
+	MaxI256 = I256{w: [4]uint64{math.MaxUint64, math.MaxUint64, math.MaxUint64, math.MaxInt64}}
+)
+
+// U256 is an unsigned 256-bit integer value.
+type U256 struct {
+	w [4]uint64
+}
+
+// U256From64 returns v as a U256.
+func U256From64(v uint64) U256 {
+	return U256{w: [4]uint64{v}}
+}
+
+// U256FromWords returns the U256 with the given 64-bit words, least significant first.
+func U256FromWords(words [4]uint64) U256 {
+	return U256{w: words}
+}
+
+// U256FromBig converts v, failing if it is out of range.
+func U256FromBig(v *big.Int) (U256, error) {
+	var out U256
+	err := wordsFromBig(out.w[:], v, false, "u256")
+	return out, err
+}
+
+// ParseU256 parses a decimal integer, or a hex, octal or binary one with a
+// 0x, 0o or 0b prefix.
+func ParseU256(s string) (U256, error) {
+	var out U256
+	err := parseWords(out.w[:], s, false, "u256")
+	return out, err
+}
+
+// Words returns the 64-bit words of x, least significant first.
+func (x U256) Words() [4]uint64 {
+	return x.w
+}
+
+func (x U256) IsZero() bool {
+	return x == U256{}
+}
+
+// Compare returns -1, 0 or +1 depending on whether x is less than, equal to
+// or greater than y.
+func (x U256) Compare(y U256) int {
+	return cmpWords(x.w[:], y.w[:])
+}
+
+// Add returns x+y, wrapping on overflow.
+func (x U256) Add(y U256) U256 {
+	var z U256
+	addWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Sub returns x-y, wrapping on overflow.
+func (x U256) Sub(y U256) U256 {
+	var z U256
+	subWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Mul returns x*y, wrapping on overflow.
+func (x U256) Mul(y U256) U256 {
+	var z U256
+	mulWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Quo returns x/y. It panics if y is zero.
+func (x U256) Quo(y U256) U256 {
+	var q, r U256
+	divWords(q.w[:], r.w[:], x.w[:], y.w[:])
+	return q
+}
+
+// Rem returns x%y. It panics if y is zero.
+func (x U256) Rem(y U256) U256 {
+	var q, r U256
+	divWords(q.w[:], r.w[:], x.w[:], y.w[:])
+	return r
+}
+
+// Big returns x as a newly allocated big.Int.
+func (x U256) Big() *big.Int {
+	return wordsToBig(x.w[:], false)
+}
+
+// String returns x in base 10.
+func (x U256) String() string {
+	return x.Big().String()
+}
+
+func (x U256) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+func (x *U256) UnmarshalText(text []byte) error {
+	parsed, err := ParseU256(string(text))
+	if err != nil {
+		return err
+	}
+	*x = parsed
+	return nil
+}
+
+func (x U256) MarshalBSATN(w *bsatn.Writer) error {
+	w.WriteU256(x.w)
+	return nil
+}
+
+func (x *U256) UnmarshalBSATN(r *bsatn.Reader) error {
+	words, err := r.ReadU256()
+	if err != nil {
+		return err
+	}
+	x.w = words
+	return nil
+}
+
+func (x U256) MarshalSATSJSON() ([]byte, error) {
+	return wordsToJSON(x.w[:], false), nil
+}
+
+func (x *U256) UnmarshalSATSJSON(data []byte) error {
+	return wordsFromJSON(x.w[:], data, false, "u256")
+}
+
+// I256 is a signed 256-bit integer value.
+type I256 struct {
+	w [4]uint64
+}
+
+// I256From64 returns v as an I256.
+func I256From64(v int64) I256 {
+	var out I256
+	for i := range out.w {
+		out.w[i] = uint64(v >> 63)
+	}
+	out.w[0] = uint64(v)
+	return out
+}
+
+// I256FromWords returns the I256 with the given 64-bit words, least significant first.
+func I256FromWords(words [4]uint64) I256 {
+	return I256{w: words}
+}
+
+// I256FromBig converts v, failing if it is out of range.
+func I256FromBig(v *big.Int) (I256, error) {
+	var out I256
+	err := wordsFromBig(out.w[:], v, true, "i256")
+	return out, err
+}
+
+// ParseI256 parses a decimal integer, or a hex, octal or binary one with a
+// 0x, 0o or 0b prefix.
+func ParseI256(s string) (I256, error) {
+	var out I256
+	err := parseWords(out.w[:], s, true, "i256")
+	return out, err
+}
+
+// Words returns the 64-bit words of x, least significant first.
+func (x I256) Words() [4]uint64 {
+	return x.w
+}
+
+func (x I256) IsZero() bool {
+	return x == I256{}
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of x.
+func (x I256) Sign() int {
+	switch {
+	case isNegativeWords(x.w[:]):
+		return -1
+	case x.IsZero():
+		return 0
+	}
+	return 1
+}
+
+// Compare returns -1, 0 or +1 depending on whether x is less than, equal to
+// or greater than y.
+func (x I256) Compare(y I256) int {
+	return cmpSignedWords(x.w[:], y.w[:])
+}
+
+// Add returns x+y, wrapping on overflow.
+func (x I256) Add(y I256) I256 {
+	var z I256
+	addWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Sub returns x-y, wrapping on overflow.
+func (x I256) Sub(y I256) I256 {
+	var z I256
+	subWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Mul returns x*y, wrapping on overflow.
+func (x I256) Mul(y I256) I256 {
+	var z I256
+	mulWords(z.w[:], x.w[:], y.w[:])
+	return z
+}
+
+// Quo returns x/y truncated towards zero. It panics if y is zero.
+func (x I256) Quo(y I256) I256 {
+	var q, r I256
+	divSignedWords(q.w[:], r.w[:], x.w[:], y.w[:])
+	return q
+}
+
+// Rem returns x%y. It panics if y is zero.
+func (x I256) Rem(y I256) I256 {
+	var q, r I256
+	divSignedWords(q.w[:], r.w[:], x.w[:], y.w[:])
+	return r
+}
+
+// Neg returns -x, wrapping for the minimum value.
+func (x I256) Neg() I256 {
+	var z I256
+	negWords(z.w[:], x.w[:])
+	return z
+}
+
+// Big returns x as a newly allocated big.Int.
+func (x I256) Big() *big.Int {
+	return wordsToBig(x.w[:], true)
+}
+
+// String returns x in base 10.
+func (x I256) String() string {
+	return x.Big().String()
+}
+
+func (x I256) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+func (x *I256) UnmarshalText(text []byte) error {
+	parsed, err := ParseI256(string(text))
+	if err != nil {
+		return err
+	}
+	*x = parsed
+	return nil
+}
+
+func (x I256) MarshalBSATN(w *bsatn.Writer) error {
+	w.WriteI256(x.w)
+	return nil
+}
+
+func (x *I256) UnmarshalBSATN(r *bsatn.Reader) error {
+	words, err := r.ReadI256()
+	if err != nil {
+		return err
+	}
+	x.w = words
+	return nil
+}
+
+func (x I256) MarshalSATSJSON() ([]byte, error) {
+	return wordsToJSON(x.w[:], true), nil
+}
+
+func (x *I256) UnmarshalSATSJSON(data []byte) error {
+	return wordsFromJSON(x.w[:], data, true, "i256")
+}
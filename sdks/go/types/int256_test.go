@@ -0,0 +1,107 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+func TestU256Arithmetic(t *testing.T) {
+	checkWideArithmetic(t, wideOps[U256]{
+		bits: 256, fromBig: U256FromBig, toBig: U256.Big,
+		add: U256.Add, sub: U256.Sub, mul: U256.Mul, quo: U256.Quo, rem: U256.Rem, cmp: U256.Compare,
+	})
+}
+
+func TestI256Arithmetic(t *testing.T) {
+	checkWideArithmetic(t, wideOps[I256]{
+		bits: 256, signed: true, fromBig: I256FromBig, toBig: I256.Big,
+		add: I256.Add, sub: I256.Sub, mul: I256.Mul, quo: I256.Quo, rem: I256.Rem, cmp: I256.Compare,
+	})
+}
+
+func TestInt256Limits(t *testing.T) {
+	if MaxU256.Add(U256From64(1)) != (U256{}) {
+		t.Fatalf("expected MaxU256+1 to wrap to zero")
+	}
+	if MinI256.Sub(I256From64(1)) != MaxI256 {
+		t.Fatalf("expected MinI256-1 to wrap to MaxI256")
+	}
+	if MinI256.Compare(MaxI256) != -1 || I256From64(-1).Compare(I256From64(0)) != -1 {
+		t.Fatalf("signed comparison should order negatives first")
+	}
+	parsed, err := ParseI256(MinI256.String())
+	if err != nil || parsed != MinI256 {
+		t.Fatalf("parse round trip mismatch: %s %v", parsed, err)
+	}
+	if U256FromWords(MaxU256.Words()) != MaxU256 {
+		t.Fatalf("FromWords should invert Words")
+	}
+}
+
+func TestInt256Encoding(t *testing.T) {
+	in := U256FromWords([4]uint64{1, 2, 3, 4})
+	encoded, err := bsatn.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if len(encoded) != 32 || encoded[0] != 1 || encoded[8] != 2 || encoded[16] != 3 || encoded[24] != 4 {
+		t.Fatalf("unexpected encoding: %v", encoded)
+	}
+	var out U256
+	if err := bsatn.Unmarshal(encoded, &out); err != nil || out != in {
+		t.Fatalf("bsatn round trip mismatch: %s %v", out, err)
+	}
+
+	negative, err := bsatn.Marshal(I256From64(-2))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !bytes.Equal(negative, append([]byte{0xfe}, bytes.Repeat([]byte{0xff}, 31)...)) {
+		t.Fatalf("unexpected negative encoding: %v", negative)
+	}
+	var decoded I256
+	if err := bsatn.Unmarshal(negative, &decoded); err != nil || decoded != I256From64(-2) {
+		t.Fatalf("bsatn round trip mismatch: %s %v", decoded, err)
+	}
+}
+
+func TestWideIntegerSATSJSON(t *testing.T) {
+	cases := []struct {
+		value any
+		json  string
+	}{
+		{MaxU128, "340282366920938463463374607431768211455"},
+		{MinI128, "-170141183460469231731687303715884105728"},
+		{U256From64(255), `"0xff"`},
+		{I256From64(-16), `"-0x10"`},
+	}
+	for _, tc := range cases {
+		encoded, err := satsjson.Marshal(tc.value)
+		if err != nil {
+			t.Fatalf("marshal %v: %v", tc.value, err)
+		}
+		if string(encoded) != tc.json {
+			t.Fatalf("unexpected json for %v: %s", tc.value, encoded)
+		}
+	}
+
+	var u U256
+	for _, form := range []string{`"0xff"`, `"255"`, `255`} {
+		if err := satsjson.Unmarshal([]byte(form), &u); err != nil || u != U256From64(255) {
+			t.Fatalf("decode %s = %s (%v)", form, u, err)
+		}
+	}
+	var i I128
+	if err := satsjson.Unmarshal([]byte(`"-0x10"`), &i); err != nil || i != I128From64(-16) {
+		t.Fatalf("decode negative hex = %s (%v)", i, err)
+	}
+	for _, bad := range []string{`-1`, `1.5`, `"x"`, `"0x1` + strings.Repeat("0", 64) + `"`} {
+		if err := satsjson.Unmarshal([]byte(bad), &u); err == nil {
+			t.Fatalf("expected %s to fail", bad)
+		}
+	}
+}
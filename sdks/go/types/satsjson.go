@@ -0,0 +1,20 @@
+package types
+
+import "github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+
+// MarshalSATSJSON returns the SATS-JSON encoding of a Go value, such as a
+// generated row struct. Structs become objects keyed by their `json` tag or
+// field name, pointers become options, and the types of this package keep
+// their SATS-JSON wrappers. A type can take over its own encoding with a
+// MarshalSATSJSON() ([]byte, error) method.
+func MarshalSATSJSON(v any) ([]byte, error) {
+	return satsjson.Marshal(v)
+}
+
+// UnmarshalSATSJSON decodes SATS-JSON into the value pointed to by v, using
+// the same mapping as MarshalSATSJSON. It accepts every form the server
+// writes, such as products as arrays or objects. A type can take over its
+// own decoding with an UnmarshalSATSJSON([]byte) error method.
+func UnmarshalSATSJSON(data []byte, v any) error {
+	return satsjson.Unmarshal(data, v)
+}
@@ -0,0 +1,37 @@
+package types_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/types"
+)
+
+type player struct {
+	ID       uint64         `json:"id"`
+	Owner    types.Identity `json:"owner"`
+	Nickname *string        `json:"nickname"`
+	JoinedAt time.Time      `json:"joined_at"`
+}
+
+func TestSATSJSONRoundTripsStructs(t *testing.T) {
+	nickname := "ace"
+	in := player{ID: 7, Owner: types.Identity{1}, Nickname: &nickname, JoinedAt: time.UnixMicro(1_700_000_000_000_000).UTC()}
+
+	encoded, err := types.MarshalSATSJSON(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var out player
+	if err := types.UnmarshalSATSJSON(encoded, &out); err != nil {
+		t.Fatalf("unmarshal %s: %v", encoded, err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("round trip through %s: got %+v, want %+v", encoded, out, in)
+	}
+
+	if err := types.UnmarshalSATSJSON([]byte(`{"id": 1}`), out); err == nil {
+		t.Fatalf("expected a non-pointer target to be rejected")
+	}
+}
@@ -0,0 +1,272 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+// ErrEmptySum reports an attempt to encode a Sum that holds no variant.
+var ErrEmptySum = errors.New("sum holds no variant")
+
+// SumVariant is implemented by the variant structs of generated sum types.
+// A variant struct has no fields for a unit variant, or exactly one field
+// holding the variant's payload.
+type SumVariant interface {
+	SumVariantName() string
+}
+
+// SumSchema describes a sum type to the Sum runtime. Generated code
+// implements it on an empty struct so that it can be used as a type argument.
+type SumSchema interface {
+	// SumName returns the name of the sum type, used in errors.
+	SumName() string
+	// SumVariants returns the zero value of every variant, in tag order.
+	SumVariants() []SumVariant
+}
+
+// Sum holds one variant of the sum type described by S.
+//
+// Generated sum types embed a Sum, which gives them BSATN, SATS-JSON and
+// encoding/json codecs, and add a typed Variant accessor plus exhaustive
+// Match and Visit helpers. The zero Sum holds no variant: it encodes as null
+// with encoding/json and fails with ErrEmptySum in the wire formats.
+//
+// With encoding/json a Sum is written as {"tag": name, "value": payload},
+// the shape the SDK uses for JSON websocket messages. Unit variants omit
+// the value.
+type Sum[S SumSchema] struct {
+	variant SumVariant
+}
+
+// NewSum returns a Sum holding v, which must be one of the variants listed by S.
+func NewSum[S SumSchema](v SumVariant) Sum[S] {
+	return Sum[S]{variant: v}
+}
+
+// Variant returns the variant held by s, or nil for the zero Sum.
+func (s Sum[S]) Variant() SumVariant {
+	return s.variant
+}
+
+// VariantName returns the name of the variant held by s, or "" for the zero Sum.
+func (s Sum[S]) VariantName() string {
+	if s.variant == nil {
+		return ""
+	}
+	return s.variant.SumVariantName()
+}
+
+// String formats s as Name.Variant(payload), or Name.Variant for unit variants.
+func (s Sum[S]) String() string {
+	info, tag, err := s.tag()
+	if err != nil {
+		return info.name + "(<empty>)"
+	}
+	payload, ok := variantPayload(s.variant)
+	if !ok {
+		return info.label(tag)
+	}
+	return fmt.Sprintf("%s(%+v)", info.label(tag), payload.Elem().Interface())
+}
+
+// MarshalBSATN writes the variant tag followed by the payload.
+func (s Sum[S]) MarshalBSATN(w *bsatn.Writer) error {
+	info, tag, err := s.tag()
+	if err != nil {
+		return err
+	}
+	w.WriteSumTag(tag)
+	payload, ok := variantPayload(s.variant)
+	if !ok {
+		return nil
+	}
+	if err := bsatn.Encode(w, payload.Interface()); err != nil {
+		return fmt.Errorf("%s: %w", info.label(tag), err)
+	}
+	return nil
+}
+
+// UnmarshalBSATN reads a variant tag and its payload.
+func (s *Sum[S]) UnmarshalBSATN(r *bsatn.Reader) error {
+	info := sumInfoFor[S]()
+	tag, err := r.ReadSumTag()
+	if err != nil {
+		return err
+	}
+	if int(tag) >= len(info.variants) {
+		return r.InvalidTag(info.name, tag)
+	}
+	variant := reflect.New(info.variants[tag]).Elem()
+	if variant.NumField() > 0 {
+		if err := bsatn.Decode(r, variant.Field(0).Addr().Interface()); err != nil {
+			return fmt.Errorf("%s: %w", info.label(tag), err)
+		}
+	}
+	s.variant = variant.Interface().(SumVariant)
+	return nil
+}
+
+// MarshalSATSJSON writes the variant as a single-key object keyed by its name.
+func (s Sum[S]) MarshalSATSJSON() ([]byte, error) {
+	info, tag, err := s.tag()
+	if err != nil {
+		return nil, err
+	}
+	encoded := satsjson.Unit
+	if payload, ok := variantPayload(s.variant); ok {
+		if encoded, err = satsjson.Marshal(payload.Interface()); err != nil {
+			return nil, fmt.Errorf("%s: %w", info.label(tag), err)
+		}
+	}
+	return satsjson.EncodeVariant(info.names[tag], tag, encoded), nil
+}
+
+// UnmarshalSATSJSON reads a variant keyed by name or tag number.
+func (s *Sum[S]) UnmarshalSATSJSON(data []byte) error {
+	info := sumInfoFor[S]()
+	tag, encoded, err := satsjson.DecodeVariant(data, info.names)
+	if err != nil {
+		return err
+	}
+	variant := reflect.New(info.variants[tag]).Elem()
+	if variant.NumField() == 0 {
+		err = satsjson.DecodeUnit(encoded)
+	} else {
+		err = satsjson.Unmarshal(encoded, variant.Field(0).Addr().Interface())
+	}
+	if err != nil {
+		return satsjson.WithPath(info.names[tag], err)
+	}
+	s.variant = variant.Interface().(SumVariant)
+	return nil
+}
+
+type sumJSON struct {
+	Tag   string          `json:"tag"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MarshalJSON writes {"tag": name, "value": payload}, or null for the zero Sum.
+func (s Sum[S]) MarshalJSON() ([]byte, error) {
+	if s.variant == nil {
+		return []byte("null"), nil
+	}
+	info, tag, err := s.tag()
+	if err != nil {
+		return nil, err
+	}
+	out := sumJSON{Tag: info.names[tag]}
+	if payload, ok := variantPayload(s.variant); ok {
+		if out.Value, err = json.Marshal(payload.Interface()); err != nil {
+			return nil, fmt.Errorf("%s: %w", info.label(tag), err)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads {"tag": name, "value": payload}. A null value or an
+// empty tag leaves s as the zero Sum.
+func (s *Sum[S]) UnmarshalJSON(data []byte) error {
+	var in sumJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Tag == "" {
+		*s = Sum[S]{}
+		return nil
+	}
+	info := sumInfoFor[S]()
+	tag, ok := info.tagOf(in.Tag)
+	if !ok {
+		return fmt.Errorf("unknown %s tag %q", info.name, in.Tag)
+	}
+	variant := reflect.New(info.variants[tag]).Elem()
+	if variant.NumField() > 0 && len(in.Value) > 0 {
+		if err := json.Unmarshal(in.Value, variant.Field(0).Addr().Interface()); err != nil {
+			return fmt.Errorf("%s: %w", info.label(tag), err)
+		}
+	}
+	s.variant = variant.Interface().(SumVariant)
+	return nil
+}
+
+// tag returns the wire tag of the variant held by s.
+func (s Sum[S]) tag() (*sumInfo, uint8, error) {
+	info := sumInfoFor[S]()
+	if s.variant == nil {
+		return info, 0, fmt.Errorf("%s: %w", info.name, ErrEmptySum)
+	}
+	tag, ok := info.tags[reflect.TypeOf(s.variant)]
+	if !ok {
+		return info, 0, fmt.Errorf("%T is not a variant of %s", s.variant, info.name)
+	}
+	return info, tag, nil
+}
+
+// variantPayload returns a pointer to a copy of the payload field of a
+// variant struct, reporting false for unit variants.
+func variantPayload(v SumVariant) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.NumField() == 0 {
+		return reflect.Value{}, false
+	}
+	// Encode through a pointer so that a payload which is itself a pointer
+	// is written as an Option rather than dereferenced.
+	payload := reflect.New(rv.Field(0).Type())
+	payload.Elem().Set(rv.Field(0))
+	return payload, true
+}
+
+type sumInfo struct {
+	name     string
+	names    []string
+	variants []reflect.Type
+	tags     map[reflect.Type]uint8
+}
+
+var sumInfoCache sync.Map // map[reflect.Type]*sumInfo
+
+func sumInfoFor[S SumSchema]() *sumInfo {
+	key := reflect.TypeFor[S]()
+	if cached, ok := sumInfoCache.Load(key); ok {
+		return cached.(*sumInfo)
+	}
+	var schema S
+	variants := schema.SumVariants()
+	info := &sumInfo{
+		name:     schema.SumName(),
+		names:    make([]string, len(variants)),
+		variants: make([]reflect.Type, len(variants)),
+		tags:     make(map[reflect.Type]uint8, len(variants)),
+	}
+	for i, v := range variants {
+		t := reflect.TypeOf(v)
+		if t.Kind() != reflect.Struct || t.NumField() > 1 {
+			panic(fmt.Sprintf("types: variant %d of %s must be a struct with at most one field, got %v", i, info.name, t))
+		}
+		info.names[i] = v.SumVariantName()
+		info.variants[i] = t
+		info.tags[t] = uint8(i)
+	}
+	cached, _ := sumInfoCache.LoadOrStore(key, info)
+	return cached.(*sumInfo)
+}
+
+func (info *sumInfo) tagOf(name string) (uint8, bool) {
+	for i, candidate := range info.names {
+		if candidate == name {
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
+
+func (info *sumInfo) label(tag uint8) string {
+	return info.name + "." + info.names[tag]
+}
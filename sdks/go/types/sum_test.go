@@ -0,0 +1,204 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+// testShape mirrors the code generated for a sum type
+// Circle(f32) | Label(Option<String>) | Empty.
+type testShape struct {
+	Sum[testShapeSchema]
+}
+
+type testShapeVariant interface {
+	SumVariant
+	isTestShapeVariant()
+}
+
+type testShapeCircle struct{ Value float32 }
+type testShapeLabel struct{ Value *string }
+type testShapeEmpty struct{}
+
+func newTestShape(v testShapeVariant) testShape {
+	return testShape{NewSum[testShapeSchema](v)}
+}
+
+func (s testShape) Variant() testShapeVariant {
+	v, _ := s.Sum.Variant().(testShapeVariant)
+	return v
+}
+
+func matchTestShape[R any](s testShape, onCircle func(float32) R, onLabel func(*string) R, onEmpty func() R) R {
+	switch v := s.Variant().(type) {
+	case testShapeCircle:
+		return onCircle(v.Value)
+	case testShapeLabel:
+		return onLabel(v.Value)
+	case testShapeEmpty:
+		return onEmpty()
+	}
+	var zero R
+	return zero
+}
+
+type testShapeSchema struct{}
+
+func (testShapeSchema) SumName() string { return "Shape" }
+
+func (testShapeSchema) SumVariants() []SumVariant {
+	return []SumVariant{testShapeCircle{}, testShapeLabel{}, testShapeEmpty{}}
+}
+
+func (testShapeCircle) SumVariantName() string { return "Circle" }
+func (testShapeCircle) isTestShapeVariant()    {}
+func (testShapeLabel) SumVariantName() string  { return "Label" }
+func (testShapeLabel) isTestShapeVariant()     {}
+func (testShapeEmpty) SumVariantName() string  { return "Empty" }
+func (testShapeEmpty) isTestShapeVariant()     {}
+
+type testDrawing struct {
+	Name   string
+	Shapes []testShape
+}
+
+func TestSumEncodings(t *testing.T) {
+	label := "hi"
+	cases := []struct {
+		shape    testShape
+		bsatn    []byte
+		satsJSON string
+		json     string
+	}{
+		{newTestShape(testShapeCircle{Value: 1.5}), []byte{0, 0, 0, 0xc0, 0x3f}, `{"Circle":1.5}`, `{"tag":"Circle","value":1.5}`},
+		{newTestShape(testShapeLabel{Value: &label}), []byte{1, 0, 2, 0, 0, 0, 'h', 'i'}, `{"Label":{"some":"hi"}}`, `{"tag":"Label","value":"hi"}`},
+		{newTestShape(testShapeLabel{}), []byte{1, 1}, `{"Label":{"none":[]}}`, `{"tag":"Label","value":null}`},
+		{newTestShape(testShapeEmpty{}), []byte{2}, `{"Empty":[]}`, `{"tag":"Empty"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.json, func(t *testing.T) {
+			data, err := bsatn.Marshal(tc.shape)
+			if err != nil {
+				t.Fatalf("marshal bsatn: %v", err)
+			}
+			if !reflect.DeepEqual(data, tc.bsatn) {
+				t.Fatalf("unexpected bsatn: got %x want %x", data, tc.bsatn)
+			}
+			var fromBSATN testShape
+			if err := bsatn.Unmarshal(data, &fromBSATN); err != nil {
+				t.Fatalf("unmarshal bsatn: %v", err)
+			}
+			if !reflect.DeepEqual(fromBSATN, tc.shape) {
+				t.Fatalf("bsatn round trip: got %v want %v", fromBSATN, tc.shape)
+			}
+
+			encoded, err := satsjson.Marshal(tc.shape)
+			if err != nil || string(encoded) != tc.satsJSON {
+				t.Fatalf("unexpected sats-json: got %s (%v) want %s", encoded, err, tc.satsJSON)
+			}
+			var fromSATS testShape
+			if err := satsjson.Unmarshal(encoded, &fromSATS); err != nil {
+				t.Fatalf("unmarshal sats-json: %v", err)
+			}
+			if !reflect.DeepEqual(fromSATS, tc.shape) {
+				t.Fatalf("sats-json round trip: got %v want %v", fromSATS, tc.shape)
+			}
+
+			encoded, err = json.Marshal(tc.shape)
+			if err != nil || string(encoded) != tc.json {
+				t.Fatalf("unexpected json: got %s (%v) want %s", encoded, err, tc.json)
+			}
+			var fromJSON testShape
+			if err := json.Unmarshal(encoded, &fromJSON); err != nil {
+				t.Fatalf("unmarshal json: %v", err)
+			}
+			if !reflect.DeepEqual(fromJSON, tc.shape) {
+				t.Fatalf("json round trip: got %v want %v", fromJSON, tc.shape)
+			}
+		})
+	}
+}
+
+func TestSumInsideProducts(t *testing.T) {
+	drawing := testDrawing{Name: "d", Shapes: []testShape{
+		newTestShape(testShapeEmpty{}),
+		newTestShape(testShapeCircle{Value: 2}),
+	}}
+	data, err := bsatn.Marshal(drawing)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back testDrawing
+	if err := bsatn.Unmarshal(data, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(back, drawing) {
+		t.Fatalf("round trip mismatch: got %+v want %+v", back, drawing)
+	}
+
+	// A sum may also be keyed by tag number in SATS-JSON.
+	if err := satsjson.Unmarshal([]byte(`{"Name":"e","Shapes":[{"0":3}]}`), &back); err != nil {
+		t.Fatalf("unmarshal sats-json: %v", err)
+	}
+	if back.Shapes[0].Variant() != (testShapeCircle{Value: 3}) {
+		t.Fatalf("unexpected shape: %v", back.Shapes[0])
+	}
+}
+
+func TestSumMatch(t *testing.T) {
+	describe := func(s testShape) string {
+		return matchTestShape(s,
+			func(r float32) string { return "circle" },
+			func(l *string) string { return "label" },
+			func() string { return "empty" },
+		)
+	}
+	if got := describe(newTestShape(testShapeLabel{})); got != "label" {
+		t.Fatalf("unexpected match: %s", got)
+	}
+	if got := describe(newTestShape(testShapeEmpty{})); got != "empty" {
+		t.Fatalf("unexpected match: %s", got)
+	}
+	if got := describe(testShape{}); got != "" {
+		t.Fatalf("expected zero result for an empty sum, got %q", got)
+	}
+
+	shape := newTestShape(testShapeCircle{Value: 1})
+	if shape.VariantName() != "Circle" || shape.String() != "Shape.Circle(1)" {
+		t.Fatalf("unexpected name or string: %s %s", shape.VariantName(), shape)
+	}
+	if (testShape{}).String() != "Shape(<empty>)" || (testShape{}).VariantName() != "" {
+		t.Fatalf("unexpected empty sum description: %s", testShape{})
+	}
+}
+
+func TestSumErrors(t *testing.T) {
+	if _, err := bsatn.Marshal(testShape{}); !errors.Is(err, ErrEmptySum) {
+		t.Fatalf("expected empty sum to fail, got %v", err)
+	}
+	if _, err := satsjson.Marshal(testShape{}); !errors.Is(err, ErrEmptySum) {
+		t.Fatalf("expected empty sum to fail, got %v", err)
+	}
+	if data, err := json.Marshal(testShape{}); err != nil || string(data) != "null" {
+		t.Fatalf("expected empty sum to encode as null, got %s (%v)", data, err)
+	}
+
+	var shape testShape
+	if err := bsatn.Unmarshal([]byte{3}, &shape); !errors.Is(err, bsatn.ErrInvalidTag) {
+		t.Fatalf("expected invalid tag, got %v", err)
+	}
+	if err := satsjson.Unmarshal([]byte(`{"Square":1}`), &shape); !errors.Is(err, satsjson.ErrInvalidTag) {
+		t.Fatalf("expected invalid tag, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"tag":"Square"}`), &shape); err == nil {
+		t.Fatalf("expected unknown json tag to fail")
+	}
+	if err := json.Unmarshal([]byte(`null`), &shape); err != nil || shape.Variant() != nil {
+		t.Fatalf("expected null to leave an empty sum, got %v (%v)", shape, err)
+	}
+}
@@ -0,0 +1,233 @@
+package types
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+// Timestamp is a point in time with microsecond precision, stored as
+// microseconds since the Unix epoch. On the wire it is an i64.
+type Timestamp struct {
+	micros int64
+}
+
+// TimeDuration is a signed span of time with microsecond precision. On the
+// wire it is an i64 number of microseconds.
+type TimeDuration struct {
+	micros int64
+}
+
+// UnixEpoch is the Timestamp of 1970-01-01T00:00:00Z.
+var UnixEpoch = Timestamp{}
+
+// TimestampFromMicros returns the Timestamp micros microseconds after the Unix epoch.
+func TimestampFromMicros(micros int64) Timestamp {
+	return Timestamp{micros: micros}
+}
+
+// TimestampFromTime converts t, truncating it to whole microseconds.
+func TimestampFromTime(t time.Time) Timestamp {
+	return Timestamp{micros: t.UnixMicro()}
+}
+
+// ParseTimestamp parses an RFC 3339 timestamp.
+func ParseTimestamp(s string) (Timestamp, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("timestamp: %w", err)
+	}
+	return TimestampFromTime(t), nil
+}
+
+// Micros returns the number of microseconds since the Unix epoch.
+func (t Timestamp) Micros() int64 {
+	return t.micros
+}
+
+// Time returns t as a UTC time.Time.
+func (t Timestamp) Time() time.Time {
+	return time.UnixMicro(t.micros).UTC()
+}
+
+// String formats t as RFC 3339 with microsecond precision.
+func (t Timestamp) String() string {
+	return t.Time().Format("2006-01-02T15:04:05.000000Z07:00")
+}
+
+// Add returns t shifted by d.
+func (t Timestamp) Add(d TimeDuration) Timestamp {
+	return Timestamp{micros: t.micros + d.micros}
+}
+
+// Sub returns the duration t-u.
+func (t Timestamp) Sub(u Timestamp) TimeDuration {
+	return TimeDuration{micros: t.micros - u.micros}
+}
+
+// Compare orders timestamps chronologically.
+func (t Timestamp) Compare(other Timestamp) int {
+	return cmp.Compare(t.micros, other.micros)
+}
+
+func (t Timestamp) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Timestamp) UnmarshalText(text []byte) error {
+	parsed, err := ParseTimestamp(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+func (t Timestamp) MarshalBSATN(w *bsatn.Writer) error {
+	w.WriteI64(t.micros)
+	return nil
+}
+
+func (t *Timestamp) UnmarshalBSATN(r *bsatn.Reader) error {
+	micros, err := r.ReadI64()
+	if err != nil {
+		return err
+	}
+	t.micros = micros
+	return nil
+}
+
+// MarshalSATSJSON writes t as {"__timestamp_micros_since_unix_epoch__": micros}.
+func (t Timestamp) MarshalSATSJSON() ([]byte, error) {
+	return satsjson.EncodeSpecial(timestampTag, []byte(strconv.FormatInt(t.micros, 10))), nil
+}
+
+// UnmarshalSATSJSON reads the product written by MarshalSATSJSON or a bare
+// RFC 3339 string.
+func (t *Timestamp) UnmarshalSATSJSON(data []byte) error {
+	micros, err := unmarshalMicros(data, timestampTag, "timestamp", func(s string) (int64, error) {
+		parsed, err := ParseTimestamp(s)
+		return parsed.micros, err
+	})
+	if err != nil {
+		return err
+	}
+	t.micros = micros
+	return nil
+}
+
+// TimeDurationFromMicros returns a TimeDuration of micros microseconds.
+func TimeDurationFromMicros(micros int64) TimeDuration {
+	return TimeDuration{micros: micros}
+}
+
+// TimeDurationFromDuration converts d, truncating it to whole microseconds.
+func TimeDurationFromDuration(d time.Duration) TimeDuration {
+	return TimeDuration{micros: d.Microseconds()}
+}
+
+// ParseTimeDuration parses a duration in time.ParseDuration syntax, such as
+// "1.5s" or "-250us".
+func ParseTimeDuration(s string) (TimeDuration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return TimeDuration{}, fmt.Errorf("time duration: %w", err)
+	}
+	return TimeDurationFromDuration(d), nil
+}
+
+// Micros returns the duration in microseconds.
+func (d TimeDuration) Micros() int64 {
+	return d.micros
+}
+
+// Duration returns d as a time.Duration, saturating at its range.
+func (d TimeDuration) Duration() time.Duration {
+	const maxMicros = int64(1<<63-1) / int64(time.Microsecond)
+	switch {
+	case d.micros > maxMicros:
+		return time.Duration(1<<63 - 1)
+	case d.micros < -maxMicros:
+		return time.Duration(-1 << 63)
+	}
+	return time.Duration(d.micros) * time.Microsecond
+}
+
+// String formats d like time.Duration.
+func (d TimeDuration) String() string {
+	return d.Duration().String()
+}
+
+// Compare orders durations by length, negative durations first.
+func (d TimeDuration) Compare(other TimeDuration) int {
+	return cmp.Compare(d.micros, other.micros)
+}
+
+func (d TimeDuration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *TimeDuration) UnmarshalText(text []byte) error {
+	parsed, err := ParseTimeDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d TimeDuration) MarshalBSATN(w *bsatn.Writer) error {
+	w.WriteI64(d.micros)
+	return nil
+}
+
+func (d *TimeDuration) UnmarshalBSATN(r *bsatn.Reader) error {
+	micros, err := r.ReadI64()
+	if err != nil {
+		return err
+	}
+	d.micros = micros
+	return nil
+}
+
+// MarshalSATSJSON writes d as {"__time_duration_micros__": micros}.
+func (d TimeDuration) MarshalSATSJSON() ([]byte, error) {
+	return satsjson.EncodeSpecial(timeDurationTag, []byte(strconv.FormatInt(d.micros, 10))), nil
+}
+
+// UnmarshalSATSJSON reads the product written by MarshalSATSJSON or a bare
+// string in time.ParseDuration syntax.
+func (d *TimeDuration) UnmarshalSATSJSON(data []byte) error {
+	micros, err := unmarshalMicros(data, timeDurationTag, "time duration", func(s string) (int64, error) {
+		parsed, err := ParseTimeDuration(s)
+		return parsed.micros, err
+	})
+	if err != nil {
+		return err
+	}
+	d.micros = micros
+	return nil
+}
+
+// unmarshalMicros reads the i64 inside a Timestamp or TimeDuration product, or
+// hands a bare string to parseBare.
+func unmarshalMicros(data []byte, tag, name string, parseBare func(string) (int64, error)) (int64, error) {
+	inner, wrapped, err := satsjson.DecodeSpecial(data, tag)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if !wrapped {
+		if s, err := satsjson.DecodeString(inner); err == nil {
+			return parseBare(s)
+		}
+	}
+	micros, err := satsjson.ParseInt(inner, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return micros, nil
+}
@@ -0,0 +1,122 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+func TestTimestampConversions(t *testing.T) {
+	ts := TimestampFromTime(time.Date(2024, 1, 2, 3, 4, 5, 678_901_234, time.UTC))
+	if ts.Micros() != 1704164645678901 {
+		t.Fatalf("unexpected micros: %d", ts.Micros())
+	}
+	if ts.String() != "2024-01-02T03:04:05.678901Z" {
+		t.Fatalf("unexpected timestamp string: %s", ts)
+	}
+
+	parsed, err := ParseTimestamp(ts.String())
+	if err != nil || parsed != ts {
+		t.Fatalf("parse round trip mismatch: %s %v", parsed, err)
+	}
+	if _, err := ParseTimestamp("yesterday"); err == nil {
+		t.Fatalf("expected invalid timestamp to fail")
+	}
+
+	later := ts.Add(TimeDurationFromDuration(1500 * time.Millisecond))
+	if later.Sub(ts).Duration() != 1500*time.Millisecond {
+		t.Fatalf("unexpected difference: %s", later.Sub(ts))
+	}
+	if ts.Compare(later) >= 0 || later.Compare(ts) <= 0 {
+		t.Fatalf("timestamps should compare chronologically")
+	}
+	if !UnixEpoch.Time().Equal(time.Unix(0, 0)) {
+		t.Fatalf("unexpected epoch: %s", UnixEpoch)
+	}
+}
+
+func TestTimestampEncoding(t *testing.T) {
+	type row struct {
+		At      Timestamp
+		Elapsed TimeDuration
+	}
+	in := row{At: TimestampFromMicros(-1), Elapsed: TimeDurationFromMicros(1500)}
+
+	encoded, err := bsatn.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xdc, 0x05, 0, 0, 0, 0, 0, 0}
+	if string(encoded) != string(want) {
+		t.Fatalf("unexpected encoding: %v", encoded)
+	}
+	var out row
+	if err := bsatn.Unmarshal(encoded, &out); err != nil || out != in {
+		t.Fatalf("bsatn round trip mismatch: %+v %v", out, err)
+	}
+
+	raw, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal json: %v", err)
+	}
+	if string(raw) != `{"At":"1969-12-31T23:59:59.999999Z","Elapsed":"1.5ms"}` {
+		t.Fatalf("unexpected json: %s", raw)
+	}
+	out = row{}
+	if err := json.Unmarshal(raw, &out); err != nil || out != in {
+		t.Fatalf("json round trip mismatch: %+v %v", out, err)
+	}
+}
+
+func TestTimeDurationSaturates(t *testing.T) {
+	huge := TimeDurationFromMicros(1 << 62)
+	if huge.Duration() != time.Duration(1<<63-1) {
+		t.Fatalf("expected saturated duration, got %d", huge.Duration())
+	}
+	if TimeDurationFromMicros(-1<<62).Duration() != time.Duration(-1<<63) {
+		t.Fatalf("expected saturated negative duration")
+	}
+	if _, err := ParseTimeDuration("soon"); err == nil {
+		t.Fatalf("expected invalid duration to fail")
+	}
+}
+
+func TestTimestampSATSJSON(t *testing.T) {
+	ts := TimestampFromMicros(1_700_000_000_123_456)
+	encoded, err := satsjson.Marshal(ts)
+	if err != nil {
+		t.Fatalf("marshal timestamp: %v", err)
+	}
+	if string(encoded) != `{"__timestamp_micros_since_unix_epoch__":1700000000123456}` {
+		t.Fatalf("unexpected timestamp json: %s", encoded)
+	}
+	for _, form := range []string{string(encoded), `"` + ts.String() + `"`} {
+		var decoded Timestamp
+		if err := satsjson.Unmarshal([]byte(form), &decoded); err != nil || decoded != ts {
+			t.Fatalf("decode %s = %s (%v)", form, decoded, err)
+		}
+	}
+
+	d := TimeDurationFromMicros(-1500)
+	encoded, err = satsjson.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal duration: %v", err)
+	}
+	if string(encoded) != `{"__time_duration_micros__":-1500}` {
+		t.Fatalf("unexpected duration json: %s", encoded)
+	}
+	for _, form := range []string{string(encoded), `"-1.5ms"`} {
+		var decoded TimeDuration
+		if err := satsjson.Unmarshal([]byte(form), &decoded); err != nil || decoded != d {
+			t.Fatalf("decode %s = %s (%v)", form, decoded, err)
+		}
+	}
+
+	var bad Timestamp
+	if err := satsjson.Unmarshal([]byte(`{"__timestamp_micros_since_unix_epoch__":1.5}`), &bad); err == nil {
+		t.Fatalf("expected fractional micros to fail")
+	}
+}
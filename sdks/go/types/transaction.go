@@ -1,16 +1,21 @@
 package types
 
-// Row stores a single serialized row payload keyed by row primary key.
+// Row stores a single serialized row payload. Key identifies the row in
+// tables the cache has no primary key for; see cache.Store.DefineTable.
 type Row struct {
 	Key  string
 	Data []byte
 }
 
 // TableMutation describes inserts/deletes for one table in a transaction.
+// QuerySet names the subscription the rows belong to; a row stays cached
+// while any query set still holds it. Deletes carry the deleted row data so
+// that the primary key of a row can be read from it.
 type TableMutation struct {
-	Table   string
-	Inserts []Row
-	Deletes []string
+	Table    string
+	QuerySet uint32
+	Inserts  []Row
+	Deletes  []Row
 }
 
 // Transaction is an atomic set of table mutations.
@@ -0,0 +1,99 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+// Uuid is a 128-bit UUID in RFC 4122 byte order. On the wire it is the
+// little-endian u128 whose big-endian bytes are the UUID bytes.
+type Uuid [16]byte
+
+// ParseUuid parses the canonical 8-4-4-4-12 form, or 32 hex characters
+// without hyphens.
+func ParseUuid(s string) (Uuid, error) {
+	var u Uuid
+	switch len(s) {
+	case 32:
+	case 36:
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, fmt.Errorf("uuid: malformed %q", s)
+		}
+		s = s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	default:
+		return u, fmt.Errorf("uuid: malformed %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+		return u, fmt.Errorf("uuid: %w", err)
+	}
+	return u, nil
+}
+
+// String returns the canonical lowercase 8-4-4-4-12 form.
+func (u Uuid) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// IsZero reports whether u is the nil UUID.
+func (u Uuid) IsZero() bool {
+	return u == Uuid{}
+}
+
+// Compare orders UUIDs by their bytes.
+func (u Uuid) Compare(other Uuid) int {
+	return bytes.Compare(u[:], other[:])
+}
+
+func (u Uuid) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *Uuid) UnmarshalText(text []byte) error {
+	parsed, err := ParseUuid(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+func (u Uuid) MarshalBSATN(w *bsatn.Writer) error {
+	writeReversed(w, u[:])
+	return nil
+}
+
+func (u *Uuid) UnmarshalBSATN(r *bsatn.Reader) error {
+	return readReversed(r, u[:])
+}
+
+// MarshalSATSJSON writes u the way the server does, as the u128 inside its
+// product: {"__uuid__": 1234}.
+func (u Uuid) MarshalSATSJSON() ([]byte, error) {
+	var words [2]uint64
+	wordsFromBytes(words[:], u[:])
+	return satsjson.EncodeSpecial(uuidTag, wordsToJSON(words[:], false)), nil
+}
+
+// UnmarshalSATSJSON reads the product written by MarshalSATSJSON or a bare
+// UUID string.
+func (u *Uuid) UnmarshalSATSJSON(data []byte) error {
+	return unmarshalSpecialWord(u[:], data, uuidTag, "uuid", func(s string) error {
+		parsed, err := ParseUuid(s)
+		*u = parsed
+		return err
+	})
+}
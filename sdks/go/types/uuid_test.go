@@ -0,0 +1,78 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/bsatn"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+func TestParseUuid(t *testing.T) {
+	u, err := ParseUuid("0190d4f2-7c3a-7def-8abc-0123456789ab")
+	if err != nil {
+		t.Fatalf("parse uuid: %v", err)
+	}
+	if u[0] != 0x01 || u[15] != 0xab {
+		t.Fatalf("unexpected uuid bytes: %v", u)
+	}
+	if u.String() != "0190d4f2-7c3a-7def-8abc-0123456789ab" {
+		t.Fatalf("unexpected uuid string: %s", u)
+	}
+
+	compact, err := ParseUuid("0190d4f27c3a7def8abc0123456789ab")
+	if err != nil || compact != u {
+		t.Fatalf("compact form should parse to the same uuid: %s %v", compact, err)
+	}
+
+	for _, bad := range []string{"", "0190d4f2_7c3a_7def_8abc_0123456789ab", "0190d4f2-7c3a-7def-8abc-0123456789ag"} {
+		if _, err := ParseUuid(bad); err == nil {
+			t.Fatalf("expected %q to fail parsing", bad)
+		}
+	}
+}
+
+func TestUuidEncoding(t *testing.T) {
+	u, _ := ParseUuid("0190d4f2-7c3a-7def-8abc-0123456789ab")
+
+	encoded, err := bsatn.Marshal(u)
+	if err != nil {
+		t.Fatalf("marshal uuid: %v", err)
+	}
+	if encoded[0] != 0xab || encoded[15] != 0x01 {
+		t.Fatalf("uuid should encode as a little-endian u128: %v", encoded)
+	}
+	var decoded Uuid
+	if err := bsatn.Unmarshal(encoded, &decoded); err != nil || decoded != u {
+		t.Fatalf("bsatn round trip mismatch: %s %v", decoded, err)
+	}
+
+	raw, err := json.Marshal(u)
+	if err != nil {
+		t.Fatalf("marshal json: %v", err)
+	}
+	if string(raw) != `"0190d4f2-7c3a-7def-8abc-0123456789ab"` {
+		t.Fatalf("unexpected json: %s", raw)
+	}
+	decoded = Uuid{}
+	if err := json.Unmarshal(raw, &decoded); err != nil || decoded != u {
+		t.Fatalf("json round trip mismatch: %s %v", decoded, err)
+	}
+}
+
+func TestUuidSATSJSON(t *testing.T) {
+	u, _ := ParseUuid("00000000-0000-0000-0000-000000000100")
+	encoded, err := satsjson.Marshal(u)
+	if err != nil {
+		t.Fatalf("marshal uuid: %v", err)
+	}
+	if string(encoded) != `{"__uuid__":256}` {
+		t.Fatalf("unexpected uuid json: %s", encoded)
+	}
+	for _, form := range []string{string(encoded), `"` + u.String() + `"`} {
+		var decoded Uuid
+		if err := satsjson.Unmarshal([]byte(form), &decoded); err != nil || decoded != u {
+			t.Fatalf("decode %s = %s (%v)", form, decoded, err)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/satsjson"
+)
+
+// The 128- and 256-bit integer types store their value as little-endian
+// 64-bit words. Signed types use two's complement, so addition, subtraction
+// and multiplication are shared with the unsigned types and, like Go's
+// built-in integers, wrap around on overflow.
+
+func addWords(z, x, y []uint64) {
+	var carry uint64
+	for i := range z {
+		z[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+}
+
+func subWords(z, x, y []uint64) {
+	var borrow uint64
+	for i := range z {
+		z[i], borrow = bits.Sub64(x[i], y[i], borrow)
+	}
+}
+
+// mulWords sets z to x*y truncated to len(z) words. z must not alias x or y.
+func mulWords(z, x, y []uint64) {
+	clear(z)
+	for i := range x {
+		if x[i] == 0 {
+			continue
+		}
+		var carry uint64
+		for j := 0; i+j < len(z); j++ {
+			hi, lo := bits.Mul64(x[i], y[j])
+			var c uint64
+			lo, c = bits.Add64(lo, z[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			z[i+j] = lo
+			carry = hi
+		}
+	}
+}
+
+func negWords(z, x []uint64) {
+	var borrow uint64
+	for i := range z {
+		z[i], borrow = bits.Sub64(0, x[i], borrow)
+	}
+}
+
+func cmpWords(x, y []uint64) int {
+	for i := len(x) - 1; i >= 0; i-- {
+		switch {
+		case x[i] < y[i]:
+			return -1
+		case x[i] > y[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+func cmpSignedWords(x, y []uint64) int {
+	xNeg, yNeg := isNegativeWords(x), isNegativeWords(y)
+	switch {
+	case xNeg && !yNeg:
+		return -1
+	case !xNeg && yNeg:
+		return 1
+	}
+	return cmpWords(x, y)
+}
+
+func isZeroWords(x []uint64) bool {
+	for _, w := range x {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isNegativeWords(x []uint64) bool {
+	return int64(x[len(x)-1]) < 0
+}
+
+// divWords sets q = x / y and r = x % y for unsigned x and y using binary long
+// division. It panics if y is zero, like Go's integer division.
+func divWords(q, r, x, y []uint64) {
+	if isZeroWords(y) {
+		panic("integer divide by zero")
+	}
+	n := len(x)
+	var quo, rem [4]uint64
+	for i := n*64 - 1; i >= 0; i-- {
+		shiftLeftOne(rem[:n])
+		rem[0] |= (x[i/64] >> (i % 64)) & 1
+		if cmpWords(rem[:n], y) >= 0 {
+			subWords(rem[:n], rem[:n], y)
+			quo[i/64] |= 1 << (i % 64)
+		}
+	}
+	copy(q, quo[:n])
+	copy(r, rem[:n])
+}
+
+// divSignedWords divides two's complement x by y, truncating towards zero.
+func divSignedWords(q, r, x, y []uint64) {
+	n := len(x)
+	var ax, ay [4]uint64
+	copy(ax[:n], x)
+	copy(ay[:n], y)
+	xNeg, yNeg := isNegativeWords(x), isNegativeWords(y)
+	if xNeg {
+		negWords(ax[:n], ax[:n])
+	}
+	if yNeg {
+		negWords(ay[:n], ay[:n])
+	}
+	divWords(q, r, ax[:n], ay[:n])
+	if xNeg != yNeg {
+		negWords(q, q)
+	}
+	if xNeg {
+		negWords(r, r)
+	}
+}
+
+func shiftLeftOne(x []uint64) {
+	for i := len(x) - 1; i > 0; i-- {
+		x[i] = x[i]<<1 | x[i-1]>>63
+	}
+	x[0] <<= 1
+}
+
+func wordsToBig(x []uint64, signed bool) *big.Int {
+	n := len(x)
+	var abs [4]uint64
+	copy(abs[:n], x)
+	neg := signed && isNegativeWords(x)
+	if neg {
+		negWords(abs[:n], abs[:n])
+	}
+	be := make([]byte, n*8)
+	for i := 0; i < n; i++ {
+		w := abs[i]
+		for b := 0; b < 8; b++ {
+			be[len(be)-1-(i*8+b)] = byte(w >> (8 * b))
+		}
+	}
+	out := new(big.Int).SetBytes(be)
+	if neg {
+		out.Neg(out)
+	}
+	return out
+}
+
+func wordsFromBig(z []uint64, v *big.Int, signed bool, typeName string) error {
+	if v == nil {
+		return fmt.Errorf("%s: nil big.Int", typeName)
+	}
+	n := len(z)
+	bitsLen := n * 64
+	if signed {
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(bitsLen-1))
+		if v.Cmp(limit) >= 0 || v.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return fmt.Errorf("%s: %s out of range", typeName, v)
+		}
+	} else if v.Sign() < 0 || v.BitLen() > bitsLen {
+		return fmt.Errorf("%s: %s out of range", typeName, v)
+	}
+
+	abs := new(big.Int).Abs(v)
+	be := abs.FillBytes(make([]byte, n*8))
+	for i := 0; i < n; i++ {
+		var w uint64
+		for b := 0; b < 8; b++ {
+			w |= uint64(be[len(be)-1-(i*8+b)]) << (8 * b)
+		}
+		z[i] = w
+	}
+	if v.Sign() < 0 {
+		negWords(z, z)
+	}
+	return nil
+}
+
+func parseWords(z []uint64, s string, signed bool, typeName string) error {
+	v, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return fmt.Errorf("%s: invalid integer %q", typeName, s)
+	}
+	return wordsFromBig(z, v, signed, typeName)
+}
+
+// wordsToJSON writes x in SATS-JSON. Like the server, it writes 128-bit
+// integers as JSON numbers and 256-bit integers as 0x-prefixed hex strings.
+func wordsToJSON(x []uint64, signed bool) []byte {
+	v := wordsToBig(x, signed)
+	if len(x) <= 2 {
+		return []byte(v.String())
+	}
+	sign := ""
+	if v.Sign() < 0 {
+		sign = "-"
+		v.Neg(v)
+	}
+	return satsjson.EncodeString(sign + "0x" + v.Text(16))
+}
+
+// wordsFromJSON reads an integer written as a JSON number or as a decimal or
+// 0x-prefixed hex string.
+func wordsFromJSON(z []uint64, data []byte, signed bool, typeName string) error {
+	text, err := satsjson.IntegerText(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", typeName, err)
+	}
+	v, ok := new(big.Int).SetString(text, satsjson.IntegerBase(text))
+	if !ok {
+		return fmt.Errorf("%s: invalid integer %q", typeName, text)
+	}
+	return wordsFromBig(z, v, signed, typeName)
+}
+
+// wordsFromBytes converts big-endian bytes, as stored by Identity and
+// ConnectionId, to little-endian words.
+func wordsFromBytes(z []uint64, b []byte) {
+	for i := range z {
+		var w uint64
+		for j := 0; j < 8; j++ {
+			w |= uint64(b[len(b)-1-(i*8+j)]) << (8 * j)
+		}
+		z[i] = w
+	}
+}
+
+// wordsToBytes is the inverse of wordsFromBytes.
+func wordsToBytes(b []byte, x []uint64) {
+	for i, w := range x {
+		for j := 0; j < 8; j++ {
+			b[len(b)-1-(i*8+j)] = byte(w >> (8 * j))
+		}
+	}
+}
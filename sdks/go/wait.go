@@ -0,0 +1,48 @@
+package spacetimedb
+
+import (
+	"context"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/events"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/await"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+)
+
+// CallReducerWait calls reducer and blocks until its result arrives. A reducer
+// that fails on the server returns its result together with a
+// connection.ErrorCallFailed error.
+func (c *DbConnection) CallReducerWait(ctx context.Context, reducer string, args []byte) (clientapi.ReducerResult, error) {
+	return await.ReducerResult(await.Call(func(callback events.ResultCallback) (uint32, error) {
+		return c.CallReducer(ctx, reducer, args, callback)
+	}))
+}
+
+// CallProcedureWait calls procedure and blocks until its result arrives.
+func (c *DbConnection) CallProcedureWait(ctx context.Context, procedure string, args []byte) (clientapi.ProcedureResult, error) {
+	return await.ProcedureResult(await.Call(func(callback events.ResultCallback) (uint32, error) {
+		return c.CallProcedure(ctx, procedure, args, callback)
+	}))
+}
+
+// OneOffQueryWait runs query and blocks until its rows arrive.
+func (c *DbConnection) OneOffQueryWait(ctx context.Context, query string) (clientapi.QueryRows, error) {
+	return await.OneOffQueryRows(await.Call(func(callback events.ResultCallback) (uint32, error) {
+		return c.OneOffQuery(ctx, query, callback)
+	}))
+}
+
+// SubscribeWait subscribes to queryStrings and blocks until the subscription
+// is applied, returning its handle and initial rows. Later messages for the
+// subscription, including the changes reported after a reconnect, go to
+// callback, which may be nil.
+func (c *DbConnection) SubscribeWait(ctx context.Context, queryStrings []string, callback SubscriptionCallback) (uint32, clientapi.SubscribeApplied, error) {
+	return await.Applied(ctx,
+		func(route SubscriptionCallback) (uint32, error) {
+			return c.Subscribe(ctx, queryStrings, route)
+		},
+		func(handle uint32) {
+			_, _ = c.Unsubscribe(context.Background(), handle)
+		},
+		callback,
+	)
+}
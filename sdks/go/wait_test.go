@@ -0,0 +1,70 @@
+package spacetimedb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/clientapi"
+	"github.com/clockworklabs/spacetimedb/sdks/go/internal/protocol"
+	"github.com/gorilla/websocket"
+)
+
+func TestSubscribeWaitReturnsHandleAndForwardsUpdates(t *testing.T) {
+	server := newScriptedTestServer(t, func(_ int32, conn *websocket.Conn) {
+		subscribe := readClientMessage(t, conn)
+		if subscribe.QueryID == nil {
+			t.Errorf("expected a subscribe message, got %+v", subscribe)
+			return
+		}
+		queryID := *subscribe.QueryID
+		writeServerMessage(t, conn, subscribeApplied(subscribe.RequestID, queryID, "a"))
+		writeServerMessage(t, conn, clientapi.ServerMessageTransactionUpdate{Value: clientapi.TransactionUpdate{
+			QuerySets: []clientapi.QuerySetUpdate{querySetUpdate(queryID, []string{"b"}, nil)},
+		}})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	conn, err := NewDbConnectionBuilder().
+		WithURI(server.URL).
+		WithDatabaseName("db").
+		WithUseWebsocketToken(false).
+		Build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer conn.Disconnect()
+
+	updates := make(chan protocol.RoutedMessage, 1)
+	handle, applied, err := conn.SubscribeWait(context.Background(), []string{"select * from users"}, func(message protocol.RoutedMessage, err error) {
+		// Disconnect at the end of the test fails the subscription; ignore that.
+		if err == nil {
+			updates <- message
+		}
+	})
+	if err != nil {
+		t.Fatalf("subscribe wait: %v", err)
+	}
+	if rows, err := protocol.SplitRows(applied.Rows.Tables[0].Rows); err != nil || !reflect.DeepEqual(rows, [][]byte{[]byte("a")}) {
+		t.Fatalf("unexpected initial rows %q: %v", rows, err)
+	}
+
+	select {
+	case message := <-updates:
+		update, ok := message.AsTransactionUpdate()
+		if !ok || message.QueryID == nil || *message.QueryID != handle {
+			t.Fatalf("expected a transaction_update for handle %d, got %s %v", handle, message.Kind, message.QueryID)
+		}
+		if inserts, _ := tableRows(t, update.QuerySets[0].Tables[0]); !reflect.DeepEqual(inserts, [][]byte{[]byte("b")}) {
+			t.Fatalf("unexpected update inserts: %q", inserts)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for forwarded update")
+	}
+}